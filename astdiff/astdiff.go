@@ -0,0 +1,369 @@
+// Package astdiff reports the structural differences between two parsed files.
+//
+// The nodes are matched by identity rather than by position: messages, enums and services by their name path,
+// fields by their number, enum values and RPCs by their name, and options by their name. Hence moving or
+// reformatting a definition is not a difference, and a renamed field is a modification of the field.
+package astdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// Kind is the kind of a change.
+type Kind string
+
+// The kinds.
+const (
+	Added    Kind = "added"
+	Removed  Kind = "removed"
+	Modified Kind = "modified"
+)
+
+// Change is a difference of a node.
+type Change struct {
+	Kind Kind `json:"kind"`
+	// Node is the node type, like "message", "field" or "enum value".
+	Node string `json:"node"`
+	// Path is the name of the node qualified by the enclosing definitions, like "Outer.Inner.name".
+	// A modified node has its name after the change.
+	Path string `json:"path"`
+	// Before is the meta of the node in the old file. It is nil for an added node.
+	Before *meta.Meta `json:"before,omitempty"`
+	// After is the meta of the node in the new file. It is nil for a removed node.
+	After *meta.Meta `json:"after,omitempty"`
+	// Details are the modified attributes of a modified node.
+	Details []*Detail `json:"details,omitempty"`
+}
+
+// Detail is a modified attribute.
+type Detail struct {
+	// Attribute is the name of the attribute, like "type" or "comments".
+	Attribute string `json:"attribute"`
+	Before    string `json:"before"`
+	After     string `json:"after"`
+}
+
+type differ struct {
+	comments bool
+}
+
+// Option is an option for Diff.
+type Option func(*differ)
+
+// WithComments is an option to report the changes of the comments, which are ignored by default.
+func WithComments(comments bool) Option {
+	return func(d *differ) {
+		d.comments = comments
+	}
+}
+
+// Diff returns the changes from before to after. The added and modified nodes are in the order of after,
+// followed by the removed nodes in the order of before. The nodes inside an added or removed node are
+// not reported separately.
+func Diff(before, after *parser.Proto, options ...Option) []*Change {
+	d := &differ{}
+	for _, opt := range options {
+		opt(d)
+	}
+	old := d.flatten(before)
+	new := d.flatten(after)
+
+	oldByKey := make(map[string]*entry)
+	for _, e := range old.entries {
+		oldByKey[e.key] = e
+	}
+	newByKey := make(map[string]*entry)
+	for _, e := range new.entries {
+		newByKey[e.key] = e
+	}
+
+	var changes []*Change
+	added := make(map[string]bool)
+	for _, e := range new.entries {
+		o, ok := oldByKey[e.key]
+		if !ok {
+			added[e.key] = true
+			if !added[e.parent] {
+				changes = append(changes, &Change{Kind: Added, Node: e.node, Path: e.path, After: e.meta})
+			}
+			continue
+		}
+		if details := compare(o.attrs, e.attrs); 0 < len(details) {
+			changes = append(changes, &Change{Kind: Modified, Node: e.node, Path: e.path, Before: o.meta, After: e.meta, Details: details})
+		}
+	}
+	removed := make(map[string]bool)
+	for _, e := range old.entries {
+		if _, ok := newByKey[e.key]; ok {
+			continue
+		}
+		removed[e.key] = true
+		if !removed[e.parent] {
+			changes = append(changes, &Change{Kind: Removed, Node: e.node, Path: e.path, Before: e.meta})
+		}
+	}
+	return changes
+}
+
+func compare(before, after []*attr) []*Detail {
+	var details []*Detail
+	for i, a := range after {
+		if before[i].value != a.value {
+			details = append(details, &Detail{Attribute: a.name, Before: before[i].value, After: a.value})
+		}
+	}
+	return details
+}
+
+// WriteText writes the changes one per line, like "~ field Item.name at a.proto:3:3 -> a.proto:4:3",
+// followed by the indented details. "+" is added, "-" is removed and "~" is modified.
+func WriteText(w io.Writer, changes []*Change) error {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(&b, "+ %s %s at %s\n", c.Node, c.Path, c.After.Pos)
+		case Removed:
+			fmt.Fprintf(&b, "- %s %s at %s\n", c.Node, c.Path, c.Before.Pos)
+		case Modified:
+			fmt.Fprintf(&b, "~ %s %s at %s -> %s\n", c.Node, c.Path, c.Before.Pos, c.After.Pos)
+		}
+		for _, detail := range c.Details {
+			fmt.Fprintf(&b, "    %s: %q -> %q\n", detail.Attribute, detail.Before, detail.After)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the changes as an indented JSON array.
+func WriteJSON(w io.Writer, changes []*Change) error {
+	if changes == nil {
+		changes = []*Change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(changes)
+}
+
+// entry is a node with its identity and its attributes to compare.
+type entry struct {
+	// key identifies the node in a file.
+	key    string
+	parent string
+	node   string
+	path   string
+	meta   *meta.Meta
+	attrs  []*attr
+}
+
+type attr struct {
+	name  string
+	value string
+}
+
+type flattener struct {
+	*differ
+	entries []*entry
+	seen    map[string]int
+}
+
+func (d *differ) flatten(p *parser.Proto) *flattener {
+	f := &flattener{differ: d, seen: make(map[string]int)}
+	if p.Syntax != nil {
+		f.add("", "syntax", "syntax", "", p.Syntax.Meta, p.Syntax.Comments, p.Syntax.InlineComment,
+			&attr{"version", p.Syntax.ProtobufVersion})
+	}
+	f.body("", "", p.ProtoBody)
+	return f
+}
+
+// add adds the node. A key which is already added, like of a repeated option, is suffixed by its count.
+func (f *flattener) add(parent, key, node, path string, m meta.Meta, comments []*parser.Comment, inline *parser.Comment, attrs ...*attr) string {
+	f.seen[key]++
+	if n := f.seen[key]; 1 < n {
+		key += "#" + strconv.Itoa(n)
+	}
+	if f.comments {
+		attrs = append(attrs, &attr{"comments", commentText(comments, inline)})
+	}
+	f.entries = append(f.entries, &entry{
+		key:    key,
+		parent: parent,
+		node:   node,
+		path:   path,
+		meta:   &m,
+		attrs:  attrs,
+	})
+	return key
+}
+
+func commentText(comments []*parser.Comment, inline *parser.Comment) string {
+	var lines []string
+	for _, c := range comments {
+		lines = append(lines, c.Raw)
+	}
+	if inline != nil {
+		lines = append(lines, inline.Raw)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// body adds the nodes of a body. scope is the key of the enclosing node and path is its name path.
+func (f *flattener) body(scope, path string, body []parser.Visitee) {
+	for _, v := range body {
+		f.node(scope, path, v, "")
+	}
+}
+
+func qualify(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// node adds the node. oneof is the name of the enclosing oneof of a oneof field.
+func (f *flattener) node(scope, path string, v parser.Visitee, oneof string) {
+	switch n := v.(type) {
+	case *parser.Package:
+		f.add(scope, "package", "package", n.Name, n.Meta, n.Comments, n.InlineComment, &attr{"name", n.Name})
+	case *parser.Import:
+		f.add(scope, "import "+n.Location, "import", n.Location, n.Meta, n.Comments, n.InlineComment,
+			&attr{"modifier", importModifier(n.Modifier)})
+	case *parser.Option:
+		f.add(scope, scope+" option "+n.OptionName, "option", qualify(path, n.OptionName), n.Meta, n.Comments, n.InlineComment,
+			&attr{"value", optionValue(n)})
+	case *parser.Message:
+		name := qualify(path, n.MessageName)
+		key := f.add(scope, "message "+name, "message", name, n.Meta, n.Comments, n.InlineComment)
+		f.body(key, name, n.MessageBody)
+	case *parser.Field:
+		label := ""
+		if n.IsRepeated {
+			label = "repeated"
+		}
+		f.field(scope, path, n.FieldName, n.FieldNumber, n.Meta, n.Comments, n.InlineComment,
+			&attr{"label", label}, &attr{"type", n.Type}, &attr{"oneof", oneof}, &attr{"options", fieldOptions(n.FieldOptions)})
+	case *parser.MapField:
+		f.field(scope, path, n.MapName, n.FieldNumber, n.Meta, n.Comments, n.InlineComment,
+			&attr{"label", "map"}, &attr{"type", "map<" + n.KeyType + ", " + n.Type + ">"}, &attr{"oneof", oneof}, &attr{"options", fieldOptions(n.FieldOptions)})
+	case *parser.OneofField:
+		f.field(scope, path, n.FieldName, n.FieldNumber, n.Meta, n.Comments, n.InlineComment,
+			&attr{"label", ""}, &attr{"type", n.Type}, &attr{"oneof", oneof}, &attr{"options", fieldOptions(n.FieldOptions)})
+	case *parser.Oneof:
+		f.add(scope, scope+" oneof "+n.OneofName, "oneof", qualify(path, n.OneofName), n.Meta, n.Comments, n.InlineComment)
+		// The oneof fields are matched as the fields of the message, so that moving a field into a oneof is a modification.
+		for _, field := range n.OneofFields {
+			f.node(scope, path, field, n.OneofName)
+		}
+	case *parser.Enum:
+		name := qualify(path, n.EnumName)
+		key := f.add(scope, "enum "+name, "enum", name, n.Meta, n.Comments, n.InlineComment)
+		f.body(key, name, n.EnumBody)
+	case *parser.EnumField:
+		var options []string
+		for _, opt := range n.EnumValueOptions {
+			options = append(options, opt.OptionName+" = "+opt.Constant)
+		}
+		f.add(scope, scope+" value "+n.Ident, "enum value", qualify(path, n.Ident), n.Meta, n.Comments, n.InlineComment,
+			&attr{"number", normalizeNumber(n.Number)}, &attr{"options", strings.Join(options, ", ")})
+	case *parser.Reserved:
+		text := reservedText(n)
+		f.add(scope, scope+" reserved "+text, "reserved", qualify(path, text), n.Meta, n.Comments, n.InlineComment)
+	case *parser.Extend:
+		name := qualify(path, "extend "+n.MessageType)
+		key := f.add(scope, scope+" extend "+n.MessageType, "extend", name, n.Meta, n.Comments, n.InlineComment)
+		f.body(key, name, n.ExtendBody)
+	case *parser.Service:
+		key := f.add(scope, "service "+n.ServiceName, "service", n.ServiceName, n.Meta, n.Comments, n.InlineComment)
+		f.body(key, n.ServiceName, n.ServiceBody)
+	case *parser.RPC:
+		name := qualify(path, n.RPCName)
+		key := f.add(scope, scope+" rpc "+n.RPCName, "rpc", name, n.Meta, n.Comments, n.InlineComment,
+			&attr{"request", messageType(n.RPCRequest.IsStream, n.RPCRequest.MessageType)},
+			&attr{"response", messageType(n.RPCResponse.IsStream, n.RPCResponse.MessageType)})
+		for _, option := range n.Options {
+			f.node(key, name, option, "")
+		}
+	}
+}
+
+// field adds a field matched by its number, so that a renamed field is modified.
+func (f *flattener) field(scope, path, name, number string, m meta.Meta, comments []*parser.Comment, inline *parser.Comment, attrs ...*attr) {
+	attrs = append([]*attr{{"name", name}}, attrs...)
+	f.add(scope, scope+" field "+normalizeNumber(number), "field", qualify(path, name), m, comments, inline, attrs...)
+}
+
+func normalizeNumber(number string) string {
+	if n, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return strconv.FormatInt(n, 10)
+	}
+	return number
+}
+
+func importModifier(modifier parser.ImportModifier) string {
+	switch modifier {
+	case parser.ImportModifierPublic:
+		return "public"
+	case parser.ImportModifierWeak:
+		return "weak"
+	}
+	return ""
+}
+
+func messageType(stream bool, name string) string {
+	if stream {
+		return "stream " + name
+	}
+	return name
+}
+
+func fieldOptions(options []*parser.FieldOption) string {
+	var texts []string
+	for _, opt := range options {
+		texts = append(texts, opt.OptionName+" = "+opt.Constant)
+	}
+	return strings.Join(texts, ", ")
+}
+
+// optionValue returns the constant of the option, or the fields of a google.api.http option.
+func optionValue(o *parser.Option) string {
+	if o.Endpoint == nil {
+		return o.Constant
+	}
+	var texts []string
+	for _, field := range o.Endpoint.Fields {
+		texts = append(texts, field.OptionName+": "+field.Constant)
+	}
+	for _, binding := range o.Endpoint.AdditionalBinding {
+		var fields []string
+		for _, field := range binding.Fields {
+			fields = append(fields, field.Name+": "+strings.Join(field.Values, ", "))
+		}
+		texts = append(texts, "additional_bindings {"+strings.Join(fields, " ")+"}")
+	}
+	return "{" + strings.Join(texts, " ") + "}"
+}
+
+func reservedText(r *parser.Reserved) string {
+	if len(r.FieldNames) != 0 {
+		return "reserved " + strings.Join(r.FieldNames, ", ")
+	}
+	var ranges []string
+	for _, rng := range r.Ranges {
+		if rng.End == "" {
+			ranges = append(ranges, normalizeNumber(rng.Begin))
+		} else {
+			ranges = append(ranges, normalizeNumber(rng.Begin)+" to "+normalizeNumber(rng.End))
+		}
+	}
+	return "reserved " + strings.Join(ranges, ", ")
+}
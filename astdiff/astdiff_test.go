@@ -0,0 +1,218 @@
+package astdiff_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/astdiff"
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+const before = `syntax = "proto3";
+package itempb;
+import "shared.proto";
+option go_package = "itempb";
+
+// Item is an item.
+message Item {
+  string title = 1;
+  int32 count = 2;
+  oneof condition {
+    double score = 3;
+  }
+  string note = 4;
+  reserved 10 to 20;
+}
+
+message Removed {
+  string name = 1;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 1;
+}
+
+service Items {
+  rpc Get(Item) returns (Item);
+  rpc Delete(Item) returns (Item);
+}
+`
+
+const after = `syntax = "proto3";
+package itempb;
+import public "shared.proto";
+option go_package = "itempb/v2";
+
+// Item is an item to sell.
+message Item {
+  string name = 1;
+  int64 count = 2 [deprecated = true];
+  double score = 3;
+  string note = 0x4;
+  reserved 10 to 20;
+
+  message Added {
+    string name = 1;
+  }
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 2;
+  STATUS_BAD = 3;
+}
+
+service Items {
+  rpc Get(Item) returns (stream Item);
+}
+`
+
+func parse(t *testing.T, filename, src string) *parser.Proto {
+	got, err := protoparser.Parse(strings.NewReader(src), protoparser.WithFilename(filename))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	return got
+}
+
+type change struct {
+	kind       astdiff.Kind
+	node       string
+	path       string
+	beforeLine int
+	afterLine  int
+	details    string
+}
+
+func simplify(changes []*astdiff.Change) []change {
+	var got []change
+	for _, c := range changes {
+		s := change{kind: c.Kind, node: c.Node, path: c.Path}
+		if c.Before != nil {
+			s.beforeLine = c.Before.Pos.Line
+		}
+		if c.After != nil {
+			s.afterLine = c.After.Pos.Line
+		}
+		var details []string
+		for _, d := range c.Details {
+			details = append(details, d.Attribute+": "+d.Before+" -> "+d.After)
+		}
+		s.details = strings.Join(details, "; ")
+		got = append(got, s)
+	}
+	return got
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []astdiff.Option
+		want    []change
+	}{
+		{
+			name: "without comments",
+			want: []change{
+				{astdiff.Modified, "import", `"shared.proto"`, 3, 3, "modifier:  -> public"},
+				{astdiff.Modified, "option", "go_package", 4, 4, `value: "itempb" -> "itempb/v2"`},
+				{astdiff.Modified, "field", "Item.name", 8, 8, "name: title -> name"},
+				{astdiff.Modified, "field", "Item.count", 9, 9, "type: int32 -> int64; options:  -> deprecated = true"},
+				{astdiff.Modified, "field", "Item.score", 11, 10, "oneof: condition -> "},
+				{astdiff.Added, "message", "Item.Added", 0, 14, ""},
+				{astdiff.Modified, "enum value", "Status.STATUS_OK", 23, 21, "number: 1 -> 2"},
+				{astdiff.Added, "enum value", "Status.STATUS_BAD", 0, 22, ""},
+				{astdiff.Modified, "rpc", "Items.Get", 27, 26, "response: Item -> stream Item"},
+				{astdiff.Removed, "oneof", "Item.condition", 10, 0, ""},
+				{astdiff.Removed, "message", "Removed", 17, 0, ""},
+				{astdiff.Removed, "rpc", "Items.Delete", 28, 0, ""},
+			},
+		},
+		{
+			name:    "with comments",
+			options: []astdiff.Option{astdiff.WithComments(true)},
+			want: []change{
+				{astdiff.Modified, "import", `"shared.proto"`, 3, 3, "modifier:  -> public"},
+				{astdiff.Modified, "option", "go_package", 4, 4, `value: "itempb" -> "itempb/v2"`},
+				{astdiff.Modified, "message", "Item", 7, 7, "comments: // Item is an item. -> // Item is an item to sell."},
+				{astdiff.Modified, "field", "Item.name", 8, 8, "name: title -> name"},
+				{astdiff.Modified, "field", "Item.count", 9, 9, "type: int32 -> int64; options:  -> deprecated = true"},
+				{astdiff.Modified, "field", "Item.score", 11, 10, "oneof: condition -> "},
+				{astdiff.Added, "message", "Item.Added", 0, 14, ""},
+				{astdiff.Modified, "enum value", "Status.STATUS_OK", 23, 21, "number: 1 -> 2"},
+				{astdiff.Added, "enum value", "Status.STATUS_BAD", 0, 22, ""},
+				{astdiff.Modified, "rpc", "Items.Get", 27, 26, "response: Item -> stream Item"},
+				{astdiff.Removed, "oneof", "Item.condition", 10, 0, ""},
+				{astdiff.Removed, "message", "Removed", 17, 0, ""},
+				{astdiff.Removed, "rpc", "Items.Delete", 28, 0, ""},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			changes := astdiff.Diff(parse(t, "before.proto", before), parse(t, "after.proto", after), test.options...)
+			got := simplify(changes)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiff_formatting(t *testing.T) {
+	reformatted := `syntax="proto3";package itempb;import "shared.proto";option go_package="itempb";
+// Item is an item,
+// reformatted.
+message Item { string title = 1; int32 count = 2; oneof condition { double score = 3; } string note = 4; reserved 10 to 20; }
+enum Status { STATUS_UNSPECIFIED = 0; STATUS_OK = 1; }
+service Items { rpc Delete(Item) returns (Item); rpc Get(Item) returns (Item); }
+message Removed { string name = 1; }
+`
+	got := astdiff.Diff(parse(t, "before.proto", before), parse(t, "after.proto", reformatted))
+	if len(got) != 0 {
+		t.Errorf("got %v, but want none", simplify(got))
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	changes := astdiff.Diff(parse(t, "before.proto", before), parse(t, "after.proto", after))
+	var b bytes.Buffer
+	if err := astdiff.WriteText(&b, changes[2:3]); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	want := `~ field Item.name at before.proto:8:3 -> after.proto:8:3
+    name: "title" -> "name"
+`
+	if got := b.String(); got != want {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	changes := astdiff.Diff(parse(t, "before.proto", before), parse(t, "after.proto", after))
+	var b bytes.Buffer
+	if err := astdiff.WriteJSON(&b, changes); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	var got []*astdiff.Change
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if !reflect.DeepEqual(simplify(got), simplify(changes)) {
+		t.Errorf("got %v, but want %v", simplify(got), simplify(changes))
+	}
+
+	b.Reset()
+	if err := astdiff.WriteJSON(&b, nil); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if got, want := b.String(), "[]\n"; got != want {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+}
@@ -0,0 +1,248 @@
+package protoparser
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+// FileResult is a result of parsing one file in a batch.
+type FileResult struct {
+	// Path is the path of the file. It is the import location itself if the import could not be found.
+	Path string
+	// Proto is the parsed file. It is nil if Err is not nil.
+	Proto *parser.Proto
+	// Err is the error which occurred while reading or parsing the file.
+	Err error
+	// Imported is true if the file was not requested but parsed because another file imports it.
+	Imported bool
+	// Imports are the paths of the files imported by Proto in order. They are set only if the import paths are set.
+	// The path of an import which is not found is its location.
+	Imports []string
+}
+
+// WithParallelism is an option to set the maximum number of files parsed at once by ParseFS and ParseFiles.
+// The default is runtime.GOMAXPROCS(0).
+func WithParallelism(parallelism int) Option {
+	return func(c *ParseConfig) {
+		c.parallelism = parallelism
+	}
+}
+
+// WithImportPaths is an option to make ParseFS and ParseFiles also parse the imported files.
+// Each import is looked up in importPaths in order, like the -I flag of protoc.
+// A file imported by several files is parsed only once.
+func WithImportPaths(importPaths ...string) Option {
+	return func(c *ParseConfig) {
+		c.importPaths = importPaths
+	}
+}
+
+// ParseFS parses the files at paths in fsys concurrently.
+//
+// The results are ordered deterministically: first the requested files in the order of paths without duplicates,
+// then the imported ones sorted by path. An error is returned only if ctx is done before all files are parsed.
+func ParseFS(ctx context.Context, fsys fs.FS, paths []string, options ...Option) ([]*FileResult, error) {
+	config := &ParseConfig{
+		permissive:  true,
+		parallelism: runtime.GOMAXPROCS(0),
+	}
+	for _, opt := range options {
+		opt(config)
+	}
+	if config.parallelism < 1 {
+		config.parallelism = 1
+	}
+
+	b := &batch{
+		ctx:     ctx,
+		fsys:    fsys,
+		config:  config,
+		results: make(map[string]*FileResult),
+	}
+	return b.run(ctx, paths)
+}
+
+// ParseFiles parses the files at the paths on the local file system concurrently.
+// See ParseFS for the details.
+func ParseFiles(ctx context.Context, paths []string, options ...Option) ([]*FileResult, error) {
+	return ParseFS(ctx, osFS{}, paths, options...)
+}
+
+// osFS opens any path on the local file system, including absolute ones which fs.FS forbids.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.FromSlash(name))
+}
+
+type batchJob struct {
+	path     string
+	imported bool
+}
+
+type batchDone struct {
+	result  *FileResult
+	imports []*FileResult
+}
+
+type batch struct {
+	ctx     context.Context
+	fsys    fs.FS
+	config  *ParseConfig
+	results map[string]*FileResult
+}
+
+func (b *batch) run(ctx context.Context, paths []string) ([]*FileResult, error) {
+	var requested []*FileResult
+	var pending []batchJob
+	for _, p := range paths {
+		if _, ok := b.results[p]; ok {
+			continue
+		}
+		result := &FileResult{Path: p}
+		b.results[p] = result
+		requested = append(requested, result)
+		pending = append(pending, batchJob{path: p})
+	}
+
+	jobs := make(chan batchJob)
+	dones := make(chan batchDone)
+	for i := 0; i < b.config.parallelism; i++ {
+		go func() {
+			for job := range jobs {
+				dones <- b.parse(job)
+			}
+		}()
+	}
+
+	var inflight int
+	var err error
+	for err == nil && (0 < len(pending) || 0 < inflight) {
+		var send chan batchJob
+		var next batchJob
+		if 0 < len(pending) {
+			send = jobs
+			next = pending[0]
+		}
+
+		select {
+		case send <- next:
+			pending = pending[1:]
+			inflight++
+		case done := <-dones:
+			inflight--
+			*b.results[done.result.Path] = *done.result
+			for _, imported := range done.imports {
+				if _, ok := b.results[imported.Path]; ok {
+					continue
+				}
+				b.results[imported.Path] = imported
+				if imported.Err == nil {
+					pending = append(pending, batchJob{path: imported.Path, imported: true})
+				}
+			}
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	close(jobs)
+	for ; 0 < inflight; inflight-- {
+		<-dones
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []*FileResult
+	for _, result := range b.results {
+		if result.Imported {
+			imported = append(imported, result)
+		}
+	}
+	sort.Slice(imported, func(i, j int) bool {
+		return imported[i].Path < imported[j].Path
+	})
+	return append(requested, imported...), nil
+}
+
+// parse parses the file of job and resolves its imports. It is called concurrently.
+func (b *batch) parse(job batchJob) batchDone {
+	result := &FileResult{
+		Path:     job.path,
+		Imported: job.imported,
+	}
+
+	f, err := b.fsys.Open(job.path)
+	if err != nil {
+		result.Err = err
+		return batchDone{result: result}
+	}
+	defer f.Close()
+
+	proto, err := ParseContext(b.ctx, f, b.fileOptions(job.path)...)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to parse %s: %w", job.path, err)
+		return batchDone{result: result}
+	}
+	result.Proto = proto
+
+	if len(b.config.importPaths) == 0 {
+		return batchDone{result: result}
+	}
+	var imports []*FileResult
+	for _, body := range proto.ProtoBody {
+		if i, ok := body.(*parser.Import); ok {
+			imported := b.resolveImport(i.Location)
+			imports = append(imports, imported)
+			result.Imports = append(result.Imports, imported.Path)
+		}
+	}
+	return batchDone{
+		result:  result,
+		imports: imports,
+	}
+}
+
+// fileOptions returns the options to parse a single file at path.
+func (b *batch) fileOptions(path string) []Option {
+	return []Option{
+		WithDebug(b.config.debug),
+		WithPermissive(b.config.permissive),
+		WithBodyIncludingComments(b.config.bodyIncludingComments),
+		WithFilename(path),
+		WithMaxInputSize(b.config.maxInputSize),
+		WithMaxDepth(b.config.maxDepth),
+		WithMaxTokens(b.config.maxTokens),
+		WithLogger(b.config.logger),
+		WithErrorHandler(b.config.errorHandler),
+	}
+}
+
+// resolveImport finds the file of the import location in the import paths.
+// The result has the Err if it is not found.
+func (b *batch) resolveImport(location string) *FileResult {
+	location = strings.Trim(location, `"'`)
+	for _, importPath := range b.config.importPaths {
+		p := path.Join(importPath, location)
+		if _, err := fs.Stat(b.fsys, p); err == nil {
+			return &FileResult{
+				Path:     p,
+				Imported: true,
+			}
+		}
+	}
+	return &FileResult{
+		Path:     location,
+		Err:      fmt.Errorf("import %q is not found in %v: %w", location, b.config.importPaths, fs.ErrNotExist),
+		Imported: true,
+	}
+}
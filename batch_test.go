@@ -0,0 +1,142 @@
+package protoparser_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+)
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto":            {Data: []byte(`syntax = "proto3"; import "common/types.proto"; import "google/protobuf/empty.proto";`)},
+		"b.proto":            {Data: []byte(`syntax = "proto3"; import "common/types.proto";`)},
+		"invalid.proto":      {Data: []byte(`syntax = "proto3"; message {`)},
+		"common/types.proto": {Data: []byte(`syntax = "proto3"; import "common/base.proto";`)},
+		"common/base.proto":  {Data: []byte(`syntax = "proto3";`)},
+	}
+
+	type result struct {
+		path     string
+		parsed   bool
+		failed   bool
+		notExist bool
+		imported bool
+	}
+
+	tests := []struct {
+		name        string
+		paths       []string
+		options     []protoparser.Option
+		wantResults []result
+	}{
+		{
+			name:  "parsing only the requested files",
+			paths: []string{"b.proto", "invalid.proto", "a.proto", "b.proto", "missing.proto"},
+			options: []protoparser.Option{
+				protoparser.WithParallelism(2),
+			},
+			wantResults: []result{
+				{path: "b.proto", parsed: true},
+				{path: "invalid.proto", failed: true},
+				{path: "a.proto", parsed: true},
+				{path: "missing.proto", failed: true, notExist: true},
+			},
+		},
+		{
+			name:  "parsing the imported files once",
+			paths: []string{"b.proto", "a.proto", "common/types.proto"},
+			options: []protoparser.Option{
+				protoparser.WithImportPaths("."),
+			},
+			wantResults: []result{
+				{path: "b.proto", parsed: true},
+				{path: "a.proto", parsed: true},
+				{path: "common/types.proto", parsed: true},
+				{path: "common/base.proto", parsed: true, imported: true},
+				{path: "google/protobuf/empty.proto", failed: true, notExist: true, imported: true},
+			},
+		},
+		{
+			name:  "parsing with a parallelism of 1",
+			paths: []string{"a.proto"},
+			options: []protoparser.Option{
+				protoparser.WithParallelism(1),
+				protoparser.WithImportPaths("vendor", "."),
+			},
+			wantResults: []result{
+				{path: "a.proto", parsed: true},
+				{path: "common/base.proto", parsed: true, imported: true},
+				{path: "common/types.proto", parsed: true, imported: true},
+				{path: "google/protobuf/empty.proto", failed: true, notExist: true, imported: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			results, err := protoparser.ParseFS(context.Background(), fsys, test.paths, test.options...)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+
+			var got []result
+			for _, r := range results {
+				got = append(got, result{
+					path:     r.Path,
+					parsed:   r.Proto != nil,
+					failed:   r.Err != nil,
+					notExist: errors.Is(r.Err, fs.ErrNotExist),
+					imported: r.Imported,
+				})
+				if r.Proto != nil && r.Proto.Meta.Filename != r.Path {
+					t.Errorf("got %v, but want %v", r.Proto.Meta.Filename, r.Path)
+				}
+			}
+			if !reflect.DeepEqual(got, test.wantResults) {
+				t.Errorf("got %v, but want %v", got, test.wantResults)
+			}
+		})
+	}
+}
+
+func TestParseFS_canceled(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";`)},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := protoparser.ParseFS(ctx, fsys, []string{"a.proto"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, but want %v", err, context.Canceled)
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	results, err := protoparser.ParseFiles(
+		context.Background(),
+		[]string{"_testdata/simple.proto"},
+		protoparser.WithImportPaths("_testdata"),
+	)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, but want 2", len(results))
+	}
+	if results[0].Err != nil || results[0].Proto == nil {
+		t.Errorf("got %v, but want the parsed _testdata/simple.proto", results[0])
+	}
+	if want := []string{"other.proto"}; !reflect.DeepEqual(results[0].Imports, want) {
+		t.Errorf("got %v, but want %v", results[0].Imports, want)
+	}
+	if results[1].Path != "other.proto" || !errors.Is(results[1].Err, fs.ErrNotExist) {
+		t.Errorf("got %v, but want the missing other.proto", results[1])
+	}
+}
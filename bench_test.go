@@ -0,0 +1,51 @@
+package protoparser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+)
+
+// largeProto generates a proto source which resembles a generated file with n messages.
+func largeProto(n int) string {
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n\npackage bench.v1;\n\nimport \"google/protobuf/timestamp.proto\";\n\n")
+	b.WriteString("service BenchService {\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "  // Get%d returns Message%d.\n", i, i)
+		fmt.Fprintf(&b, "  rpc Get%d(Message%d) returns (stream Message%d) {\n", i, i, i)
+		fmt.Fprintf(&b, "    option (google.api.http) = {\n      get: \"/v1/messages%d/{id}\"\n    };\n  }\n", i)
+	}
+	b.WriteString("}\n\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "// Message%d is a generated message.\n", i)
+		fmt.Fprintf(&b, "message Message%d {\n", i)
+		fmt.Fprintf(&b, "  // id is a unique identifier.\n")
+		fmt.Fprintf(&b, "  string id = 1 [(validator.field) = {length_gt: 0}];\n")
+		fmt.Fprintf(&b, "  repeated int64 values = 2; // values\n")
+		fmt.Fprintf(&b, "  map<string, double> scores = 3;\n")
+		fmt.Fprintf(&b, "  google.protobuf.Timestamp create_time = 4;\n")
+		fmt.Fprintf(&b, "  oneof choice {\n    string name = 5;\n    int32 number = 6 [deprecated = true];\n  }\n")
+		fmt.Fprintf(&b, "  enum Kind {\n    KIND_UNSPECIFIED = 0;\n    KIND_ONE = 1;\n  }\n")
+		b.WriteString("  reserved 10 to 20;\n}\n\n")
+	}
+	return b.String()
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, n := range []int{10, 1000} {
+		input := largeProto(n)
+		b.Run(fmt.Sprintf("lines=%d", strings.Count(input, "\n")), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := protoparser.Parse(strings.NewReader(input))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package builder constructs and modifies the parsed nodes, so that a file can be generated by the printer
+// instead of by concatenating strings.
+//
+// The nodes are well-formed: the names and the numbers are checked, the bodies are kept in the order
+// which the printer expects, and Meta is left zero since the nodes have no source.
+// The constants of the options are the source text, like "true" or strconv.Quote("foo").
+package builder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fullIdentifier is a dotted name, optionally with a leading dot, like ".foo.Bar".
+var fullIdentifier = regexp.MustCompile(`^\.?[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// optionName is the name of an option, like "deprecated", "(foo.bar)" or "(foo).bar".
+var optionName = regexp.MustCompile(`^(\(\.?[A-Za-z_][A-Za-z0-9_.]*\)|[A-Za-z_][A-Za-z0-9_]*)(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+func checkIdentifier(name string) error {
+	if !identifier.MatchString(name) {
+		return fmt.Errorf("found %q but expected an identifier", name)
+	}
+	return nil
+}
+
+func checkType(name string) error {
+	if !fullIdentifier.MatchString(name) {
+		return fmt.Errorf("found %q but expected a type name", name)
+	}
+	return nil
+}
+
+// NewFile returns a proto3 file of the package. It has no package statement if pkg is empty.
+func NewFile(pkg string) (*parser.Proto, error) {
+	p := &parser.Proto{
+		Syntax: &parser.Syntax{ProtobufVersion: "proto3"},
+		Meta:   &parser.ProtoMeta{},
+	}
+	if pkg == "" {
+		return p, nil
+	}
+	if !fullIdentifier.MatchString(pkg) || pkg[0] == '.' {
+		return nil, fmt.Errorf("found %q but expected a package name", pkg)
+	}
+	p.ProtoBody = append(p.ProtoBody, &parser.Package{Name: pkg})
+	return p, nil
+}
+
+// AddImport adds the import of the location after the other imports, or returns the existing one.
+func AddImport(p *parser.Proto, location string) *parser.Import {
+	quoted := strconv.Quote(location)
+	for _, v := range p.ProtoBody {
+		if imp, ok := v.(*parser.Import); ok && imp.Location == quoted {
+			return imp
+		}
+	}
+	imp := &parser.Import{Location: quoted}
+	p.ProtoBody = insert(p.ProtoBody, afterLast(p.ProtoBody, isPackageOrImport), imp)
+	return imp
+}
+
+// SetOption sets the option of the file, the message, the enum, the service or the RPC. It replaces the constant
+// of the option of the name if any, and otherwise adds the option after the other options.
+func SetOption(node parser.Visitee, name, constant string) (*parser.Option, error) {
+	if !optionName.MatchString(name) {
+		return nil, fmt.Errorf("found %q but expected an option name", name)
+	}
+
+	var body *[]parser.Visitee
+	after := isOption
+	switch n := node.(type) {
+	case *parser.Proto:
+		body = &n.ProtoBody
+		after = func(v parser.Visitee) bool {
+			return isPackageOrImport(v) || isOption(v)
+		}
+	case *parser.Message:
+		body = &n.MessageBody
+	case *parser.Enum:
+		body = &n.EnumBody
+	case *parser.Service:
+		body = &n.ServiceBody
+	case *parser.RPC:
+		for _, opt := range n.Options {
+			if opt.OptionName == name {
+				opt.Constant, opt.Endpoint = constant, nil
+				return opt, nil
+			}
+		}
+		opt := &parser.Option{OptionName: name, Constant: constant}
+		n.Options = append(n.Options, opt)
+		return opt, nil
+	default:
+		return nil, fmt.Errorf("found %T but expected a node which has options", node)
+	}
+
+	for _, v := range *body {
+		if opt, ok := v.(*parser.Option); ok && opt.OptionName == name {
+			opt.Constant, opt.Endpoint = constant, nil
+			return opt, nil
+		}
+	}
+	opt := &parser.Option{OptionName: name, Constant: constant}
+	*body = insert(*body, afterLast(*body, after), opt)
+	return opt, nil
+}
+
+// SetFieldOption sets the option of the field, the map field, the oneof field or the enum value.
+// It replaces the constant of the option of the name if any.
+func SetFieldOption(node parser.Visitee, name, constant string) error {
+	if !optionName.MatchString(name) {
+		return fmt.Errorf("found %q but expected an option name", name)
+	}
+
+	var options *[]*parser.FieldOption
+	switch n := node.(type) {
+	case *parser.Field:
+		options = &n.FieldOptions
+	case *parser.MapField:
+		options = &n.FieldOptions
+	case *parser.OneofField:
+		options = &n.FieldOptions
+	case *parser.EnumField:
+		for _, opt := range n.EnumValueOptions {
+			if opt.OptionName == name {
+				opt.Constant = constant
+				return nil
+			}
+		}
+		n.EnumValueOptions = append(n.EnumValueOptions, &parser.EnumValueOption{OptionName: name, Constant: constant})
+		return nil
+	default:
+		return fmt.Errorf("found %T but expected a field or an enum value", node)
+	}
+
+	for _, opt := range *options {
+		if opt.OptionName == name {
+			opt.Constant = constant
+			return nil
+		}
+	}
+	*options = append(*options, &parser.FieldOption{OptionName: name, Constant: constant})
+	return nil
+}
+
+// AddComment adds a line comment like "// text" to the comments placed before the node.
+func AddComment(node parser.Visitee, text string) error {
+	c := &parser.Comment{Raw: "// " + text}
+	switch n := node.(type) {
+	case *parser.Syntax:
+		n.Comments = append(n.Comments, c)
+	case *parser.Package:
+		n.Comments = append(n.Comments, c)
+	case *parser.Import:
+		n.Comments = append(n.Comments, c)
+	case *parser.Option:
+		n.Comments = append(n.Comments, c)
+	case *parser.Message:
+		n.Comments = append(n.Comments, c)
+	case *parser.Field:
+		n.Comments = append(n.Comments, c)
+	case *parser.MapField:
+		n.Comments = append(n.Comments, c)
+	case *parser.Oneof:
+		n.Comments = append(n.Comments, c)
+	case *parser.OneofField:
+		n.Comments = append(n.Comments, c)
+	case *parser.Enum:
+		n.Comments = append(n.Comments, c)
+	case *parser.EnumField:
+		n.Comments = append(n.Comments, c)
+	case *parser.Reserved:
+		n.Comments = append(n.Comments, c)
+	case *parser.Extend:
+		n.Comments = append(n.Comments, c)
+	case *parser.Service:
+		n.Comments = append(n.Comments, c)
+	case *parser.RPC:
+		n.Comments = append(n.Comments, c)
+	default:
+		return fmt.Errorf("found %T but expected a node which has comments", node)
+	}
+	return nil
+}
+
+func isPackageOrImport(v parser.Visitee) bool {
+	switch v.(type) {
+	case *parser.Package, *parser.Import:
+		return true
+	}
+	return false
+}
+
+func isOption(v parser.Visitee) bool {
+	_, ok := v.(*parser.Option)
+	return ok
+}
+
+// afterLast returns the index after the last node of body for which match is true, or 0.
+func afterLast(body []parser.Visitee, match func(parser.Visitee) bool) int {
+	for i := len(body) - 1; 0 <= i; i-- {
+		if match(body[i]) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func insert(body []parser.Visitee, i int, v parser.Visitee) []parser.Visitee {
+	body = append(body, nil)
+	copy(body[i+1:], body[i:])
+	body[i] = v
+	return body
+}
@@ -0,0 +1,235 @@
+package builder_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/builder"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/printer"
+)
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	p, err := builder.NewFile("item.v1")
+	check(t, err)
+	builder.AddImport(p, "google/protobuf/timestamp.proto")
+	_, err = builder.SetOption(p, "go_package", strconv.Quote("example.com/item/v1"))
+	check(t, err)
+	builder.AddImport(p, "shared.proto")
+	builder.AddImport(p, "shared.proto")
+
+	item, err := builder.NewMessage("Item")
+	check(t, err)
+	check(t, builder.AddComment(item, "Item is an item."))
+	_, err = builder.SetOption(item, "deprecated", "false")
+	check(t, err)
+	_, err = builder.SetOption(item, "deprecated", "true")
+	check(t, err)
+	_, err = builder.ReserveNumbers(item, 2, 3)
+	check(t, err)
+	_, err = builder.ReserveNames(item, "old_name")
+	check(t, err)
+	_, err = builder.AddField(item, "string", "name", 0)
+	check(t, err)
+	tags, err := builder.AddField(item, "string", "tags", 0)
+	check(t, err)
+	tags.IsRepeated = true
+	check(t, builder.SetFieldOption(tags, "deprecated", "true"))
+	_, err = builder.AddMapField(item, "string", "int32", "counts", 10)
+	check(t, err)
+	condition, err := builder.AddOneof(item, "condition")
+	check(t, err)
+	_, err = builder.AddOneofField(item, condition, "double", "score", 0)
+	check(t, err)
+	_, err = builder.AddOneofField(item, condition, "google.protobuf.Timestamp", "sold_at", 0)
+	check(t, err)
+
+	status, err := builder.NewEnum("Status")
+	check(t, err)
+	_, err = builder.AddEnumValue(status, "STATUS_UNSPECIFIED", 0)
+	check(t, err)
+	ok, err := builder.AddEnumValue(status, "STATUS_OK", 1)
+	check(t, err)
+	check(t, builder.SetFieldOption(ok, "deprecated", "true"))
+	check(t, builder.Add(item, status))
+	check(t, builder.Add(p, item))
+
+	items, err := builder.NewService("Items")
+	check(t, err)
+	get, err := builder.AddRPC(items, "Get", "Item", "Item")
+	check(t, err)
+	get.RPCResponse.IsStream = true
+	_, err = builder.SetOption(get, "idempotency_level", "NO_SIDE_EFFECTS")
+	check(t, err)
+	check(t, builder.Add(p, items))
+
+	want := `syntax = "proto3";
+
+package item.v1;
+
+import "google/protobuf/timestamp.proto";
+import "shared.proto";
+
+option go_package = "example.com/item/v1";
+
+// Item is an item.
+message Item {
+  option deprecated = true;
+  reserved 2 to 3;
+  reserved "old_name";
+  string name = 1;
+  repeated string tags = 4 [deprecated = true];
+  map<string, int32> counts = 10;
+  oneof condition {
+    double score = 5;
+    google.protobuf.Timestamp sold_at = 6;
+  }
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_OK = 1 [deprecated = true];
+  }
+}
+
+service Items {
+  rpc Get(Item) returns (stream Item) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+}
+`
+	got, err := printer.Sprint(p)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if got != want {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if _, err := protoparser.Parse(strings.NewReader(got)); err != nil {
+		t.Errorf("got err %v", err)
+	}
+}
+
+func TestNextFieldNumber(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{
+			name:  "empty",
+			input: `message A {}`,
+			want:  1,
+		},
+		{
+			name:  "used and reserved",
+			input: `message A { int32 a = 1; oneof o { int32 b = 0x2; } reserved 3 to 5, 7; map<string, int32> c = 6; }`,
+			want:  8,
+		},
+		{
+			name:  "implementation numbers",
+			input: `message A { reserved 1 to 18999; }`,
+			want:  20000,
+		},
+		{
+			name:  "reserved to max",
+			input: `message A { int32 a = 1; reserved 3 to max; }`,
+			want:  2,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p, err := protoparser.Parse(strings.NewReader(`syntax = "proto3"; ` + test.input))
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			got, err := builder.NextFieldNumber(p.ProtoBody[0].(*parser.Message))
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+
+	p, err := protoparser.Parse(strings.NewReader(`syntax = "proto3"; message A { reserved 1 to max; }`))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if got, err := builder.NextFieldNumber(p.ProtoBody[0].(*parser.Message)); err == nil {
+		t.Errorf("got %v, but want an error", got)
+	}
+}
+
+func TestBuild_errors(t *testing.T) {
+	p, err := protoparser.Parse(strings.NewReader(`syntax = "proto3";
+message A {
+  int32 a = 1;
+  oneof o {
+    int32 b = 2;
+  }
+  reserved 10 to 20;
+  reserved "old";
+}
+enum E {
+  E_UNSPECIFIED = 0;
+  reserved 5;
+}
+service S {
+  rpc Get(A) returns (A);
+}
+`))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	a := p.ProtoBody[0].(*parser.Message)
+	e := p.ProtoBody[1].(*parser.Enum)
+	s := p.ProtoBody[2].(*parser.Service)
+	other := &parser.Oneof{OneofName: "other"}
+
+	for name, f := range map[string]func() error{
+		"invalid name":           func() error { _, err := builder.AddField(a, "int32", "1a", 0); return err },
+		"invalid type":           func() error { _, err := builder.AddField(a, "int 32", "c", 0); return err },
+		"duplicate name":         func() error { _, err := builder.AddField(a, "int32", "b", 0); return err },
+		"name of a oneof":        func() error { _, err := builder.AddField(a, "int32", "o", 0); return err },
+		"reserved name":          func() error { _, err := builder.AddField(a, "int32", "old", 0); return err },
+		"duplicate number":       func() error { _, err := builder.AddField(a, "int32", "c", 2); return err },
+		"reserved number":        func() error { _, err := builder.AddField(a, "int32", "c", 15); return err },
+		"implementation number":  func() error { _, err := builder.AddField(a, "int32", "c", 19500); return err },
+		"too large number":       func() error { _, err := builder.AddField(a, "int32", "c", 1<<29); return err },
+		"map key":                func() error { _, err := builder.AddMapField(a, "double", "int32", "c", 0); return err },
+		"oneof of another":       func() error { _, err := builder.AddOneofField(a, other, "int32", "c", 0); return err },
+		"used number reserved":   func() error { _, err := builder.ReserveNumbers(a, 1, 3); return err },
+		"used name reserved":     func() error { _, err := builder.ReserveNames(a, "a"); return err },
+		"duplicate enum value":   func() error { _, err := builder.AddEnumValue(e, "E_OTHER", 0); return err },
+		"reserved enum value":    func() error { _, err := builder.AddEnumValue(e, "E_OTHER", 5); return err },
+		"duplicate RPC":          func() error { _, err := builder.AddRPC(s, "Get", "A", "A"); return err },
+		"duplicate definition":   func() error { return builder.Add(p, &parser.Enum{EnumName: "A"}) },
+		"service in a message":   func() error { return builder.Add(a, &parser.Service{ServiceName: "T"}) },
+		"option of a field":      func() error { _, err := builder.SetOption(&parser.Field{}, "deprecated", "true"); return err },
+		"invalid option name":    func() error { _, err := builder.SetOption(a, "(foo", "true"); return err },
+		"invalid package":        func() error { _, err := builder.NewFile("foo..bar"); return err },
+		"comment of a reference": func() error { return builder.AddComment(&parser.EmptyStatement{}, "text") },
+	} {
+		if err := f(); err == nil {
+			t.Errorf("got nil, but want an error for %s", name)
+		}
+	}
+
+	c, err := builder.AddField(a, "int32", "c", 0)
+	check(t, err)
+	if c.FieldNumber != "3" {
+		t.Errorf("got %v, but want 3", c.FieldNumber)
+	}
+	check(t, builder.SetFieldOption(c, "(my.option).value", "1"))
+}
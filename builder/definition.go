@@ -0,0 +1,419 @@
+package builder
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// The field numbers reserved for the implementation of Protocol Buffers.
+const (
+	firstImplementationNumber = 19000
+	lastImplementationNumber  = 19999
+)
+
+var mapKeyTypes = map[string]bool{
+	"int32": true, "int64": true, "uint32": true, "uint64": true, "sint32": true, "sint64": true,
+	"fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true, "bool": true, "string": true,
+}
+
+// NewMessage returns an empty message. It is added to a file or a message by Add.
+func NewMessage(name string) (*parser.Message, error) {
+	if err := checkIdentifier(name); err != nil {
+		return nil, err
+	}
+	return &parser.Message{MessageName: name}, nil
+}
+
+// NewEnum returns an empty enum. It is added to a file or a message by Add.
+func NewEnum(name string) (*parser.Enum, error) {
+	if err := checkIdentifier(name); err != nil {
+		return nil, err
+	}
+	return &parser.Enum{EnumName: name}, nil
+}
+
+// NewService returns an empty service. It is added to a file by Add.
+func NewService(name string) (*parser.Service, error) {
+	if err := checkIdentifier(name); err != nil {
+		return nil, err
+	}
+	return &parser.Service{ServiceName: name}, nil
+}
+
+// Add adds the message, the enum or the service to the end of the file, or the message or the enum
+// to the end of the message. The name must not be used by the other definitions there.
+func Add(parent, definition parser.Visitee) error {
+	var body *[]parser.Visitee
+	switch n := parent.(type) {
+	case *parser.Proto:
+		body = &n.ProtoBody
+	case *parser.Message:
+		if _, ok := definition.(*parser.Service); ok {
+			return fmt.Errorf("found a service but expected a message or an enum in message %q", n.MessageName)
+		}
+		body = &n.MessageBody
+	default:
+		return fmt.Errorf("found %T but expected a file or a message", parent)
+	}
+
+	name := definitionName(definition)
+	if name == "" {
+		return fmt.Errorf("found %T but expected a message, an enum or a service", definition)
+	}
+	for _, v := range *body {
+		if definitionName(v) == name {
+			return fmt.Errorf("found a duplicate definition %q", name)
+		}
+	}
+	*body = append(*body, definition)
+	return nil
+}
+
+func definitionName(v parser.Visitee) string {
+	switch n := v.(type) {
+	case *parser.Message:
+		return n.MessageName
+	case *parser.Enum:
+		return n.EnumName
+	case *parser.Service:
+		return n.ServiceName
+	}
+	return ""
+}
+
+// AddField adds a field to the end of the message. If number is 0, it is NextFieldNumber.
+// The field is repeated if IsRepeated is set.
+func AddField(m *parser.Message, typ, name string, number int) (*parser.Field, error) {
+	if err := checkType(typ); err != nil {
+		return nil, err
+	}
+	number, err := checkField(m, name, number)
+	if err != nil {
+		return nil, err
+	}
+	f := &parser.Field{Type: typ, FieldName: name, FieldNumber: strconv.Itoa(number)}
+	m.MessageBody = append(m.MessageBody, f)
+	return f, nil
+}
+
+// AddMapField adds a map field to the end of the message. If number is 0, it is NextFieldNumber.
+func AddMapField(m *parser.Message, keyType, valueType, name string, number int) (*parser.MapField, error) {
+	if !mapKeyTypes[keyType] {
+		return nil, fmt.Errorf("found %q but expected an integral or string type for the map key", keyType)
+	}
+	if err := checkType(valueType); err != nil {
+		return nil, err
+	}
+	number, err := checkField(m, name, number)
+	if err != nil {
+		return nil, err
+	}
+	f := &parser.MapField{KeyType: keyType, Type: valueType, MapName: name, FieldNumber: strconv.Itoa(number)}
+	m.MessageBody = append(m.MessageBody, f)
+	return f, nil
+}
+
+// AddOneof adds an empty oneof to the end of the message. Its fields are added by AddOneofField.
+func AddOneof(m *parser.Message, name string) (*parser.Oneof, error) {
+	if _, err := checkField(m, name, -1); err != nil {
+		return nil, err
+	}
+	o := &parser.Oneof{OneofName: name}
+	m.MessageBody = append(m.MessageBody, o)
+	return o, nil
+}
+
+// AddOneofField adds a field to the end of the oneof of the message. If number is 0, it is NextFieldNumber.
+func AddOneofField(m *parser.Message, o *parser.Oneof, typ, name string, number int) (*parser.OneofField, error) {
+	found := false
+	for _, v := range m.MessageBody {
+		found = found || v == o
+	}
+	if !found {
+		return nil, fmt.Errorf("found oneof %q but expected a oneof of message %q", o.OneofName, m.MessageName)
+	}
+	if err := checkType(typ); err != nil {
+		return nil, err
+	}
+	number, err := checkField(m, name, number)
+	if err != nil {
+		return nil, err
+	}
+	f := &parser.OneofField{Type: typ, FieldName: name, FieldNumber: strconv.Itoa(number)}
+	o.OneofFields = append(o.OneofFields, f)
+	return f, nil
+}
+
+// NextFieldNumber returns the smallest field number of the message which is neither used nor reserved,
+// skipping the numbers 19000 to 19999 reserved for the implementation.
+func NextFieldNumber(m *parser.Message) (int, error) {
+	used := fieldNumbers(m)
+	ranges := schema.ParseReservedRanges(reserved(m.MessageBody), schema.MaxFieldNumber)
+	for number := 1; number <= schema.MaxFieldNumber; number++ {
+		if firstImplementationNumber <= number && number <= lastImplementationNumber {
+			number = lastImplementationNumber
+			continue
+		}
+		if used[number] {
+			continue
+		}
+		if r, ok := reservedRange(ranges, number); ok {
+			number = r.End
+			continue
+		}
+		return number, nil
+	}
+	return 0, fmt.Errorf("found no free field number in message %q", m.MessageName)
+}
+
+// checkField checks the name and the number of a new field of the message and returns the number.
+// A number of 0 is replaced by NextFieldNumber, and a number of -1 is not checked.
+func checkField(m *parser.Message, name string, number int) (int, error) {
+	if err := checkIdentifier(name); err != nil {
+		return 0, err
+	}
+	if fieldNames(m)[name] {
+		return 0, fmt.Errorf("found a duplicate field name %q in message %q", name, m.MessageName)
+	}
+	if contains(schema.ParseReservedNames(reserved(m.MessageBody)), name) {
+		return 0, fmt.Errorf("found a reserved field name %q in message %q", name, m.MessageName)
+	}
+
+	switch {
+	case number == -1:
+		return number, nil
+	case number == 0:
+		return NextFieldNumber(m)
+	case number < 1 || schema.MaxFieldNumber < number:
+		return 0, fmt.Errorf("found %d but expected a field number from 1 to %d", number, schema.MaxFieldNumber)
+	case firstImplementationNumber <= number && number <= lastImplementationNumber:
+		return 0, fmt.Errorf("found %d but expected a field number out of 19000 to 19999", number)
+	case fieldNumbers(m)[number]:
+		return 0, fmt.Errorf("found a duplicate field number %d in message %q", number, m.MessageName)
+	}
+	ranges := schema.ParseReservedRanges(reserved(m.MessageBody), schema.MaxFieldNumber)
+	if _, ok := reservedRange(ranges, number); ok {
+		return 0, fmt.Errorf("found a reserved field number %d in message %q", number, m.MessageName)
+	}
+	return number, nil
+}
+
+// fieldNames returns the names of the fields and the oneofs of the message, which share a namespace.
+func fieldNames(m *parser.Message) map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range m.MessageBody {
+		switch n := v.(type) {
+		case *parser.Field:
+			names[n.FieldName] = true
+		case *parser.MapField:
+			names[n.MapName] = true
+		case *parser.Oneof:
+			names[n.OneofName] = true
+			for _, f := range n.OneofFields {
+				names[f.FieldName] = true
+			}
+		}
+	}
+	return names
+}
+
+func fieldNumbers(m *parser.Message) map[int]bool {
+	numbers := make(map[int]bool)
+	add := func(number string) {
+		if n, err := strconv.ParseInt(number, 0, 64); err == nil {
+			numbers[int(n)] = true
+		}
+	}
+	for _, v := range m.MessageBody {
+		switch n := v.(type) {
+		case *parser.Field:
+			add(n.FieldNumber)
+		case *parser.MapField:
+			add(n.FieldNumber)
+		case *parser.Oneof:
+			for _, f := range n.OneofFields {
+				add(f.FieldNumber)
+			}
+		}
+	}
+	return numbers
+}
+
+func reserved(body []parser.Visitee) []*parser.Reserved {
+	var rs []*parser.Reserved
+	for _, v := range body {
+		if r, ok := v.(*parser.Reserved); ok {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+func reservedRange(ranges []schema.ReservedRange, number int) (schema.ReservedRange, bool) {
+	for _, r := range ranges {
+		if r.Start <= number && number <= r.End {
+			return r, true
+		}
+	}
+	return schema.ReservedRange{}, false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReserveNumbers reserves the numbers from start to end of the message or the enum. They must not be used.
+// An end of schema.MaxFieldNumber or schema.MaxEnumValueNumber is written as "max".
+func ReserveNumbers(node parser.Visitee, start, end int) (*parser.Reserved, error) {
+	var body *[]parser.Visitee
+	var used map[int]bool
+	min, max := 1, schema.MaxFieldNumber
+	switch n := node.(type) {
+	case *parser.Message:
+		body, used = &n.MessageBody, fieldNumbers(n)
+	case *parser.Enum:
+		body, used = &n.EnumBody, enumNumbers(n)
+		min, max = -schema.MaxEnumValueNumber-1, schema.MaxEnumValueNumber
+	default:
+		return nil, fmt.Errorf("found %T but expected a message or an enum", node)
+	}
+	if start < min || max < end || end < start {
+		return nil, fmt.Errorf("found %d to %d but expected a range within %d to %d", start, end, min, max)
+	}
+	for number := range used {
+		if start <= number && number <= end {
+			return nil, fmt.Errorf("found the number %d in use in %d to %d", number, start, end)
+		}
+	}
+
+	rng := &parser.Range{Begin: strconv.Itoa(start)}
+	switch {
+	case end == max:
+		rng.End = "max"
+	case end != start:
+		rng.End = strconv.Itoa(end)
+	}
+	r := &parser.Reserved{Ranges: []*parser.Range{rng}}
+	*body = append(*body, r)
+	return r, nil
+}
+
+// ReserveNames reserves the names of the message or the enum. They must not be used.
+func ReserveNames(node parser.Visitee, names ...string) (*parser.Reserved, error) {
+	var body *[]parser.Visitee
+	var used map[string]bool
+	switch n := node.(type) {
+	case *parser.Message:
+		body, used = &n.MessageBody, fieldNames(n)
+	case *parser.Enum:
+		body, used = &n.EnumBody, enumNames(n)
+	default:
+		return nil, fmt.Errorf("found %T but expected a message or an enum", node)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("found no names to reserve")
+	}
+
+	r := &parser.Reserved{}
+	for _, name := range names {
+		if err := checkIdentifier(name); err != nil {
+			return nil, err
+		}
+		if used[name] {
+			return nil, fmt.Errorf("found the name %q in use", name)
+		}
+		r.FieldNames = append(r.FieldNames, strconv.Quote(name))
+	}
+	*body = append(*body, r)
+	return r, nil
+}
+
+// AddEnumValue adds a value to the end of the enum. A number can be used by several values only if
+// the enum has the option allow_alias.
+func AddEnumValue(e *parser.Enum, name string, number int) (*parser.EnumField, error) {
+	if err := checkIdentifier(name); err != nil {
+		return nil, err
+	}
+	if enumNames(e)[name] {
+		return nil, fmt.Errorf("found a duplicate value name %q in enum %q", name, e.EnumName)
+	}
+	if contains(schema.ParseReservedNames(reserved(e.EnumBody)), name) {
+		return nil, fmt.Errorf("found a reserved value name %q in enum %q", name, e.EnumName)
+	}
+	if number < -schema.MaxEnumValueNumber-1 || schema.MaxEnumValueNumber < number {
+		return nil, fmt.Errorf("found %d but expected a 32-bit enum value number", number)
+	}
+	if enumNumbers(e)[number] && !allowAlias(e) {
+		return nil, fmt.Errorf("found a duplicate value number %d in enum %q", number, e.EnumName)
+	}
+	ranges := schema.ParseReservedRanges(reserved(e.EnumBody), schema.MaxEnumValueNumber)
+	if _, ok := reservedRange(ranges, number); ok {
+		return nil, fmt.Errorf("found a reserved value number %d in enum %q", number, e.EnumName)
+	}
+	v := &parser.EnumField{Ident: name, Number: strconv.Itoa(number)}
+	e.EnumBody = append(e.EnumBody, v)
+	return v, nil
+}
+
+func enumNames(e *parser.Enum) map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range e.EnumBody {
+		if f, ok := v.(*parser.EnumField); ok {
+			names[f.Ident] = true
+		}
+	}
+	return names
+}
+
+func enumNumbers(e *parser.Enum) map[int]bool {
+	numbers := make(map[int]bool)
+	for _, v := range e.EnumBody {
+		if f, ok := v.(*parser.EnumField); ok {
+			if n, err := strconv.ParseInt(f.Number, 0, 64); err == nil {
+				numbers[int(n)] = true
+			}
+		}
+	}
+	return numbers
+}
+
+func allowAlias(e *parser.Enum) bool {
+	for _, v := range e.EnumBody {
+		if opt, ok := v.(*parser.Option); ok && opt.OptionName == "allow_alias" && opt.Constant == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRPC adds an RPC to the end of the service. The request and the response are streamed if IsStream is set.
+func AddRPC(s *parser.Service, name, request, response string) (*parser.RPC, error) {
+	if err := checkIdentifier(name); err != nil {
+		return nil, err
+	}
+	for _, typ := range []string{request, response} {
+		if err := checkType(typ); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range s.ServiceBody {
+		if r, ok := v.(*parser.RPC); ok && r.RPCName == name {
+			return nil, fmt.Errorf("found a duplicate RPC %q in service %q", name, s.ServiceName)
+		}
+	}
+	r := &parser.RPC{
+		RPCName:     name,
+		RPCRequest:  &parser.RPCRequest{MessageType: request},
+		RPCResponse: &parser.RPCResponse{MessageType: response},
+	}
+	s.ServiceBody = append(s.ServiceBody, r)
+	return r, nil
+}
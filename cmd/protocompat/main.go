@@ -0,0 +1,112 @@
+// Command protocompat reports the breaking changes between two versions of Protocol Buffer files.
+//
+//	protocompat [-I path]... [-fail wire|json|source] [-json] -old dir -new dir file...
+//
+// The files and the import paths are relative to both directories. It exits with 1 if a change is
+// at least as severe as -fail.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/compat"
+	"github.com/thought-machine/go-protoparser/internal/cli"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var (
+	importPaths cli.StringsFlag
+	oldDir      = flag.String("old", "", "directory of the old version")
+	newDir      = flag.String("new", "", "directory of the new version")
+	fail        = flag.String("fail", "wire", "least severe change to fail on, wire, json or source")
+	jsonOutput  = flag.Bool("json", false, "print the changes as a JSON array")
+)
+
+func init() {
+	flag.Var(&importPaths, "I", "directory to look up the imports in, like protoc -I. It can be given multiple times")
+}
+
+// change is the JSON output of a compat.Change.
+type change struct {
+	Severity string `json:"severity"`
+	Name     string `json:"name"`
+	Message  string `json:"message"`
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func load(dir string) (*schema.Schema, error) {
+	s, err := schema.Load(context.Background(), os.DirFS(dir), flag.Args(), protoparser.WithImportPaths(importPaths...))
+	if s == nil {
+		return nil, err
+	}
+	if err != nil {
+		// The definitions which are parsed and resolved can still be compared.
+		fmt.Fprintf(os.Stderr, "warning: %s: %v\n", dir, err)
+	}
+	return s, nil
+}
+
+func run() int {
+	flag.Parse()
+	if flag.NArg() == 0 || *oldDir == "" || *newDir == "" {
+		fmt.Fprintln(os.Stderr, "usage: protocompat [-I path]... [-fail wire|json|source] [-json] -old dir -new dir file...")
+		return 2
+	}
+	min, err := compat.ParseSeverity(*fail)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	oldSchema, err := load(*oldDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load %s, err %v\n", *oldDir, err)
+		return 1
+	}
+	newSchema, err := load(*newDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load %s, err %v\n", *newDir, err)
+		return 1
+	}
+
+	changes := compat.Compare(oldSchema, newSchema)
+	if *jsonOutput {
+		out := []*change{}
+		for _, c := range changes {
+			out = append(out, &change{
+				Severity: c.Severity.String(),
+				Name:     c.Name,
+				Message:  c.Message,
+				Filename: c.Pos.Filename,
+				Line:     c.Pos.Line,
+				Column:   c.Pos.Column,
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write, err %v\n", err)
+			return 1
+		}
+	} else {
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+	}
+
+	if 0 < len(compat.Filter(changes, min)) {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
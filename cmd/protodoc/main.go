@@ -0,0 +1,93 @@
+// Command protodoc renders the documentation of Protocol Buffer files as Markdown or HTML pages, one per package.
+//
+//	protodoc [-I path]... [-format markdown|html] [-template file]... [-out dir] file...
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/internal/cli"
+	"github.com/thought-machine/go-protoparser/protodoc"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var (
+	importPaths cli.StringsFlag
+	templates   cli.StringsFlag
+	format      = flag.String("format", "markdown", "output format, markdown or html")
+	out         = flag.String("out", ".", "directory to write the pages to")
+)
+
+func init() {
+	flag.Var(&importPaths, "I", "directory to look up the imports in, like protoc -I. It can be given multiple times")
+	flag.Var(&templates, "template", `file defining templates to override, like {{define "header"}}...{{end}}. It can be given multiple times`)
+}
+
+func run() int {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: protodoc [-I path]... [-format markdown|html] [-template file]... [-out dir] file...")
+		return 2
+	}
+
+	options := []protodoc.Option{}
+	switch *format {
+	case "markdown":
+		options = append(options, protodoc.WithFormat(protodoc.FormatMarkdown))
+	case "html":
+		options = append(options, protodoc.WithFormat(protodoc.FormatHTML))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		return 2
+	}
+	for _, path := range templates {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read %s, err %v\n", path, err)
+			return 1
+		}
+		options = append(options, protodoc.WithTemplates(string(text)))
+	}
+	generator, err := protodoc.NewGenerator(options...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse the templates, err %v\n", err)
+		return 1
+	}
+
+	s, err := schema.LoadFiles(context.Background(), flag.Args(), protoparser.WithImportPaths(importPaths...))
+	if s == nil {
+		fmt.Fprintf(os.Stderr, "failed to load, err %v\n", err)
+		return 1
+	}
+	if err != nil {
+		// The documentation is still useful without the definitions which could not be parsed or resolved.
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	pages, err := generator.Generate(s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render, err %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create %s, err %v\n", *out, err)
+		return 1
+	}
+	for _, page := range pages {
+		path := filepath.Join(*out, page.Path)
+		if err := os.WriteFile(path, page.Content, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s, err %v\n", path, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,86 @@
+// Command protograph prints the dependency graph of Protocol Buffer files.
+//
+//	protograph [-I path]... [-types] [-format dot|mermaid|json] [-check] file...
+//
+// It prints the graph of the imports, or of the type usage with -types. With -check, it prints the cycles
+// and the unused imports to stderr and exits with 1 if there is any.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/depgraph"
+	"github.com/thought-machine/go-protoparser/internal/cli"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var (
+	importPaths cli.StringsFlag
+	types       = flag.Bool("types", false, "print the type usage between the definitions instead of the imports")
+	format      = flag.String("format", "dot", "output format, dot, mermaid or json")
+	check       = flag.Bool("check", false, "report the cycles and the unused imports, and fail if there is any")
+)
+
+func init() {
+	flag.Var(&importPaths, "I", "directory to look up the imports in, like protoc -I. It can be given multiple times")
+}
+
+func run() int {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: protograph [-I path]... [-types] [-format dot|mermaid|json] [-check] file...")
+		return 2
+	}
+	s, err := schema.LoadFiles(context.Background(), flag.Args(), protoparser.WithImportPaths(importPaths...))
+	if s == nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err != nil {
+		// The files which are parsed and the references which are resolved still make a graph.
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	g := depgraph.Imports(s)
+	if *types {
+		g = depgraph.Types(s)
+	}
+	switch *format {
+	case "dot":
+		err = g.WriteDOT(os.Stdout)
+	case "mermaid":
+		err = g.WriteMermaid(os.Stdout)
+	case "json":
+		err = g.WriteJSON(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "found the format %q but expected dot, mermaid or json\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write, err %v\n", err)
+		return 1
+	}
+
+	if !*check {
+		return 0
+	}
+	code := 0
+	for _, cycle := range g.Cycles() {
+		fmt.Fprintf(os.Stderr, "cycle: %s\n", strings.Join(cycle, " -> "))
+		code = 1
+	}
+	for _, u := range depgraph.UnusedImports(s) {
+		fmt.Fprintf(os.Stderr, "%s: unused import %q\n", u.Import.Node.Meta.Pos, u.Import.Path)
+		code = 1
+	}
+	return code
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,96 @@
+// Command protoimports organizes the imports of Protocol Buffer files.
+//
+//	protoimports [-I path]... [-w] file...
+//
+// It prints the files whose imports are missing, unused, not canonical or not sorted, and exits with 1 if there
+// is any. With -w, it rewrites the import blocks of the files in place instead. The files which define the types
+// to import are given too, since a file which is not imported yet is not looked up.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/imports"
+	"github.com/thought-machine/go-protoparser/internal/cli"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var (
+	importPaths cli.StringsFlag
+	write       = flag.Bool("w", false, "rewrite the files in place")
+)
+
+func init() {
+	flag.Var(&importPaths, "I", "directory to look up the imports in, like protoc -I. It can be given multiple times")
+}
+
+func run() int {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: protoimports [-I path]... [-w] file...")
+		return 2
+	}
+	s, err := schema.LoadFiles(context.Background(), flag.Args(), protoparser.WithImportPaths(importPaths...))
+	if err != nil {
+		// An unresolved reference would make its import look unused.
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	sources := make(map[string][]byte)
+	for _, f := range s.Files {
+		if f.Imported {
+			continue
+		}
+		src, err := os.ReadFile(f.Path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		sources[f.Path] = src
+	}
+	edits, err := imports.NewOrganizer(s, sources, imports.WithImportPaths(importPaths...)).Organize()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	edited, err := edit.ApplyFiles(sources, edits)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var paths []string
+	for path := range edited {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if !*write {
+			fmt.Println(path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			err = os.WriteFile(path, edited[path], info.Mode().Perm())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s, err %v\n", path, err)
+			return 2
+		}
+	}
+	if !*write && 0 < len(paths) {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,173 @@
+// Command protolint checks Protocol Buffer files against the lint rules.
+//
+//	protolint [-config file] [-fail info|warning|error] [-json] [-fix] file...
+//
+// The config is a YAML or JSON file described by lint.Config. With -fix, the fixable findings and the missing
+// trailing ";" of the statements are fixed in place, and the remaining findings are printed. It exits with 1 if a finding is at least as severe as -fail.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/lint"
+)
+
+var (
+	configFile = flag.String("config", "", "YAML or JSON file to configure the rules")
+	fail       = flag.String("fail", "warning", "least severe finding to fail on, info, warning or error")
+	jsonOutput = flag.Bool("json", false, "print the findings as a JSON array")
+	fix        = flag.Bool("fix", false, "fix the files in place and print the remaining findings")
+)
+
+// finding is the JSON output of a lint.Finding.
+type finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func loadConfig() (*lint.Config, error) {
+	if *configFile == "" {
+		return &lint.Config{}, nil
+	}
+	data, err := os.ReadFile(*configFile)
+	if err != nil {
+		return nil, err
+	}
+	return lint.ParseConfig(data)
+}
+
+func run() int {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: protolint [-config file] [-fail info|warning|error] [-json] [-fix] file...")
+		return 2
+	}
+	min, err := lint.ParseSeverity(*fail)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load %s, err %v\n", *configFile, err)
+		return 2
+	}
+	options, err := config.Options(lint.DefaultRegistry())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config %s, err %v\n", *configFile, err)
+		return 2
+	}
+	linter := lint.NewLinter(options...)
+
+	var paths []string
+	for _, path := range flag.Args() {
+		if !config.Excluded(path) {
+			paths = append(paths, path)
+		}
+	}
+	var findings []*lint.Finding
+	var code int
+	if *fix {
+		findings, code = fixFiles(linter, paths)
+	} else {
+		findings, code = lintFiles(linter, paths)
+	}
+
+	if *jsonOutput {
+		out := []*finding{}
+		for _, f := range findings {
+			out = append(out, &finding{
+				Rule:     f.Rule,
+				Severity: f.Severity.String(),
+				Message:  f.Message,
+				Filename: f.Pos.Filename,
+				Line:     f.Pos.Line,
+				Column:   f.Pos.Column,
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write, err %v\n", err)
+			return 1
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	}
+
+	for _, f := range findings {
+		if min <= f.Severity {
+			code = 1
+		}
+	}
+	return code
+}
+
+func lintFiles(linter *lint.Linter, paths []string) ([]*lint.Finding, int) {
+	results, err := protoparser.ParseFiles(context.Background(), paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 1
+	}
+
+	code := 0
+	var findings []*lint.Finding
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintln(os.Stderr, result.Err)
+			code = 1
+			continue
+		}
+		findings = append(findings, linter.Lint(result.Proto)...)
+	}
+	return findings, code
+}
+
+// fixFiles fixes the files one by one. A file is left as it is if the fixed one cannot be parsed.
+func fixFiles(linter *lint.Linter, paths []string) ([]*lint.Finding, int) {
+	code := 0
+	var findings []*lint.Finding
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		fixed, remaining, err := linter.Fix(src, protoparser.WithFilename(path))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to fix %s, err %v\n", path, err)
+			code = 1
+			continue
+		}
+		findings = append(findings, remaining...)
+		if bytes.Equal(fixed, src) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			err = os.WriteFile(path, fixed, info.Mode().Perm())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s, err %v\n", path, err)
+			code = 1
+		}
+	}
+	return findings, code
+}
+
+func main() {
+	os.Exit(run())
+}
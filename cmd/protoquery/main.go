@@ -0,0 +1,93 @@
+// Command protoquery prints the nodes of Protocol Buffer files selected by a query.
+//
+//	protoquery [-json] query file...
+//
+// The query is described by the query package, like 'message > field[type=google.protobuf.Timestamp]'.
+// Each match is printed as its position, kind and name. Like grep, it exits with 1 if nothing matches,
+// and with 2 on an error.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/query"
+)
+
+var jsonOutput = flag.Bool("json", false, "print the matches as a JSON array")
+
+// match is the JSON output of a query.Match.
+type match struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func run() int {
+	flag.Parse()
+	if flag.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "usage: protoquery [-json] query file...")
+		return 2
+	}
+	q, err := query.Compile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid query, err %v\n", err)
+		return 2
+	}
+	results, err := protoparser.ParseFiles(context.Background(), flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	code := 0
+	var protos []*parser.Proto
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintln(os.Stderr, result.Err)
+			code = 2
+			continue
+		}
+		protos = append(protos, result.Proto)
+	}
+	matches := q.Select(protos...)
+
+	if *jsonOutput {
+		out := []*match{}
+		for _, m := range matches {
+			out = append(out, &match{
+				Kind:     m.Kind,
+				Name:     m.Name,
+				Filename: m.Pos.Filename,
+				Line:     m.Pos.Line,
+				Column:   m.Pos.Column,
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write, err %v\n", err)
+			return 2
+		}
+	} else {
+		for _, m := range matches {
+			fmt.Printf("%s: %s %s\n", m.Pos, m.Kind, m.Name)
+		}
+	}
+
+	if code == 0 && len(matches) == 0 {
+		code = 1
+	}
+	return code
+}
+
+func main() {
+	os.Exit(run())
+}
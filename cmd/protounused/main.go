@@ -0,0 +1,68 @@
+// Command protounused reports the unused imports and definitions of Protocol Buffer files.
+//
+//	protounused [-I path]... [-json] file...
+//
+// It reports the imports whose definitions are never used, the top-level messages and enums which are never
+// referenced, and the nested ones which are dead. It exits with 1 if there is any.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/internal/cli"
+	"github.com/thought-machine/go-protoparser/schema"
+	"github.com/thought-machine/go-protoparser/unused"
+)
+
+var (
+	importPaths cli.StringsFlag
+	jsonOutput  = flag.Bool("json", false, "print the findings as JSON")
+)
+
+func init() {
+	flag.Var(&importPaths, "I", "directory to look up the imports in, like protoc -I. It can be given multiple times")
+}
+
+func run() int {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: protounused [-I path]... [-json] file...")
+		return 2
+	}
+	s, err := schema.LoadFiles(context.Background(), flag.Args(), protoparser.WithImportPaths(importPaths...))
+	if err != nil {
+		// An unresolved reference would make its definition or import look unused.
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	findings := unused.Find(s)
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if findings == nil {
+			findings = []*unused.Finding{}
+		}
+		if err := enc.Encode(findings); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write, err %v\n", err)
+			return 2
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	}
+	if 0 < len(findings) {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
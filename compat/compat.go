@@ -0,0 +1,398 @@
+// Package compat detects the breaking changes between two versions of a set of linked files.
+//
+// Each change is classified by the most severe kind of compatibility it breaks: the binary wire format,
+// the JSON mapping, or only the source code generated from the definitions.
+package compat
+
+import (
+	"fmt"
+
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// Severity is the kind of compatibility a change breaks. A more severe change also breaks the less severe kinds.
+type Severity int
+
+const (
+	// Source breaks the code which uses the generated code, like a renamed message.
+	Source Severity = iota + 1
+	// JSON breaks the clients which exchange the JSON mapping, like a renamed field.
+	JSON
+	// Wire breaks the clients which exchange the binary format, like a renumbered field.
+	Wire
+)
+
+// String returns "source", "json" or "wire".
+func (s Severity) String() string {
+	switch s {
+	case Source:
+		return "source"
+	case JSON:
+		return "json"
+	case Wire:
+		return "wire"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// ParseSeverity parses the name returned by String.
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{Source, JSON, Wire} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("found %q but expected source, json or wire", name)
+}
+
+// Change is a breaking change.
+type Change struct {
+	Severity Severity
+	// Name is the full name of the changed element, like "foo.Item.name" for a field.
+	Name    string
+	Message string
+	// Pos is the position of the element in the new version, or in the old version if it is removed.
+	Pos meta.Position
+}
+
+// String returns the change like "a.proto:3:3: wire: field ...".
+func (c *Change) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Pos, c.Severity, c.Message)
+}
+
+// Compare returns the breaking changes from the old to the new version, in the order of the old definitions.
+// Only the files which are not imported are compared, and the definitions are matched by full name, so that
+// a definition moved to another file of the same package is not a change.
+func Compare(old, new *schema.Schema) []*Change {
+	c := &comparer{new: new}
+	for _, f := range old.Files {
+		if f.Imported {
+			continue
+		}
+		if new.File(f.Path) == nil {
+			pos := meta.Position{Filename: f.Path, Line: 1, Column: 1}
+			c.add(Source, f.Path, pos, "file %q was removed", f.Path)
+		}
+		for _, m := range f.Messages {
+			c.message(m)
+		}
+		for _, e := range f.Enums {
+			c.enum(e)
+		}
+		for _, s := range f.Services {
+			c.service(s)
+		}
+	}
+	return c.changes
+}
+
+// Filter returns the changes of the severity or more severe.
+func Filter(changes []*Change, min Severity) []*Change {
+	var filtered []*Change
+	for _, change := range changes {
+		if min <= change.Severity {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+type comparer struct {
+	new     *schema.Schema
+	changes []*Change
+}
+
+func (c *comparer) add(severity Severity, name string, pos meta.Position, format string, args ...interface{}) {
+	c.changes = append(c.changes, &Change{
+		Severity: severity,
+		Name:     name,
+		Message:  fmt.Sprintf(format, args...),
+		Pos:      pos,
+	})
+}
+
+func (c *comparer) message(old *schema.Message) {
+	new := c.new.Message(old.FullName)
+	if new == nil {
+		// The nested definitions are removed with it.
+		c.add(Source, old.FullName, old.Node.Meta.Pos, "message %q was removed", old.FullName)
+		return
+	}
+	for _, f := range old.Fields {
+		c.field(f, new)
+	}
+	c.reserved(old.FullName, new.Node.Meta.Pos, old.ReservedRanges(), new.ReservedRanges(), old.ReservedNames(), new.ReservedNames())
+	for _, m := range old.Messages {
+		c.message(m)
+	}
+	for _, e := range old.Enums {
+		c.enum(e)
+	}
+}
+
+func (c *comparer) field(old *schema.Field, newParent *schema.Message) {
+	name := old.Parent.FullName + "." + old.Name
+	var new, sameName *schema.Field
+	for _, f := range newParent.Fields {
+		if f.Number == old.Number {
+			new = f
+		}
+		if f.Name == old.Name {
+			sameName = f
+		}
+	}
+
+	switch {
+	case new == nil && sameName != nil:
+		c.add(Wire, name, sameName.Type.Pos, "field %q changed its number from %d to %d", old.Name, old.Number, sameName.Number)
+		return
+	case new == nil && newParent.IsReservedNumber(old.Number):
+		c.add(JSON, name, newParent.Node.Meta.Pos, "field %q (%d) was removed", old.Name, old.Number)
+		return
+	case new == nil:
+		c.add(Wire, name, newParent.Node.Meta.Pos, "field %q (%d) was removed without reserving the number", old.Name, old.Number)
+		return
+	}
+
+	pos := new.Type.Pos
+	if old.Name != new.Name {
+		c.add(JSON, name, pos, "field %d changed its name from %q to %q", old.Number, old.Name, new.Name)
+	} else if old.JSONName != new.JSONName {
+		c.add(JSON, name, pos, "field %q changed its JSON name from %q to %q", old.Name, old.JSONName, new.JSONName)
+	}
+
+	switch {
+	case old.IsMap() != new.IsMap() || old.Repeated != new.Repeated:
+		c.add(Wire, name, pos, "field %q changed from %s to %s", old.Name, cardinality(old), cardinality(new))
+	case old.IsMap() && old.KeyType != new.KeyType:
+		c.add(typeChange(old.KeyType, "", new.KeyType, ""), name, pos,
+			"field %q changed its key type from %s to %s", old.Name, old.KeyType, new.KeyType)
+	}
+	if oldType, newType := typeName(old.Type), typeName(new.Type); oldType != newType {
+		c.add(typeChange(oldType, refKind(old.Type), newType, refKind(new.Type)), name, pos,
+			"field %q changed its type from %s to %s", old.Name, oldType, newType)
+	}
+
+	switch oldOneof, newOneof := oneofName(old), oneofName(new); {
+	case oldOneof == newOneof:
+	case oldOneof == "":
+		c.add(Wire, name, pos, "field %q was moved into the oneof %q", old.Name, newOneof)
+	case newOneof == "":
+		c.add(Wire, name, pos, "field %q was moved out of the oneof %q", old.Name, oldOneof)
+	default:
+		c.add(Wire, name, pos, "field %q was moved from the oneof %q to %q", old.Name, oldOneof, newOneof)
+	}
+}
+
+func cardinality(f *schema.Field) string {
+	switch {
+	case f.IsMap():
+		return "map"
+	case f.Repeated:
+		return "repeated"
+	}
+	return "singular"
+}
+
+func oneofName(f *schema.Field) string {
+	if f.Oneof == nil {
+		return ""
+	}
+	return f.Oneof.Name
+}
+
+// typeName returns the scalar type name, or the full name of the resolved definition.
+func typeName(t *schema.Ref) string {
+	if t.IsScalar() {
+		return t.Name
+	}
+	return t.FullName
+}
+
+// refKind returns "message", "enum" or "" for a scalar or undefined type.
+func refKind(t *schema.Ref) string {
+	switch {
+	case t.Message != nil:
+		return "message"
+	case t.Enum != nil:
+		return "enum"
+	}
+	return ""
+}
+
+// wireGroups are the types of which the values are compatible in the binary format.
+var wireGroups = map[string]string{
+	"int32":    "varint",
+	"int64":    "varint",
+	"uint32":   "varint",
+	"uint64":   "varint",
+	"bool":     "varint",
+	"enum":     "varint",
+	"sint32":   "zigzag",
+	"sint64":   "zigzag",
+	"fixed32":  "fixed32",
+	"sfixed32": "fixed32",
+	"float":    "float",
+	"fixed64":  "fixed64",
+	"sfixed64": "fixed64",
+	"double":   "double",
+	"string":   "bytes",
+	"bytes":    "bytes",
+	"message":  "bytes",
+}
+
+// jsonGroups are the types of which the values have the same representation in the JSON mapping.
+var jsonGroups = map[string]string{
+	"int32":    "number",
+	"uint32":   "number",
+	"sint32":   "number",
+	"fixed32":  "number",
+	"sfixed32": "number",
+	"float":    "number",
+	"double":   "number",
+	"int64":    "decimal string",
+	"uint64":   "decimal string",
+	"sint64":   "decimal string",
+	"fixed64":  "decimal string",
+	"sfixed64": "decimal string",
+}
+
+// typeChange returns the severity of changing the type, where kind is "message", "enum" or "" for a scalar type.
+func typeChange(oldType, oldKind, newType, newKind string) Severity {
+	oldGroup, newGroup := oldType, newType
+	if oldKind != "" {
+		oldGroup = oldKind
+	}
+	if newKind != "" {
+		newGroup = newKind
+	}
+	switch {
+	case wireGroups[oldGroup] == "" || wireGroups[oldGroup] != wireGroups[newGroup]:
+		// An undefined type is assumed to be incompatible.
+		return Wire
+	case oldKind == "message" && newKind == "message":
+		// The fields of another message are likely incompatible.
+		return Wire
+	case oldKind != "" || newKind != "":
+		// An enum is encoded by the value names and a message by the fields.
+		return JSON
+	case jsonGroups[oldType] != "" && jsonGroups[oldType] == jsonGroups[newType]:
+		return Source
+	}
+	return JSON
+}
+
+// reserved reports the reservations in the old version which are not in the new one.
+func (c *comparer) reserved(name string, pos meta.Position, oldRanges, newRanges []schema.ReservedRange, oldNames, newNames []string) {
+	for _, r := range oldRanges {
+		if !covered(newRanges, r) {
+			if r.Start == r.End {
+				c.add(Wire, name, pos, "reserved number %d of %q was deleted", r.Start, name)
+			} else {
+				c.add(Wire, name, pos, "reserved range %d to %d of %q was deleted", r.Start, r.End, name)
+			}
+		}
+	}
+	kept := make(map[string]bool)
+	for _, n := range newNames {
+		kept[n] = true
+	}
+	for _, n := range oldNames {
+		if !kept[n] {
+			c.add(JSON, name, pos, "reserved name %q of %q was deleted", n, name)
+		}
+	}
+}
+
+// covered reports whether the ranges, sorted by Start, include all numbers of r.
+func covered(ranges []schema.ReservedRange, r schema.ReservedRange) bool {
+	next := r.Start
+	for _, rng := range ranges {
+		if next < rng.Start {
+			break
+		}
+		if next <= rng.End {
+			next = rng.End + 1
+		}
+		if r.End < next {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparer) enum(old *schema.Enum) {
+	new := c.new.Enum(old.FullName)
+	if new == nil {
+		c.add(Source, old.FullName, old.Node.Meta.Pos, "enum %q was removed", old.FullName)
+		return
+	}
+
+	for _, v := range old.Values {
+		name := old.FullName + "." + v.Name
+		var sameNumber, sameName *schema.EnumValue
+		for _, nv := range new.Values {
+			if nv.Number == v.Number && (sameNumber == nil || nv.Name == v.Name) {
+				sameNumber = nv
+			}
+			if nv.Name == v.Name {
+				sameName = nv
+			}
+		}
+		switch {
+		case sameNumber != nil && sameNumber.Name != v.Name:
+			c.add(JSON, name, sameNumber.Node.Meta.Pos, "enum value %d changed its name from %q to %q", v.Number, v.Name, sameNumber.Name)
+		case sameNumber != nil:
+		case sameName != nil:
+			c.add(Wire, name, sameName.Node.Meta.Pos, "enum value %q changed its number from %d to %d", v.Name, v.Number, sameName.Number)
+		case new.IsReservedNumber(v.Number):
+			c.add(JSON, name, new.Node.Meta.Pos, "enum value %q (%d) was removed", v.Name, v.Number)
+		default:
+			c.add(Wire, name, new.Node.Meta.Pos, "enum value %q (%d) was removed without reserving the number", v.Name, v.Number)
+		}
+	}
+	c.reserved(old.FullName, new.Node.Meta.Pos, old.ReservedRanges(), new.ReservedRanges(), old.ReservedNames(), new.ReservedNames())
+}
+
+func (c *comparer) service(old *schema.Service) {
+	new := c.new.Service(old.FullName)
+	if new == nil {
+		c.add(Wire, old.FullName, old.Node.Meta.Pos, "service %q was removed", old.FullName)
+		return
+	}
+
+	rpcs := make(map[string]*schema.RPC)
+	for _, rpc := range new.RPCs {
+		rpcs[rpc.Name] = rpc
+	}
+	for _, rpc := range old.RPCs {
+		name := old.FullName + "." + rpc.Name
+		newRPC, ok := rpcs[rpc.Name]
+		if !ok {
+			c.add(Wire, name, new.Node.Meta.Pos, "RPC %q was removed", rpc.Name)
+			continue
+		}
+
+		pos := newRPC.Node.Meta.Pos
+		if oldType, newType := typeName(rpc.Request), typeName(newRPC.Request); oldType != newType {
+			c.add(Wire, name, pos, "RPC %q changed its request from %s to %s", rpc.Name, oldType, newType)
+		}
+		if oldType, newType := typeName(rpc.Response), typeName(newRPC.Response); oldType != newType {
+			c.add(Wire, name, pos, "RPC %q changed its response from %s to %s", rpc.Name, oldType, newType)
+		}
+		if rpc.RequestStream != newRPC.RequestStream {
+			c.add(Wire, name, pos, "RPC %q changed its request from %s to %s", rpc.Name, streaming(rpc.RequestStream), streaming(newRPC.RequestStream))
+		}
+		if rpc.ResponseStream != newRPC.ResponseStream {
+			c.add(Wire, name, pos, "RPC %q changed its response from %s to %s", rpc.Name, streaming(rpc.ResponseStream), streaming(newRPC.ResponseStream))
+		}
+	}
+}
+
+func streaming(stream bool) string {
+	if stream {
+		return "streaming"
+	}
+	return "unary"
+}
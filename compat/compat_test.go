@@ -0,0 +1,169 @@
+package compat_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/thought-machine/go-protoparser/compat"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+const oldVersion = `syntax = "proto3";
+package itempb;
+
+message Item {
+  message Image {
+    string url = 1;
+  }
+  string name = 1;
+  int32 count = 2;
+  int32 size = 3;
+  string title = 4;
+  Image image = 5;
+  oneof condition {
+    double score = 6;
+    string note = 7;
+  }
+  repeated string tags = 8;
+  string removed = 9;
+  string dropped = 10;
+  bytes payload = 11;
+  reserved 20 to 29, 40;
+  reserved "gone";
+}
+
+message Removed {
+  message Nested {}
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 1;
+  STATUS_BAD = 2;
+  STATUS_OLD = 3;
+  STATUS_MOVED = 4;
+}
+
+service Items {
+  rpc Get(Item) returns (Item);
+  rpc List(Item) returns (stream Item);
+  rpc Delete(Item) returns (Item);
+}
+`
+
+const newVersion = `syntax = "proto3";
+package itempb;
+
+message Item {
+  message Image {
+    string url = 1;
+  }
+  string name = 1;
+  int64 count = 2;
+  uint32 size = 3;
+  string heading = 4;
+  bytes image = 5;
+  double score = 6;
+  oneof condition {
+    string note = 7;
+  }
+  string tags = 8;
+  string dropped = 12;
+  string payload = 11 [json_name = "data"];
+  reserved 9, 20 to 25, 27 to 29;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_GOOD = 1;
+  STATUS_BAD = 2;
+  STATUS_MOVED = 5;
+  reserved 3;
+}
+
+service Items {
+  rpc Get(stream Item) returns (Item.Image);
+  rpc List(Item) returns (Item);
+}
+`
+
+func load(t *testing.T, src string) *schema.Schema {
+	fsys := fstest.MapFS{"item.proto": {Data: []byte(src)}}
+	s, err := schema.Load(context.Background(), fsys, []string{"item.proto"})
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	return s
+}
+
+type change struct {
+	severity compat.Severity
+	name     string
+	message  string
+	line     int
+}
+
+func TestCompare(t *testing.T) {
+	want := []change{
+		{compat.JSON, "itempb.Item.count", `field "count" changed its type from int32 to int64`, 9},
+		{compat.Source, "itempb.Item.size", `field "size" changed its type from int32 to uint32`, 10},
+		{compat.JSON, "itempb.Item.title", `field 4 changed its name from "title" to "heading"`, 11},
+		{compat.JSON, "itempb.Item.image", `field "image" changed its type from itempb.Item.Image to bytes`, 12},
+		{compat.Wire, "itempb.Item.score", `field "score" was moved out of the oneof "condition"`, 13},
+		{compat.Wire, "itempb.Item.tags", `field "tags" changed from repeated to singular`, 17},
+		{compat.JSON, "itempb.Item.removed", `field "removed" (9) was removed`, 4},
+		{compat.Wire, "itempb.Item.dropped", `field "dropped" changed its number from 10 to 12`, 18},
+		{compat.JSON, "itempb.Item.payload", `field "payload" changed its JSON name from "payload" to "data"`, 19},
+		{compat.JSON, "itempb.Item.payload", `field "payload" changed its type from bytes to string`, 19},
+		{compat.Wire, "itempb.Item", `reserved range 20 to 29 of "itempb.Item" was deleted`, 4},
+		{compat.Wire, "itempb.Item", `reserved number 40 of "itempb.Item" was deleted`, 4},
+		{compat.JSON, "itempb.Item", `reserved name "gone" of "itempb.Item" was deleted`, 4},
+		{compat.Source, "itempb.Removed", `message "itempb.Removed" was removed`, 25},
+		{compat.JSON, "itempb.Status.STATUS_OK", `enum value 1 changed its name from "STATUS_OK" to "STATUS_GOOD"`, 25},
+		{compat.JSON, "itempb.Status.STATUS_OLD", `enum value "STATUS_OLD" (3) was removed`, 23},
+		{compat.Wire, "itempb.Status.STATUS_MOVED", `enum value "STATUS_MOVED" changed its number from 4 to 5`, 27},
+		{compat.Wire, "itempb.Items.Get", `RPC "Get" changed its response from itempb.Item to itempb.Item.Image`, 32},
+		{compat.Wire, "itempb.Items.Get", `RPC "Get" changed its request from unary to streaming`, 32},
+		{compat.Wire, "itempb.Items.List", `RPC "List" changed its response from streaming to unary`, 33},
+		{compat.Wire, "itempb.Items.Delete", `RPC "Delete" was removed`, 31},
+	}
+
+	var got []change
+	for _, c := range compat.Compare(load(t, oldVersion), load(t, newVersion)) {
+		got = append(got, change{c.Severity, c.Name, c.Message, c.Pos.Line})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+
+	wantWire := 0
+	for _, c := range want {
+		if c.severity == compat.Wire {
+			wantWire++
+		}
+	}
+	if got := len(compat.Filter(compat.Compare(load(t, oldVersion), load(t, newVersion)), compat.Wire)); got != wantWire {
+		t.Errorf("got %v, but want %v", got, wantWire)
+	}
+}
+
+func TestCompare_compatible(t *testing.T) {
+	old := load(t, oldVersion)
+	if got := compat.Compare(old, old); len(got) != 0 {
+		t.Errorf("got %v, but want none", got)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, want := range []compat.Severity{compat.Source, compat.JSON, compat.Wire} {
+		got, err := compat.ParseSeverity(want.String())
+		if err != nil || got != want {
+			t.Errorf("got %v and err %v, but want %v", got, err, want)
+		}
+	}
+	if _, err := compat.ParseSeverity("minor"); err == nil {
+		t.Errorf("got nil, but want an error")
+	}
+}
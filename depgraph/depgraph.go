@@ -0,0 +1,335 @@
+// Package depgraph builds the dependency graphs of a linked schema: the graph of the imports between the files,
+// and the graph of the type usage between the messages, the enums and the services. It finds the cycles,
+// the transitive closures and the unused imports, and writes the graphs as DOT, Mermaid or JSON.
+package depgraph
+
+import (
+	"sort"
+
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// The kinds of the nodes.
+const (
+	FileNode    = "file"
+	MissingNode = "missing"
+	MessageNode = "message"
+	EnumNode    = "enum"
+	ServiceNode = "service"
+)
+
+// Kind is the kind of an edge.
+type Kind string
+
+// The kinds of the edges.
+const (
+	// Import is a plain import of a file.
+	Import Kind = "import"
+	// PublicImport is an "import public" of a file.
+	PublicImport Kind = "public"
+	// WeakImport is an "import weak" of a file.
+	WeakImport Kind = "weak"
+	// FieldType is the use of a type by a field of a message.
+	FieldType Kind = "field"
+	// RPCType is the use of a message by an RPC of a service.
+	RPCType Kind = "rpc"
+	// ExtendType is the use of a type by an extend nested in a message, either the extended message or the type
+	// of an extension field.
+	ExtendType Kind = "extend"
+)
+
+// Node is a node of a graph.
+type Node struct {
+	// ID is the path of a file, or the full name of a definition.
+	ID string `json:"id"`
+	// Kind is the kind of the node, like "file" or "message". An imported file which is not parsed is "missing".
+	Kind string `json:"kind"`
+	// File is the path of the file which has the definition. It is empty for a file.
+	File string `json:"file,omitempty"`
+}
+
+// Edge is a dependency of a node on another one.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind Kind   `json:"kind"`
+	// Labels are the names of the fields or the RPCs which use the type, in the order of the declaration.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Graph is a directed graph. The nodes and the edges are in the order of the files and the declaration.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+
+	nodes map[string]int
+	out   map[string][]*Edge
+	edges map[edgeKey]*Edge
+}
+
+type edgeKey struct {
+	from, to string
+	kind     Kind
+}
+
+func newGraph() *Graph {
+	return &Graph{
+		Nodes: []*Node{},
+		Edges: []*Edge{},
+		nodes: make(map[string]int),
+		out:   make(map[string][]*Edge),
+		edges: make(map[edgeKey]*Edge),
+	}
+}
+
+func (g *Graph) addNode(id, kind, file string) {
+	if _, ok := g.nodes[id]; ok {
+		return
+	}
+	g.nodes[id] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, &Node{ID: id, Kind: kind, File: file})
+}
+
+// addEdge adds the edge, or adds the label to the edge of the same nodes and kind unless it is the last label.
+func (g *Graph) addEdge(from, to string, kind Kind, label string) {
+	key := edgeKey{from: from, to: to, kind: kind}
+	e, ok := g.edges[key]
+	if !ok {
+		e = &Edge{From: from, To: to, Kind: kind}
+		g.edges[key] = e
+		g.Edges = append(g.Edges, e)
+		g.out[from] = append(g.out[from], e)
+	}
+	if label != "" && (len(e.Labels) == 0 || e.Labels[len(e.Labels)-1] != label) {
+		e.Labels = append(e.Labels, label)
+	}
+}
+
+// Node returns the node of the ID, or nil if there is none.
+func (g *Graph) Node(id string) *Node {
+	if i, ok := g.nodes[id]; ok {
+		return g.Nodes[i]
+	}
+	return nil
+}
+
+// Imports returns the graph of the imports between the files of the schema.
+func Imports(s *schema.Schema) *Graph {
+	g := newGraph()
+	for _, f := range s.Files {
+		g.addNode(f.Path, FileNode, "")
+	}
+	for _, f := range s.Files {
+		for _, imp := range f.Imports {
+			if imp.File == nil {
+				g.addNode(imp.Path, MissingNode, "")
+			}
+			g.addEdge(f.Path, imp.Path, importKind(imp.Node), "")
+		}
+	}
+	return g
+}
+
+func importKind(imp *parser.Import) Kind {
+	switch imp.Modifier {
+	case parser.ImportModifierPublic:
+		return PublicImport
+	case parser.ImportModifierWeak:
+		return WeakImport
+	}
+	return Import
+}
+
+// Types returns the graph of the type usage between the messages, the enums and the services of the schema.
+// The references to the scalar types and the unresolved references are not edges.
+func Types(s *schema.Schema) *Graph {
+	g := newGraph()
+	for _, f := range s.Files {
+		addDefinitions(g, f, f.Messages, f.Enums)
+		for _, service := range f.Services {
+			g.addNode(service.FullName, ServiceNode, f.Path)
+		}
+	}
+	for _, f := range s.Files {
+		addMessageEdges(g, f.Messages)
+		for _, service := range f.Services {
+			for _, rpc := range service.RPCs {
+				addRefEdge(g, service.FullName, rpc.Request, RPCType, rpc.Name)
+				addRefEdge(g, service.FullName, rpc.Response, RPCType, rpc.Name)
+			}
+		}
+	}
+	return g
+}
+
+func addDefinitions(g *Graph, f *schema.File, messages []*schema.Message, enums []*schema.Enum) {
+	for _, m := range messages {
+		g.addNode(m.FullName, MessageNode, f.Path)
+		addDefinitions(g, f, m.Messages, m.Enums)
+	}
+	for _, e := range enums {
+		g.addNode(e.FullName, EnumNode, f.Path)
+	}
+}
+
+func addMessageEdges(g *Graph, messages []*schema.Message) {
+	for _, m := range messages {
+		for _, field := range m.Fields {
+			addRefEdge(g, m.FullName, field.Type, FieldType, field.Name)
+		}
+		for _, e := range m.Extends {
+			addRefEdge(g, m.FullName, e.Type, ExtendType, "")
+			for _, field := range e.Fields {
+				addRefEdge(g, m.FullName, field.Type, ExtendType, field.Name)
+			}
+		}
+		addMessageEdges(g, m.Messages)
+	}
+}
+
+func addRefEdge(g *Graph, from string, ref *schema.Ref, kind Kind, label string) {
+	if ref.Message == nil && ref.Enum == nil {
+		return
+	}
+	g.addEdge(from, ref.FullName, kind, label)
+}
+
+// Closure returns the IDs of the nodes which the node depends on transitively, in the order of Nodes.
+// It has the node itself only if the node is in a cycle.
+func (g *Graph) Closure(id string) []string {
+	return g.reach(id, func(n string) []string {
+		var next []string
+		for _, e := range g.out[n] {
+			next = append(next, e.To)
+		}
+		return next
+	})
+}
+
+// Dependents returns the IDs of the nodes which depend on the node transitively, in the order of Nodes.
+// It has the node itself only if the node is in a cycle.
+func (g *Graph) Dependents(id string) []string {
+	in := make(map[string][]string)
+	for _, e := range g.Edges {
+		in[e.To] = append(in[e.To], e.From)
+	}
+	return g.reach(id, func(n string) []string {
+		return in[n]
+	})
+}
+
+func (g *Graph) reach(id string, next func(string) []string) []string {
+	seen := make(map[string]bool)
+	stack := next(id)
+	for 0 < len(stack) {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		stack = append(stack, next(n)...)
+	}
+	var ids []string
+	for n := range seen {
+		ids = append(ids, n)
+	}
+	g.sort(ids)
+	return ids
+}
+
+// sort sorts the IDs in the order of Nodes.
+func (g *Graph) sort(ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		return g.nodes[ids[i]] < g.nodes[ids[j]]
+	})
+}
+
+// Cycles returns the strongly connected components which have a cycle, which are the components of more
+// than one node and the nodes depending on themselves. Each cycle and the cycles are in the order of Nodes.
+func (g *Graph) Cycles() [][]string {
+	t := &tarjan{
+		graph:   g,
+		index:   make(map[string]int),
+		lowlink: make(map[string]int),
+		onStack: make(map[string]bool),
+	}
+	for _, n := range g.Nodes {
+		if _, ok := t.index[n.ID]; !ok {
+			t.connect(n.ID)
+		}
+	}
+
+	var cycles [][]string
+	for _, component := range t.components {
+		if len(component) == 1 && !g.dependsOn(component[0], component[0]) {
+			continue
+		}
+		g.sort(component)
+		cycles = append(cycles, component)
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return g.nodes[cycles[i][0]] < g.nodes[cycles[j][0]]
+	})
+	return cycles
+}
+
+func (g *Graph) dependsOn(from, to string) bool {
+	for _, e := range g.out[from] {
+		if e.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// tarjan finds the strongly connected components by Tarjan's algorithm.
+type tarjan struct {
+	graph      *Graph
+	counter    int
+	index      map[string]int
+	lowlink    map[string]int
+	stack      []string
+	onStack    map[string]bool
+	components [][]string
+}
+
+func (t *tarjan) connect(v string) {
+	t.index[v] = t.counter
+	t.lowlink[v] = t.counter
+	t.counter++
+	t.stack = append(t.stack, v)
+	t.onStack[v] = true
+
+	for _, e := range t.graph.out[v] {
+		w := e.To
+		if _, ok := t.index[w]; !ok {
+			t.connect(w)
+			t.lowlink[v] = min(t.lowlink[v], t.lowlink[w])
+		} else if t.onStack[w] {
+			t.lowlink[v] = min(t.lowlink[v], t.index[w])
+		}
+	}
+
+	if t.lowlink[v] == t.index[v] {
+		var component []string
+		for {
+			w := t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+			t.onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		t.components = append(t.components, component)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
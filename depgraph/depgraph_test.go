@@ -0,0 +1,276 @@
+package depgraph_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/depgraph"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var testFS = fstest.MapFS{
+	"a.proto": {Data: []byte(`syntax = "proto3";
+package a;
+import "b.proto";
+import "c.proto";
+import weak "d.proto";
+import "opts.proto";
+option (opts.label) = "a";
+message A {
+  b.B b = 1;
+  A self = 2;
+  message Inner {
+    b.Kind kind = 1;
+  }
+  repeated Inner inners = 3;
+}
+service S {
+  rpc Get(A) returns (A);
+}
+`)},
+	"b.proto": {Data: []byte(`syntax = "proto3";
+package b;
+import public "r.proto";
+import "e.proto";
+message B {
+  e.E e = 1;
+}
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+}
+`)},
+	"e.proto": {Data: []byte(`syntax = "proto3";
+package e;
+import "b.proto";
+message E {
+  b.B b = 1;
+}
+`)},
+	"r.proto": {Data: []byte(`syntax = "proto3";
+package r;
+message R {}
+`)},
+	"c.proto": {Data: []byte(`syntax = "proto3";
+package c;
+message C {}
+`)},
+	"d.proto": {Data: []byte(`syntax = "proto3";
+package d;
+message D {}
+`)},
+	"f.proto": {Data: []byte(`syntax = "proto3";
+package f;
+import "b.proto";
+message F {
+  r.R r = 1;
+}
+`)},
+	"opts.proto": {Data: []byte(`syntax = "proto3";
+package opts;
+import "google/protobuf/descriptor.proto";
+extend google.protobuf.FileOptions {
+  string label = 50000;
+}
+`)},
+}
+
+func load(t *testing.T) *schema.Schema {
+	// The error is the undefined google.protobuf.FileOptions.
+	s, _ := schema.Load(context.Background(), testFS, []string{"a.proto", "f.proto"}, protoparser.WithImportPaths("."))
+	if s == nil || len(s.Files) != 8 {
+		t.Fatalf("got %v, but want the schema of 8 files", s)
+	}
+	return s
+}
+
+func sorted(ids []string) []string {
+	ids = append([]string(nil), ids...)
+	sort.Strings(ids)
+	return ids
+}
+
+func TestImports(t *testing.T) {
+	g := depgraph.Imports(load(t))
+
+	var got []string
+	for _, e := range g.Edges {
+		if e.From == "a.proto" || e.From == "b.proto" {
+			got = append(got, e.From+" "+string(e.Kind)+" "+e.To)
+		}
+	}
+	want := []string{
+		"a.proto import b.proto",
+		"a.proto import c.proto",
+		"a.proto weak d.proto",
+		"a.proto import opts.proto",
+		"b.proto public r.proto",
+		"b.proto import e.proto",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if got := g.Node("google/protobuf/descriptor.proto"); got == nil || got.Kind != depgraph.MissingNode {
+		t.Errorf("got %v, but want the missing file", got)
+	}
+
+	cycles := g.Cycles()
+	if len(cycles) != 1 || !reflect.DeepEqual(sorted(cycles[0]), []string{"b.proto", "e.proto"}) {
+		t.Errorf("got %v, but want the cycle of b.proto and e.proto", cycles)
+	}
+	wantClosure := []string{"b.proto", "e.proto", "r.proto"}
+	if got := sorted(g.Closure("f.proto")); !reflect.DeepEqual(got, wantClosure) {
+		t.Errorf("got %v, but want %v", got, wantClosure)
+	}
+	wantDependents := []string{"a.proto", "b.proto", "e.proto", "f.proto"}
+	if got := sorted(g.Dependents("b.proto")); !reflect.DeepEqual(got, wantDependents) {
+		t.Errorf("got %v, but want %v", got, wantDependents)
+	}
+}
+
+func TestTypes(t *testing.T) {
+	g := depgraph.Types(load(t))
+
+	var got []string
+	for _, e := range g.Edges {
+		if e.From == "a.A" || e.From == "a.A.Inner" || e.From == "a.S" {
+			got = append(got, e.From+" "+string(e.Kind)+" "+e.To+" "+strings.Join(e.Labels, ","))
+		}
+	}
+	want := []string{
+		"a.A field b.B b",
+		"a.A field a.A self",
+		"a.A field a.A.Inner inners",
+		"a.A.Inner field b.Kind kind",
+		"a.S rpc a.A Get",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if got := g.Node("b.Kind"); got == nil || got.Kind != depgraph.EnumNode || got.File != "b.proto" {
+		t.Errorf("got %v, but want the enum b.Kind", got)
+	}
+
+	var cycles [][]string
+	for _, cycle := range g.Cycles() {
+		cycles = append(cycles, sorted(cycle))
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	wantCycles := [][]string{{"a.A"}, {"b.B", "e.E"}}
+	if !reflect.DeepEqual(cycles, wantCycles) {
+		t.Errorf("got %v, but want %v", cycles, wantCycles)
+	}
+	if got, want := g.Closure("a.S"), []string{"a.A", "a.A.Inner", "b.B", "b.Kind", "e.E"}; !reflect.DeepEqual(sorted(got), want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+}
+
+func TestUnusedImports(t *testing.T) {
+	var got []string
+	for _, u := range depgraph.UnusedImports(load(t)) {
+		got = append(got, u.File.Path+" "+u.Import.Path)
+	}
+	// f.proto uses r.R through the public import of b.proto, and a.proto uses opts.proto by the option.
+	want := []string{"a.proto c.proto", "a.proto d.proto"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+}
+
+func TestGraph_Write(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";
+import public "b.proto";
+message A {
+  B b = 1;
+  Kind kind = 2;
+}
+`)},
+		"b.proto": {Data: []byte(`syntax = "proto3";
+message B {}
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+}
+`)},
+	}
+	s, err := schema.Load(context.Background(), fsys, []string{"a.proto"}, protoparser.WithImportPaths("."))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		graph *depgraph.Graph
+		write func(g *depgraph.Graph, b *bytes.Buffer) error
+		want  string
+	}{
+		{
+			name:  "imports as DOT",
+			graph: depgraph.Imports(s),
+			write: func(g *depgraph.Graph, b *bytes.Buffer) error { return g.WriteDOT(b) },
+			want: `digraph dependencies {
+  "a.proto" [shape=box];
+  "b.proto" [shape=box];
+  "a.proto" -> "b.proto" [style=bold, label="public"];
+}
+`,
+		},
+		{
+			name:  "types as Mermaid",
+			graph: depgraph.Types(s),
+			write: func(g *depgraph.Graph, b *bytes.Buffer) error { return g.WriteMermaid(b) },
+			want: `flowchart LR
+  n0["A"]
+  n1["B"]
+  n2{{"Kind"}}
+  n0 -->|"b"| n1
+  n0 -->|"kind"| n2
+`,
+		},
+		{
+			name:  "imports as JSON",
+			graph: depgraph.Imports(s),
+			write: func(g *depgraph.Graph, b *bytes.Buffer) error { return g.WriteJSON(b) },
+			want: `{
+  "nodes": [
+    {
+      "id": "a.proto",
+      "kind": "file"
+    },
+    {
+      "id": "b.proto",
+      "kind": "file"
+    }
+  ],
+  "edges": [
+    {
+      "from": "a.proto",
+      "to": "b.proto",
+      "kind": "public"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := test.write(test.graph, &b); err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if got := b.String(); got != test.want {
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package depgraph
+
+import (
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// UnusedImport is an import of a file whose definitions are not used by the importing file.
+type UnusedImport struct {
+	File   *schema.File
+	Import *schema.Import
+}
+
+// UsedFiles returns the files other than f which define the types and the extensions used by f,
+// in the order of the files of the schema.
+func UsedFiles(s *schema.Schema, f *schema.File) []*schema.File {
+	used := make(map[*schema.File]bool)
+	for _, ref := range s.Refs {
+		if ref.File != f {
+			continue
+		}
+		switch {
+		case ref.Message != nil:
+			used[ref.Message.File] = true
+		case ref.Enum != nil:
+			used[ref.Enum.File] = true
+		}
+	}
+	for _, ref := range s.ExtensionRefs {
+		if ref.File == f && ref.Extension != nil {
+			used[ref.Extension.Extend.File] = true
+		}
+	}
+
+	var files []*schema.File
+	for _, file := range s.Files {
+		if file != f && used[file] {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// PublicClosure returns f and the files publicly imported by f transitively, whose definitions are
+// available to a file which imports f.
+func PublicClosure(f *schema.File) []*schema.File {
+	files := []*schema.File{f}
+	seen := map[*schema.File]bool{f: true}
+	for i := 0; i < len(files); i++ {
+		for _, imp := range files[i].Imports {
+			if importKind(imp.Node) == PublicImport && imp.File != nil && !seen[imp.File] {
+				seen[imp.File] = true
+				files = append(files, imp.File)
+			}
+		}
+	}
+	return files
+}
+
+// UnusedImports returns the imports of the files which are not imported by others, whose files and public
+// imports define nothing used by the importing file. The public imports are re-exports and are never unused,
+// and the imports of the files which are not parsed are unknown.
+func UnusedImports(s *schema.Schema) []*UnusedImport {
+	var unused []*UnusedImport
+	for _, f := range s.Files {
+		if f.Imported {
+			continue
+		}
+		used := make(map[*schema.File]bool)
+		for _, file := range UsedFiles(s, f) {
+			used[file] = true
+		}
+		for _, imp := range f.Imports {
+			if imp.File == nil || importKind(imp.Node) == PublicImport {
+				continue
+			}
+			if !UsesAny(used, PublicClosure(imp.File)) {
+				unused = append(unused, &UnusedImport{File: f, Import: imp})
+			}
+		}
+	}
+	return unused
+}
+
+// UsesAny reports whether any of the files is used, like the closure of an import given by PublicClosure.
+func UsesAny(used map[*schema.File]bool, files []*schema.File) bool {
+	for _, f := range files {
+		if used[f] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package depgraph
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the graph in the DOT language of Graphviz. The files are boxes, the public imports are bold,
+// the weak imports are dashed, and the edges other than the imports are labeled by the names of the fields
+// or the RPCs.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph dependencies {")
+	for _, n := range g.Nodes {
+		var attrs []string
+		switch n.Kind {
+		case FileNode:
+			attrs = append(attrs, "shape=box")
+		case MissingNode:
+			attrs = append(attrs, "shape=box", "style=dashed")
+		case EnumNode:
+			attrs = append(attrs, "shape=hexagon")
+		case ServiceNode:
+			attrs = append(attrs, "shape=component")
+		}
+		fmt.Fprintf(b, "  %s [%s];\n", strconv.Quote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		switch e.Kind {
+		case PublicImport:
+			attrs = append(attrs, "style=bold", `label="public"`)
+		case WeakImport:
+			attrs = append(attrs, "style=dashed", `label="weak"`)
+		case Import:
+		default:
+			if 0 < len(e.Labels) {
+				attrs = append(attrs, "label="+strconv.Quote(strings.Join(e.Labels, ", ")))
+			}
+		}
+		fmt.Fprintf(b, "  %s -> %s", strconv.Quote(e.From), strconv.Quote(e.To))
+		if 0 < len(attrs) {
+			fmt.Fprintf(b, " [%s]", strings.Join(attrs, ", "))
+		}
+		fmt.Fprintln(b, ";")
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart. The nodes are named n0, n1 and so on, and labeled
+// by their IDs.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "flowchart LR")
+	for i, n := range g.Nodes {
+		label := mermaidLabel(n.ID)
+		switch n.Kind {
+		case EnumNode:
+			fmt.Fprintf(b, "  n%d{{%s}}\n", i, label)
+		case ServiceNode:
+			fmt.Fprintf(b, "  n%d[[%s]]\n", i, label)
+		case MissingNode:
+			fmt.Fprintf(b, "  n%d[/%s/]\n", i, label)
+		default:
+			fmt.Fprintf(b, "  n%d[%s]\n", i, label)
+		}
+	}
+	for _, e := range g.Edges {
+		from, to := g.nodes[e.From], g.nodes[e.To]
+		switch e.Kind {
+		case PublicImport:
+			fmt.Fprintf(b, "  n%d ==>|public| n%d\n", from, to)
+		case WeakImport:
+			fmt.Fprintf(b, "  n%d -.->|weak| n%d\n", from, to)
+		case Import:
+			fmt.Fprintf(b, "  n%d --> n%d\n", from, to)
+		default:
+			if len(e.Labels) == 0 {
+				fmt.Fprintf(b, "  n%d --> n%d\n", from, to)
+			} else {
+				fmt.Fprintf(b, "  n%d -->|%s| n%d\n", from, mermaidLabel(strings.Join(e.Labels, ", ")), to)
+			}
+		}
+	}
+	return b.Flush()
+}
+
+// mermaidLabel quotes the text so that the characters like "/" and "." are not parsed.
+func mermaidLabel(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, "#quot;") + `"`
+}
+
+// WriteJSON writes the graph as a JSON object of "nodes" and "edges".
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
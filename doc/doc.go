@@ -0,0 +1,210 @@
+// Package doc parses the comments of an element into clean paragraphs and structured tags.
+package doc
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+// The names of the recognized tags.
+const (
+	// TagDeprecated is "@deprecated text", "Deprecated: text" or "Deprecated." at the beginning of a paragraph.
+	TagDeprecated = "deprecated"
+	// TagRequired is "Required." at the beginning of a paragraph.
+	TagRequired = "required"
+	// TagOptional is "Optional." at the beginning of a paragraph.
+	TagOptional = "optional"
+	// TagOutputOnly is "Output only." at the beginning of a paragraph.
+	TagOutputOnly = "output only"
+	// TagInputOnly is "Input only." at the beginning of a paragraph.
+	TagInputOnly = "input only"
+	// TagImmutable is "Immutable." at the beginning of a paragraph.
+	TagImmutable = "immutable"
+	// TagTODO is "TODO: text" or "TODO(arg): text".
+	TagTODO = "todo"
+)
+
+// Tag is a structured tag in a doc comment.
+type Tag struct {
+	// Name is one of the Tag constants, or the lower-cased word of any other "@word".
+	Name string
+	// Arg is the text in the parentheses of "TODO(arg)".
+	Arg string
+	// Value is the text following the tag.
+	Value string
+}
+
+// Doc is a doc comment.
+type Doc struct {
+	// Paragraphs are the texts separated by blank lines. The lines are joined by newlines and
+	// the lines of the tags are removed.
+	Paragraphs []string
+	// Tags are the tags in order of appearance.
+	Tags []*Tag
+}
+
+// Text returns the paragraphs joined by blank lines.
+func (d *Doc) Text() string {
+	return strings.Join(d.Paragraphs, "\n\n")
+}
+
+// Tag returns the first tag of the name, or nil if there is none.
+func (d *Doc) Tag(name string) *Tag {
+	for _, tag := range d.Tags {
+		if tag.Name == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+// Has reports whether the doc has a tag of the name.
+func (d *Doc) Has(name string) bool {
+	return d.Tag(name) != nil
+}
+
+// Of parses the Comments of the element. It returns an empty Doc if the element has no Comments.
+func Of(element parser.Visitee) *Doc {
+	switch e := element.(type) {
+	case *parser.Syntax:
+		return FromComments(e.Comments)
+	case *parser.Package:
+		return FromComments(e.Comments)
+	case *parser.Import:
+		return FromComments(e.Comments)
+	case *parser.Option:
+		return FromComments(e.Comments)
+	case *parser.Message:
+		return FromComments(e.Comments)
+	case *parser.Field:
+		return FromComments(e.Comments)
+	case *parser.MapField:
+		return FromComments(e.Comments)
+	case *parser.Oneof:
+		return FromComments(e.Comments)
+	case *parser.OneofField:
+		return FromComments(e.Comments)
+	case *parser.Enum:
+		return FromComments(e.Comments)
+	case *parser.EnumField:
+		return FromComments(e.Comments)
+	case *parser.Reserved:
+		return FromComments(e.Comments)
+	case *parser.Extend:
+		return FromComments(e.Comments)
+	case *parser.Service:
+		return FromComments(e.Comments)
+	case *parser.RPC:
+		return FromComments(e.Comments)
+	default:
+		return &Doc{}
+	}
+}
+
+// FromComments parses the comments. Comments separated by a blank line make separate paragraphs.
+func FromComments(comments []*parser.Comment) *Doc {
+	var lines []string
+	endLine := 0
+	for _, comment := range comments {
+		if 0 < endLine && endLine+1 < comment.Meta.Pos.Line {
+			lines = append(lines, "")
+		}
+		lines = append(lines, commentLines(comment)...)
+		endLine = comment.Meta.Pos.Line + strings.Count(comment.Raw, "\n")
+	}
+	return parse(lines)
+}
+
+// FromText parses the text of a comment without the comment markers, such as the ones of sourceinfo.Comments.
+func FromText(text string) *Doc {
+	return parse(strings.Split(text, "\n"))
+}
+
+// commentLines returns the lines of the comment without the markers and the leading "*" of a block comment.
+func commentLines(comment *parser.Comment) []string {
+	if !comment.IsCStyle() {
+		return []string{strings.TrimPrefix(comment.Raw, "//")}
+	}
+
+	lines := comment.Lines()
+	// "/**" of Javadoc.
+	lines[0] = strings.TrimPrefix(lines[0], "*")
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " \t")
+		if strings.HasPrefix(trimmed, "*") {
+			lines[i] = trimmed[1:]
+		}
+	}
+	return lines
+}
+
+var (
+	atTag       = regexp.MustCompile(`^@(\w+)\s*(.*)$`)
+	deprecated  = regexp.MustCompile(`^Deprecated:\s*(.*)$`)
+	todoTag     = regexp.MustCompile(`^TODO(?:\(([^)]*)\))?(?::|\s|$)\s*(.*)$`)
+	behaviorTag = regexp.MustCompile(`^(Required|Optional|Output only|Input only|Immutable|Deprecated)\.\s*`)
+)
+
+func parse(lines []string) *Doc {
+	doc := &Doc{}
+
+	indent := -1
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		lines[i] = line
+		if line == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || n < indent {
+			indent = n
+		}
+	}
+
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		text := strings.Join(paragraph, "\n")
+		paragraph = nil
+		for {
+			m := behaviorTag.FindStringSubmatch(text)
+			if m == nil {
+				break
+			}
+			doc.Tags = append(doc.Tags, &Tag{Name: strings.ToLower(m[1])})
+			text = text[len(m[0]):]
+		}
+		if text != "" {
+			doc.Paragraphs = append(doc.Paragraphs, text)
+		}
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			flush()
+			continue
+		}
+		line = line[indent:]
+
+		trimmed := strings.TrimSpace(line)
+		if m := atTag.FindStringSubmatch(trimmed); m != nil {
+			doc.Tags = append(doc.Tags, &Tag{Name: strings.ToLower(m[1]), Value: m[2]})
+			continue
+		}
+		if m := deprecated.FindStringSubmatch(trimmed); m != nil {
+			doc.Tags = append(doc.Tags, &Tag{Name: TagDeprecated, Value: m[1]})
+			continue
+		}
+		if m := todoTag.FindStringSubmatch(trimmed); m != nil {
+			doc.Tags = append(doc.Tags, &Tag{Name: TagTODO, Arg: m[1], Value: m[2]})
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+	return doc
+}
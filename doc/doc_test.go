@@ -0,0 +1,130 @@
+package doc_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/doc"
+	"github.com/thought-machine/go-protoparser/internal/util_test"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+func TestFromComments(t *testing.T) {
+	tests := []struct {
+		name          string
+		inputComments []*parser.Comment
+		wantDoc       *doc.Doc
+	}{
+		{
+			name: "parsing line comments",
+			inputComments: []*parser.Comment{
+				{Raw: "//   Hello is a message.  ", Meta: meta.Meta{Pos: meta.Position{Line: 1}}},
+				{Raw: "//     indented", Meta: meta.Meta{Pos: meta.Position{Line: 2}}},
+				{Raw: "//", Meta: meta.Meta{Pos: meta.Position{Line: 3}}},
+				{Raw: "//   Second paragraph.", Meta: meta.Meta{Pos: meta.Position{Line: 4}}},
+				{Raw: "//   Detached.", Meta: meta.Meta{Pos: meta.Position{Line: 6}}},
+			},
+			wantDoc: &doc.Doc{
+				Paragraphs: []string{
+					"Hello is a message.\n  indented",
+					"Second paragraph.",
+					"Detached.",
+				},
+			},
+		},
+		{
+			name: "parsing a block comment with a gutter",
+			inputComments: []*parser.Comment{
+				{
+					Raw: `/**
+   * Hello is a message.
+   *
+   * @deprecated Use World.
+   */`,
+					Meta: meta.Meta{Pos: meta.Position{Line: 1}},
+				},
+			},
+			wantDoc: &doc.Doc{
+				Paragraphs: []string{
+					"Hello is a message.",
+				},
+				Tags: []*doc.Tag{
+					{Name: doc.TagDeprecated, Value: "Use World."},
+				},
+			},
+		},
+		{
+			name: "parsing tags",
+			inputComments: []*parser.Comment{
+				{Raw: "// Output only. Immutable. The name of the book.", Meta: meta.Meta{Pos: meta.Position{Line: 1}}},
+				{Raw: "// TODO(alice): Rename it.", Meta: meta.Meta{Pos: meta.Position{Line: 2}}},
+				{Raw: "//", Meta: meta.Meta{Pos: meta.Position{Line: 3}}},
+				{Raw: "// Required.", Meta: meta.Meta{Pos: meta.Position{Line: 4}}},
+				{Raw: "// TODOs are not tags.", Meta: meta.Meta{Pos: meta.Position{Line: 5}}},
+				{Raw: "// Deprecated: Use title.", Meta: meta.Meta{Pos: meta.Position{Line: 6}}},
+				{Raw: "// @since 1.2", Meta: meta.Meta{Pos: meta.Position{Line: 7}}},
+			},
+			wantDoc: &doc.Doc{
+				Paragraphs: []string{
+					"The name of the book.",
+					"TODOs are not tags.",
+				},
+				Tags: []*doc.Tag{
+					{Name: doc.TagTODO, Arg: "alice", Value: "Rename it."},
+					{Name: doc.TagOutputOnly},
+					{Name: doc.TagImmutable},
+					{Name: doc.TagDeprecated, Value: "Use title."},
+					{Name: "since", Value: "1.2"},
+					{Name: doc.TagRequired},
+				},
+			},
+		},
+		{
+			name:    "parsing no comments",
+			wantDoc: &doc.Doc{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got := doc.FromComments(test.inputComments)
+			if !reflect.DeepEqual(got, test.wantDoc) {
+				t.Errorf("got %v, but want %v", util_test.PrettyFormat(got), util_test.PrettyFormat(test.wantDoc))
+			}
+		})
+	}
+}
+
+func TestFromText(t *testing.T) {
+	got := doc.FromText(" Required. The name.\n\n More.\n")
+	want := &doc.Doc{
+		Paragraphs: []string{"The name.", "More."},
+		Tags:       []*doc.Tag{{Name: doc.TagRequired}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", util_test.PrettyFormat(got), util_test.PrettyFormat(want))
+	}
+	if got.Text() != "The name.\n\nMore." {
+		t.Errorf("got %q, but want %q", got.Text(), "The name.\n\nMore.")
+	}
+	if !got.Has(doc.TagRequired) || got.Has(doc.TagDeprecated) {
+		t.Errorf("got %v, but want only required", got.Tags)
+	}
+}
+
+func TestOf(t *testing.T) {
+	message := &parser.Message{
+		Comments: []*parser.Comment{
+			{Raw: "// Book is a book."},
+		},
+	}
+	got := doc.Of(message).Text()
+	if got != "Book is a book." {
+		t.Errorf("got %q, but want %q", got, "Book is a book.")
+	}
+	if got := doc.Of(&parser.EmptyStatement{}); !reflect.DeepEqual(got, &doc.Doc{}) {
+		t.Errorf("got %v, but want an empty doc", got)
+	}
+}
@@ -0,0 +1,496 @@
+package dynamic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// The wire types.
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+// EncodeBinary encodes the message in the protobuf binary format.
+// The fields are in the order of the field numbers, followed by the unknown fields, and the map entries are
+// sorted by key, so that the output is deterministic.
+func (c *Codec) EncodeBinary(m *Message) ([]byte, error) {
+	return appendMessage(nil, m)
+}
+
+func appendMessage(b []byte, m *Message) ([]byte, error) {
+	for _, f := range m.Fields() {
+		k, err := kindOf(f.Type)
+		if err != nil {
+			return nil, err
+		}
+		value := m.values[f.Name]
+		switch {
+		case f.IsMap():
+			entries := value.(map[interface{}]interface{})
+			for _, key := range sortedKeys(entries) {
+				entry, err := appendValue(nil, 1, kind(f.KeyType), key)
+				if err != nil {
+					return nil, err
+				}
+				entry, err = appendValue(entry, 2, k, entries[key])
+				if err != nil {
+					return nil, err
+				}
+				b = appendTag(b, f.Number, wireBytes)
+				b = appendBytes(b, entry)
+			}
+		case f.Repeated && packed(f, k):
+			list := value.([]interface{})
+			if len(list) == 0 {
+				continue
+			}
+			var payload []byte
+			for _, v := range list {
+				payload = appendScalar(payload, k, v)
+			}
+			b = appendTag(b, f.Number, wireBytes)
+			b = appendBytes(b, payload)
+		case f.Repeated:
+			for _, v := range value.([]interface{}) {
+				if b, err = appendValue(b, f.Number, k, v); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if b, err = appendValue(b, f.Number, k, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return append(b, m.Unknown...), nil
+}
+
+// packed reports whether the repeated field is encoded packed, which is the default of numeric types in proto3.
+func packed(f *schema.Field, k kind) bool {
+	if wireType(k) == wireBytes {
+		return false
+	}
+	for _, option := range f.Options {
+		if option.OptionName == "packed" {
+			return option.Constant != "false"
+		}
+	}
+	return true
+}
+
+func wireType(k kind) int {
+	switch k {
+	case "fixed32", "sfixed32", "float":
+		return wireFixed32
+	case "fixed64", "sfixed64", "double":
+		return wireFixed64
+	case "string", "bytes", kindMessage:
+		return wireBytes
+	}
+	return wireVarint
+}
+
+func appendValue(b []byte, number int, k kind, v interface{}) ([]byte, error) {
+	b = appendTag(b, number, wireType(k))
+	switch k {
+	case "string":
+		return appendBytes(b, []byte(v.(string))), nil
+	case "bytes":
+		return appendBytes(b, v.([]byte)), nil
+	case kindMessage:
+		sub, err := appendMessage(nil, v.(*Message))
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(b, sub), nil
+	}
+	return appendScalar(b, k, v), nil
+}
+
+// appendScalar appends the numeric or bool value without the tag.
+func appendScalar(b []byte, k kind, v interface{}) []byte {
+	switch k {
+	case "int32", kindEnum:
+		return binary.AppendUvarint(b, uint64(int64(v.(int32))))
+	case "int64":
+		return binary.AppendUvarint(b, uint64(v.(int64)))
+	case "uint32":
+		return binary.AppendUvarint(b, uint64(v.(uint32)))
+	case "uint64":
+		return binary.AppendUvarint(b, v.(uint64))
+	case "sint32":
+		n := v.(int32)
+		return binary.AppendUvarint(b, uint64(uint32(n<<1)^uint32(n>>31)))
+	case "sint64":
+		n := v.(int64)
+		return binary.AppendUvarint(b, uint64(n<<1)^uint64(n>>63))
+	case "bool":
+		if v.(bool) {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case "fixed32":
+		return binary.LittleEndian.AppendUint32(b, v.(uint32))
+	case "sfixed32":
+		return binary.LittleEndian.AppendUint32(b, uint32(v.(int32)))
+	case "float":
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(v.(float32)))
+	case "fixed64":
+		return binary.LittleEndian.AppendUint64(b, v.(uint64))
+	case "sfixed64":
+		return binary.LittleEndian.AppendUint64(b, uint64(v.(int64)))
+	case "double":
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.(float64)))
+	}
+	return b
+}
+
+func appendTag(b []byte, number int, wt int) []byte {
+	return binary.AppendUvarint(b, uint64(number)<<3|uint64(wt))
+}
+
+func appendBytes(b []byte, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// sortedKeys returns the map keys in order, with false before true.
+func sortedKeys(entries map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		switch a := keys[i].(type) {
+		case int32:
+			return a < keys[j].(int32)
+		case int64:
+			return a < keys[j].(int64)
+		case uint32:
+			return a < keys[j].(uint32)
+		case uint64:
+			return a < keys[j].(uint64)
+		case string:
+			return a < keys[j].(string)
+		case bool:
+			return !a && keys[j].(bool)
+		}
+		return false
+	})
+	return keys
+}
+
+// DecodeBinary decodes the protobuf binary format into the message, merging into the fields which are set.
+// The fields which are not in the message type, or which have an unexpected wire type, are kept in Unknown.
+func (c *Codec) DecodeBinary(b []byte, m *Message) error {
+	return decodeMessage(b, m, 0)
+}
+
+// decodeMessage decodes b into m, which is nested in depth messages and groups.
+func decodeMessage(b []byte, m *Message, depth int) error {
+	for offset := 0; offset < len(b); {
+		number, wt, n, err := consumeTag(b[offset:])
+		if err != nil {
+			return fmt.Errorf("offset %d: %w", offset, err)
+		}
+		size, err := consumeValue(number, wt, b[offset+n:], depth)
+		if err != nil {
+			return fmt.Errorf("offset %d: %w", offset, err)
+		}
+		raw := payload(wt, b[offset+n:offset+n+size])
+
+		known := false
+		if f := m.fieldByNumber(number); f != nil {
+			known, err = decodeField(m, f, wt, raw, depth)
+			if err != nil {
+				return fmt.Errorf("offset %d: %s: %w", offset, f.Name, err)
+			}
+		}
+		if !known {
+			m.Unknown = append(m.Unknown, b[offset:offset+n+size]...)
+		}
+		offset += n + size
+	}
+	return nil
+}
+
+// decodeField decodes the value of the field. It returns false if the wire type is unexpected for the field.
+func decodeField(m *Message, f *schema.Field, wt int, raw []byte, depth int) (bool, error) {
+	k, err := kindOf(f.Type)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case f.IsMap():
+		if wt != wireBytes {
+			return false, nil
+		}
+		key, value, err := decodeMapEntry(f, k, raw, depth)
+		if err != nil {
+			return false, err
+		}
+		entries, _ := m.values[f.Name].(map[interface{}]interface{})
+		if entries == nil {
+			entries = make(map[interface{}]interface{})
+			m.values[f.Name] = entries
+		}
+		entries[key] = value
+		return true, nil
+
+	case f.Repeated:
+		list, _ := m.values[f.Name].([]interface{})
+		if wt == wireBytes && wireType(k) != wireBytes {
+			for len(raw) > 0 {
+				v, n, err := decodeScalar(k, wireType(k), raw)
+				if err != nil {
+					return false, err
+				}
+				list = append(list, v)
+				raw = raw[n:]
+			}
+			m.values[f.Name] = list
+			return true, nil
+		}
+		if wt != wireType(k) {
+			return false, nil
+		}
+		v, err := decodeValue(f, k, raw, nil, depth)
+		if err != nil {
+			return false, err
+		}
+		m.values[f.Name] = append(list, v)
+		return true, nil
+	}
+
+	if wt != wireType(k) {
+		return false, nil
+	}
+	var existing *Message
+	if k == kindMessage {
+		existing, _ = m.values[f.Name].(*Message)
+	}
+	v, err := decodeValue(f, k, raw, existing, depth)
+	if err != nil {
+		return false, err
+	}
+	if f.Oneof != nil {
+		for _, other := range f.Oneof.Fields {
+			delete(m.values, other.Name)
+		}
+	} else if isZero(v) {
+		delete(m.values, f.Name)
+		return true, nil
+	}
+	m.values[f.Name] = v
+	return true, nil
+}
+
+// decodeValue decodes the raw value of the wire type of the kind. A message is merged into existing if it is not nil.
+func decodeValue(f *schema.Field, k kind, raw []byte, existing *Message, depth int) (interface{}, error) {
+	switch k {
+	case "string":
+		if !utf8.Valid(raw) {
+			return nil, fmt.Errorf("found invalid UTF-8 but expected a string")
+		}
+		return string(raw), nil
+	case "bytes":
+		return append([]byte{}, raw...), nil
+	case kindMessage:
+		sub := existing
+		if sub == nil {
+			sub = NewMessage(f.Type.Message)
+		}
+		if maxDepth <= depth {
+			return nil, errTooDeep
+		}
+		if err := decodeMessage(raw, sub, depth+1); err != nil {
+			return nil, err
+		}
+		return sub, nil
+	}
+	v, _, err := decodeScalar(k, wireType(k), raw)
+	return v, err
+}
+
+func decodeMapEntry(f *schema.Field, k kind, raw []byte, depth int) (interface{}, interface{}, error) {
+	if maxDepth <= depth {
+		return nil, nil, errTooDeep
+	}
+	keyKind := kind(f.KeyType)
+	key := zeroValue(keyKind)
+	var value interface{}
+	for len(raw) > 0 {
+		number, wt, n, err := consumeTag(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		size, err := consumeValue(number, wt, raw[n:], depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		field := payload(wt, raw[n:n+size])
+		raw = raw[n+size:]
+		switch {
+		case number == 1 && wt == wireType(keyKind):
+			if key, err = decodeValue(f, keyKind, field, nil, depth+1); err != nil {
+				return nil, nil, err
+			}
+		case number == 2 && wt == wireType(k):
+			existing, _ := value.(*Message)
+			if value, err = decodeValue(f, k, field, existing, depth+1); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if value == nil {
+		if k == kindMessage {
+			value = NewMessage(f.Type.Message)
+		} else {
+			value = zeroValue(k)
+		}
+	}
+	return key, value, nil
+}
+
+// decodeScalar decodes a numeric or bool value at the start of raw and returns the number of the bytes.
+func decodeScalar(k kind, wt int, raw []byte) (interface{}, int, error) {
+	switch wt {
+	case wireFixed32:
+		if len(raw) < 4 {
+			return nil, 0, errTruncated
+		}
+		u := binary.LittleEndian.Uint32(raw)
+		switch k {
+		case "sfixed32":
+			return int32(u), 4, nil
+		case "float":
+			return math.Float32frombits(u), 4, nil
+		}
+		return u, 4, nil
+	case wireFixed64:
+		if len(raw) < 8 {
+			return nil, 0, errTruncated
+		}
+		u := binary.LittleEndian.Uint64(raw)
+		switch k {
+		case "sfixed64":
+			return int64(u), 8, nil
+		case "double":
+			return math.Float64frombits(u), 8, nil
+		}
+		return u, 8, nil
+	}
+
+	u, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return nil, 0, errTruncated
+	}
+	switch k {
+	case "int32", kindEnum:
+		return int32(u), n, nil
+	case "int64":
+		return int64(u), n, nil
+	case "uint32":
+		return uint32(u), n, nil
+	case "sint32":
+		return int32(uint32(u)>>1) ^ -int32(u&1), n, nil
+	case "sint64":
+		return int64(u>>1) ^ -int64(u&1), n, nil
+	case "bool":
+		return u != 0, n, nil
+	}
+	return u, n, nil
+}
+
+var errTruncated = errors.New("found the end of the input but expected more bytes")
+
+// maxDepth is the maximum nesting of the messages and the groups, like protobuf-go, so that a malicious input
+// cannot overflow the stack.
+const maxDepth = 10000
+
+var errTooDeep = fmt.Errorf("found more than %d nested messages or groups", maxDepth)
+
+func consumeTag(b []byte) (int, int, int, error) {
+	u, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, 0, 0, errTruncated
+	}
+	number := u >> 3
+	if number == 0 || number > 1<<29-1 {
+		return 0, 0, 0, fmt.Errorf("found the field number %d but expected 1 to %d", number, 1<<29-1)
+	}
+	return int(number), int(u & 7), n, nil
+}
+
+// payload strips the length of a length-delimited value.
+func payload(wt int, value []byte) []byte {
+	if wt != wireBytes {
+		return value
+	}
+	_, n := binary.Uvarint(value)
+	return value[n:]
+}
+
+// consumeValue returns the size of the value of the wire type at the start of b, including a whole group.
+// depth is the nesting of the value.
+func consumeValue(number int, wt int, b []byte, depth int) (int, error) {
+	switch wt {
+	case wireVarint:
+		_, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, errTruncated
+		}
+		return n, nil
+	case wireFixed32:
+		if len(b) < 4 {
+			return 0, errTruncated
+		}
+		return 4, nil
+	case wireFixed64:
+		if len(b) < 8 {
+			return 0, errTruncated
+		}
+		return 8, nil
+	case wireBytes:
+		u, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < u {
+			return 0, errTruncated
+		}
+		return n + int(u), nil
+	case wireStartGroup:
+		if maxDepth <= depth {
+			return 0, errTooDeep
+		}
+		for offset := 0; ; {
+			innerNumber, innerWT, n, err := consumeTag(b[offset:])
+			if err != nil {
+				return 0, err
+			}
+			offset += n
+			if innerWT == wireEndGroup {
+				if innerNumber != number {
+					return 0, fmt.Errorf("found the end of the group %d but expected %d", innerNumber, number)
+				}
+				return offset, nil
+			}
+			size, err := consumeValue(innerNumber, innerWT, b[offset:], depth+1)
+			if err != nil {
+				return 0, err
+			}
+			offset += size
+		}
+	}
+	return 0, fmt.Errorf("found the wire type %d but expected 0, 1, 2, 3 or 5", wt)
+}
@@ -0,0 +1,295 @@
+// Package dynamic encodes and decodes messages of linked definitions without generated code.
+//
+// A Message holds the values of its fields as a generic tree, and a Codec converts it to and from
+// the protobuf binary format, the proto3 JSON mapping and the text format.
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// ErrInvalidValue is the error of a value which does not have the Go type of the field.
+var ErrInvalidValue = errors.New("invalid value")
+
+// Message is a message of a linked definition.
+//
+// The Go types of the values are
+//   - int32 for int32, sint32, sfixed32 and enum fields
+//   - int64 for int64, sint64 and sfixed64 fields
+//   - uint32 for uint32 and fixed32 fields, and uint64 for uint64 and fixed64 fields
+//   - float32 for float fields, and float64 for double fields
+//   - bool, string and []byte for bool, string and bytes fields
+//   - *Message of the field type for message fields
+//   - []interface{} of the element values for repeated fields
+//   - map[interface{}]interface{} of the key and the element values for map fields
+type Message struct {
+	Type *schema.Message
+	// Unknown are the fields which are not in Type, in the binary format.
+	Unknown []byte
+
+	values map[string]interface{}
+}
+
+// NewMessage creates an empty message of the type.
+func NewMessage(t *schema.Message) *Message {
+	return &Message{
+		Type:   t,
+		values: make(map[string]interface{}),
+	}
+}
+
+// Field returns the field of the name, or nil if there is none.
+func (m *Message) Field(name string) *schema.Field {
+	for _, f := range m.Type.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (m *Message) fieldByNumber(number int) *schema.Field {
+	for _, f := range m.Type.Fields {
+		if f.Number == number {
+			return f
+		}
+	}
+	return nil
+}
+
+// Has reports whether the field of the name is set.
+func (m *Message) Has(name string) bool {
+	_, ok := m.values[name]
+	return ok
+}
+
+// Get returns the value of the field of the name.
+// It returns the zero value of a scalar or enum field which is not set, and nil for other fields which are not set.
+func (m *Message) Get(name string) interface{} {
+	if v, ok := m.values[name]; ok {
+		return v
+	}
+	f := m.Field(name)
+	if f == nil || f.Repeated || f.IsMap() {
+		return nil
+	}
+	k, err := kindOf(f.Type)
+	if err != nil || k == kindMessage {
+		return nil
+	}
+	return zeroValue(k)
+}
+
+// Set sets the value of the field of the name, clearing the other fields of its oneof.
+// Setting the zero value of a scalar or enum field outside a oneof clears the field, as it is not encoded.
+func (m *Message) Set(name string, value interface{}) error {
+	f := m.Field(name)
+	if f == nil {
+		return fmt.Errorf("found %q but expected a field of %s", name, m.Type.FullName)
+	}
+	if err := checkValue(f, value); err != nil {
+		return err
+	}
+	if f.Oneof != nil {
+		for _, other := range f.Oneof.Fields {
+			delete(m.values, other.Name)
+		}
+	} else if !f.Repeated && !f.IsMap() && isZero(value) {
+		delete(m.values, name)
+		return nil
+	}
+	m.values[name] = value
+	return nil
+}
+
+// Clear clears the field of the name.
+func (m *Message) Clear(name string) {
+	delete(m.values, name)
+}
+
+// Fields returns the fields which are set in the order of the field numbers.
+func (m *Message) Fields() []*schema.Field {
+	var fields []*schema.Field
+	for _, f := range m.Type.Fields {
+		if _, ok := m.values[f.Name]; ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Number < fields[j].Number
+	})
+	return fields
+}
+
+// kind is a scalar value type name, kindEnum or kindMessage.
+type kind string
+
+const (
+	kindEnum    kind = "enum"
+	kindMessage kind = "message"
+)
+
+// kindOf returns the kind of the type, which fails if the type is undefined.
+func kindOf(t *schema.Ref) (kind, error) {
+	switch {
+	case t.IsScalar():
+		return kind(t.Name), nil
+	case t.Message != nil:
+		return kindMessage, nil
+	case t.Enum != nil:
+		return kindEnum, nil
+	}
+	return "", fmt.Errorf("%s: %w %q", t.Pos, schema.ErrUndefined, t.Name)
+}
+
+func zeroValue(k kind) interface{} {
+	switch k {
+	case "int32", "sint32", "sfixed32", kindEnum:
+		return int32(0)
+	case "int64", "sint64", "sfixed64":
+		return int64(0)
+	case "uint32", "fixed32":
+		return uint32(0)
+	case "uint64", "fixed64":
+		return uint64(0)
+	case "float":
+		return float32(0)
+	case "double":
+		return float64(0)
+	case "bool":
+		return false
+	case "string":
+		return ""
+	case "bytes":
+		return []byte(nil)
+	}
+	return nil
+}
+
+func isZero(v interface{}) bool {
+	switch v := v.(type) {
+	case int32:
+		return v == 0
+	case int64:
+		return v == 0
+	case uint32:
+		return v == 0
+	case uint64:
+		return v == 0
+	case float32:
+		return v == 0
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case []byte:
+		return len(v) == 0
+	}
+	return false
+}
+
+// checkValue checks the Go type of the value of the field.
+func checkValue(f *schema.Field, value interface{}) error {
+	k, err := kindOf(f.Type)
+	if err != nil {
+		return err
+	}
+	switch {
+	case f.IsMap():
+		entries, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return fmt.Errorf("%w: found %T but expected a map for %s", ErrInvalidValue, value, f.Name)
+		}
+		for key, v := range entries {
+			if err := checkElement(f, kind(f.KeyType), key); err != nil {
+				return err
+			}
+			if err := checkElement(f, k, v); err != nil {
+				return err
+			}
+		}
+	case f.Repeated:
+		list, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%w: found %T but expected a list for %s", ErrInvalidValue, value, f.Name)
+		}
+		for _, v := range list {
+			if err := checkElement(f, k, v); err != nil {
+				return err
+			}
+		}
+	default:
+		return checkElement(f, k, value)
+	}
+	return nil
+}
+
+func checkElement(f *schema.Field, k kind, v interface{}) error {
+	if k == kindMessage {
+		if sub, ok := v.(*Message); ok && sub != nil && sub.Type == f.Type.Message {
+			return nil
+		}
+		return fmt.Errorf("%w: found %T but expected a *Message of %s for %s", ErrInvalidValue, v, f.Type.Message.FullName, f.Name)
+	}
+	want := zeroValue(k)
+	if fmt.Sprintf("%T", v) != fmt.Sprintf("%T", want) {
+		return fmt.Errorf("%w: found %T but expected %T for %s", ErrInvalidValue, v, want, f.Name)
+	}
+	return nil
+}
+
+// Codec converts messages to and from the binary format, the JSON mapping and the text format.
+type Codec struct {
+	schema         *schema.Schema
+	protoNames     bool
+	indent         string
+	discardUnknown bool
+}
+
+// Option is an option for NewCodec.
+type Option func(*Codec)
+
+// WithSchema is an option to resolve the type URLs of google.protobuf.Any messages in the JSON mapping.
+func WithSchema(s *schema.Schema) Option {
+	return func(c *Codec) {
+		c.schema = s
+	}
+}
+
+// WithProtoNames is an option to encode JSON with the field names as written rather than the lowerCamelCase JSON names.
+// Both names are always accepted when decoding.
+func WithProtoNames(protoNames bool) Option {
+	return func(c *Codec) {
+		c.protoNames = protoNames
+	}
+}
+
+// WithIndent is an option to encode JSON and the text format on multiple lines indented by indent.
+// The default is a single line.
+func WithIndent(indent string) Option {
+	return func(c *Codec) {
+		c.indent = indent
+	}
+}
+
+// WithDiscardUnknown is an option to ignore unknown field names when decoding JSON and the text format.
+func WithDiscardUnknown(discardUnknown bool) Option {
+	return func(c *Codec) {
+		c.discardUnknown = discardUnknown
+	}
+}
+
+// NewCodec creates a new Codec.
+func NewCodec(options ...Option) *Codec {
+	c := &Codec{}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
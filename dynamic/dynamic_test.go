@@ -0,0 +1,466 @@
+package dynamic_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/dynamic"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var testFS = fstest.MapFS{
+	"item.proto": {Data: []byte(`syntax = "proto3";
+package itempb;
+import "google/protobuf/wkt.proto";
+
+message Item {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_OK = 1;
+  }
+  message Image {
+    int64 display_order = 1;
+    bytes binary = 2;
+  }
+  string item_name = 1;
+  repeated Image images = 2;
+  map<int32, Status> statuses = 3;
+  google.protobuf.Timestamp created_at = 4;
+  oneof condition {
+    double score = 5;
+    Item parent = 6;
+  }
+  repeated sint32 deltas = 7;
+  repeated fixed64 ids = 8 [packed = false];
+  google.protobuf.Duration ttl = 9;
+  google.protobuf.Int32Value limit = 10;
+  google.protobuf.Struct attributes = 11;
+  google.protobuf.FieldMask mask = 12;
+  google.protobuf.Any extra = 13;
+  bool active = 14;
+  float ratio = 15;
+  uint64 big = 16;
+}
+`)},
+	"google/protobuf/wkt.proto": {Data: []byte(`syntax = "proto3";
+package google.protobuf;
+
+message Any {
+  string type_url = 1;
+  bytes value = 2;
+}
+message Timestamp {
+  int64 seconds = 1;
+  int32 nanos = 2;
+}
+message Duration {
+  int64 seconds = 1;
+  int32 nanos = 2;
+}
+message Int32Value {
+  int32 value = 1;
+}
+message FieldMask {
+  repeated string paths = 1;
+}
+message Struct {
+  map<string, Value> fields = 1;
+}
+message Value {
+  oneof kind {
+    NullValue null_value = 1;
+    double number_value = 2;
+    string string_value = 3;
+    bool bool_value = 4;
+    Struct struct_value = 5;
+    ListValue list_value = 6;
+  }
+}
+enum NullValue {
+  NULL_VALUE = 0;
+}
+message ListValue {
+  repeated Value values = 1;
+}
+`)},
+}
+
+func load(t *testing.T) *schema.Schema {
+	s, err := schema.Load(context.Background(), testFS, []string{"item.proto"}, protoparser.WithImportPaths("."))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	return s
+}
+
+func TestCodec_binary(t *testing.T) {
+	s := load(t)
+	item := s.Message("itempb.Item")
+
+	want := dynamic.NewMessage(item)
+	for name, value := range map[string]interface{}{
+		"item_name": "a",
+		"statuses":  map[interface{}]interface{}{int32(1): int32(1)},
+		"score":     0.5,
+		"deltas":    []interface{}{int32(-1), int32(1)},
+		"ids":       []interface{}{uint64(1)},
+		"active":    false,
+	} {
+		if err := want.Set(name, value); err != nil {
+			t.Fatalf("got err %v", err)
+		}
+	}
+	// The field 100 is unknown.
+	want.Unknown = []byte{0xa0, 0x06, 0x01}
+	wantBinary := "0a0161" + "1a0408011001" + "29000000000000e03f" + "3a020102" + "410100000000000000" + "a00601"
+
+	codec := dynamic.NewCodec()
+	got, err := codec.EncodeBinary(want)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if hex.EncodeToString(got) != wantBinary {
+		t.Errorf("got %x, but want %s", got, wantBinary)
+	}
+
+	decoded := dynamic.NewMessage(item)
+	if err := codec.DecodeBinary(got, decoded); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("got %v, but want %v", decoded, want)
+	}
+
+	// The packed encoding of ids is also accepted.
+	packed := dynamic.NewMessage(item)
+	if err := codec.DecodeBinary([]byte{0x42, 0x08, 1, 0, 0, 0, 0, 0, 0, 0}, packed); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if got := packed.Get("ids"); !reflect.DeepEqual(got, []interface{}{uint64(1)}) {
+		t.Errorf("got %v, but want %v", got, []interface{}{uint64(1)})
+	}
+}
+
+func TestCodec_JSON(t *testing.T) {
+	canonical := `{"itemName":"a<b","images":[{"displayOrder":"-2","binary":"AQI="}],"statuses":{"1":"STATUS_OK","2":7},` +
+		`"createdAt":"2024-01-02T03:04:05.500Z","parent":{"score":"NaN"},"deltas":[-1,1],"ids":["18446744073709551615"],` +
+		`"ttl":"-1.000000001s","limit":0,"attributes":{"a":[1,"b",true,null,{}]},"mask":"itemName,createdAt",` +
+		`"extra":{"@type":"type.googleapis.com/itempb.Item.Image","displayOrder":"3"},"active":true,"ratio":0.25,"big":"9"}`
+
+	tests := []struct {
+		name    string
+		options []dynamic.Option
+		input   string
+		want    string
+	}{
+		{
+			name:  "round-tripping the canonical encoding",
+			input: canonical,
+			want:  canonical,
+		},
+		{
+			name: "decoding the proto names, the strings of numbers and the nulls",
+			input: `{"item_name":"x","deltas":["-1",1e0],"big":9,"ratio":"Infinity","created_at":null,` +
+				`"statuses":{"1":1},"extra":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"1s"}}`,
+			want: `{"itemName":"x","statuses":{"1":"STATUS_OK"},"deltas":[-1,1],` +
+				`"extra":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"1s"},"ratio":"Infinity","big":"9"}`,
+		},
+		{
+			name: "encoding with the proto names and the indent",
+			options: []dynamic.Option{
+				dynamic.WithProtoNames(true),
+				dynamic.WithIndent("  "),
+			},
+			input: `{"itemName":"x","images":[{}]}`,
+			want: `{
+  "item_name": "x",
+  "images": [
+    {}
+  ]
+}`,
+		},
+		{
+			name: "discarding the unknown fields",
+			options: []dynamic.Option{
+				dynamic.WithDiscardUnknown(true),
+			},
+			input: `{"unknown":{"a":1},"itemName":"x"}`,
+			want:  `{"itemName":"x"}`,
+		},
+	}
+
+	s := load(t)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			codec := dynamic.NewCodec(append(test.options, dynamic.WithSchema(s))...)
+			m := dynamic.NewMessage(s.Message("itempb.Item"))
+			if err := codec.DecodeJSON([]byte(test.input), m); err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			got, err := codec.EncodeJSON(m)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %s, but want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCodec_text(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []dynamic.Option
+		input   string
+		want    string
+	}{
+		{
+			name: "round-tripping the indented encoding",
+			options: []dynamic.Option{
+				dynamic.WithIndent("  "),
+			},
+			input: `item_name: "a\"b\n\303\251"
+images {
+  display_order: -2
+  binary: "\001\002"
+}
+statuses {
+  key: 1
+  value: STATUS_OK
+}
+parent {
+  score: nan
+}
+deltas: -1
+deltas: 1
+ids: 18446744073709551615
+limit {
+}
+active: true
+ratio: 0.25
+`,
+			want: `item_name: "a\"b\né"
+images {
+  display_order: -2
+  binary: "\001\002"
+}
+statuses {
+  key: 1
+  value: STATUS_OK
+}
+parent {
+  score: nan
+}
+deltas: -1
+deltas: 1
+ids: 18446744073709551615
+limit {
+}
+active: true
+ratio: 0.25
+`,
+		},
+		{
+			name:  "decoding the lists, the comments and the alternative syntax",
+			input: "# An item.\nitem_name: 'a' \"b\", deltas: [-1, 0x2]; images: < display_order: 1 >\nstatuses { key: 2 value: 1 } ratio: -inf, score: 1",
+			want:  `item_name: "ab" images { display_order: 1 } statuses { key: 2 value: STATUS_OK } score: 1 deltas: -1 deltas: 2 ratio: -inf`,
+		},
+		{
+			name: "discarding the unknown fields",
+			options: []dynamic.Option{
+				dynamic.WithDiscardUnknown(true),
+			},
+			input: `unknown { a: [1, 2] b: "c" } other: -1 item_name: "x"`,
+			want:  `item_name: "x"`,
+		},
+	}
+
+	s := load(t)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			codec := dynamic.NewCodec(test.options...)
+			m := dynamic.NewMessage(s.Message("itempb.Item"))
+			if err := codec.DecodeText([]byte(test.input), m); err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			got, err := codec.EncodeText(m)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %s, but want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCodec_errors(t *testing.T) {
+	s := load(t)
+	codec := dynamic.NewCodec()
+	decoders := map[string]func([]byte, *dynamic.Message) error{
+		"binary": codec.DecodeBinary,
+		"JSON":   codec.DecodeJSON,
+		"text":   codec.DecodeText,
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "decoding a truncated varint",
+			format:  "binary",
+			input:   "\x08\x80",
+			wantErr: "offset 0: found the end of the input but expected more bytes",
+		},
+		{
+			name:    "decoding a truncated length",
+			format:  "binary",
+			input:   "\x0a\x05ab",
+			wantErr: "offset 0: found the end of the input but expected more bytes",
+		},
+		{
+			name:    "decoding an unknown JSON field",
+			format:  "JSON",
+			input:   `{"missing":1}`,
+			wantErr: `found "missing" but expected a field of itempb.Item`,
+		},
+		{
+			name:    "decoding an unknown enum name",
+			format:  "JSON",
+			input:   `{"statuses":{"1":"STATUS_BAD"}}`,
+			wantErr: `statuses: found "STATUS_BAD" but expected a value of itempb.Item.Status`,
+		},
+		{
+			name:    "decoding two fields of a oneof",
+			format:  "JSON",
+			input:   `{"score":1,"parent":{}}`,
+			wantErr: "expected only one field of the oneof condition",
+		},
+		{
+			name:    "decoding an out-of-range integer",
+			format:  "JSON",
+			input:   `{"deltas":[2147483648]}`,
+			wantErr: `deltas: found "2147483648" but expected a 32-bit integer`,
+		},
+		{
+			name:    "decoding a scalar without a colon",
+			format:  "text",
+			input:   `item_name "a"`,
+			wantErr: `1:11: found "\"a\"" but expected ":"`,
+		},
+		{
+			name:    "decoding an unclosed message",
+			format:  "text",
+			input:   "images {\n  display_order: 1\n",
+			wantErr: `3:1: found EOF but expected a field name or "}"`,
+		},
+		{
+			name:    "decoding too deeply nested groups",
+			format:  "binary",
+			input:   strings.Repeat("\x0b", 20<<20),
+			wantErr: "found more than 10000 nested messages or groups",
+		},
+		{
+			name:    "decoding too deeply nested messages",
+			format:  "binary",
+			input:   nestedParents(10001),
+			wantErr: "found more than 10000 nested messages or groups",
+		},
+		{
+			name:    "decoding too deeply nested text messages",
+			format:  "text",
+			input:   strings.Repeat("parent {", 10001),
+			wantErr: "1:80008: found more than 10000 nested messages or groups",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			m := dynamic.NewMessage(s.Message("itempb.Item"))
+			err := decoders[test.format]([]byte(test.input), m)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got %v, but want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCodec_maxDepth(t *testing.T) {
+	s := load(t)
+	codec := dynamic.NewCodec()
+
+	m := dynamic.NewMessage(s.Message("itempb.Item"))
+	if err := codec.DecodeBinary([]byte(nestedParents(10000)), m); err != nil {
+		t.Errorf("got err %v", err)
+	}
+	m = dynamic.NewMessage(s.Message("itempb.Item"))
+	if err := codec.DecodeText([]byte(strings.Repeat("parent {", 10000)+strings.Repeat("}", 10000)), m); err != nil {
+		t.Errorf("got err %v", err)
+	}
+}
+
+// nestedParents returns the binary format of n messages nested in the parent fields.
+func nestedParents(n int) string {
+	var b []byte
+	for i := 0; i < n; i++ {
+		length := make([]byte, binary.MaxVarintLen64)
+		length = length[:binary.PutUvarint(length, uint64(len(b)))]
+		b = append(append([]byte{0x32}, length...), b...)
+	}
+	return string(b)
+}
+
+func TestMessage_Set(t *testing.T) {
+	s := load(t)
+	m := dynamic.NewMessage(s.Message("itempb.Item"))
+
+	if err := m.Set("item_name", 1); !errors.Is(err, dynamic.ErrInvalidValue) {
+		t.Errorf("got %v, but want %v", err, dynamic.ErrInvalidValue)
+	}
+	if err := m.Set("images", []interface{}{dynamic.NewMessage(s.Message("itempb.Item"))}); !errors.Is(err, dynamic.ErrInvalidValue) {
+		t.Errorf("got %v, but want %v", err, dynamic.ErrInvalidValue)
+	}
+	if err := m.Set("missing", 1); err == nil {
+		t.Errorf("got nil, but want an error")
+	}
+
+	if err := m.Set("score", 0.0); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if err := m.Set("parent", dynamic.NewMessage(s.Message("itempb.Item"))); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if m.Has("score") || !m.Has("parent") {
+		t.Errorf("got score %v and parent %v, but want only parent", m.Has("score"), m.Has("parent"))
+	}
+
+	if err := m.Set("item_name", ""); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if m.Has("item_name") || m.Get("item_name") != "" {
+		t.Errorf("got %v, but want the unset zero value", m.Get("item_name"))
+	}
+
+	got, err := dynamic.NewCodec().EncodeBinary(m)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if want := []byte{0x32, 0x00}; !bytes.Equal(got, want) {
+		t.Errorf("got %x, but want %x", got, want)
+	}
+}
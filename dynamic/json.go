@@ -0,0 +1,441 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// EncodeJSON encodes the message in the proto3 JSON mapping.
+// The fields are in the order of the declaration and the unknown fields are dropped.
+func (c *Codec) EncodeJSON(m *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.encodeJSONMessage(&buf, m); err != nil {
+		return nil, err
+	}
+	if c.indent == "" {
+		return buf.Bytes(), nil
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", c.indent); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+func (c *Codec) encodeJSONMessage(buf *bytes.Buffer, m *Message) error {
+	if wkt, ok := wellKnownTypes[m.Type.FullName]; ok {
+		return wkt.encode(c, buf, m)
+	}
+
+	buf.WriteByte('{')
+	first := true
+	for _, f := range m.Type.Fields {
+		if !m.Has(f.Name) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		name := f.JSONName
+		if c.protoNames {
+			name = f.Name
+		}
+		writeJSONString(buf, name)
+		buf.WriteByte(':')
+		if err := c.encodeJSONField(buf, f, m.values[f.Name]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (c *Codec) encodeJSONField(buf *bytes.Buffer, f *schema.Field, value interface{}) error {
+	k, err := kindOf(f.Type)
+	if err != nil {
+		return err
+	}
+	switch {
+	case f.IsMap():
+		entries := value.(map[interface{}]interface{})
+		buf.WriteByte('{')
+		for i, key := range sortedKeys(entries) {
+			if 0 < i {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, fmt.Sprint(key))
+			buf.WriteByte(':')
+			if err := c.encodeJSONValue(buf, f.Type, k, entries[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case f.Repeated:
+		buf.WriteByte('[')
+		for i, v := range value.([]interface{}) {
+			if 0 < i {
+				buf.WriteByte(',')
+			}
+			if err := c.encodeJSONValue(buf, f.Type, k, v); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return c.encodeJSONValue(buf, f.Type, k, value)
+	}
+	return nil
+}
+
+func (c *Codec) encodeJSONValue(buf *bytes.Buffer, t *schema.Ref, k kind, v interface{}) error {
+	switch k {
+	case kindMessage:
+		return c.encodeJSONMessage(buf, v.(*Message))
+	case kindEnum:
+		n := v.(int32)
+		if t.Enum.FullName == "google.protobuf.NullValue" {
+			buf.WriteString("null")
+			return nil
+		}
+		for _, value := range t.Enum.Values {
+			if value.Number == int(n) {
+				writeJSONString(buf, value.Name)
+				return nil
+			}
+		}
+		buf.WriteString(strconv.FormatInt(int64(n), 10))
+	case "int64", "sint64", "sfixed64", "uint64", "fixed64":
+		writeJSONString(buf, fmt.Sprint(v))
+	case "float":
+		writeJSONFloat(buf, float64(v.(float32)), 32)
+	case "double":
+		writeJSONFloat(buf, v.(float64), 64)
+	case "string":
+		writeJSONString(buf, v.(string))
+	case "bytes":
+		writeJSONString(buf, base64.StdEncoding.EncodeToString(v.([]byte)))
+	default:
+		fmt.Fprint(buf, v)
+	}
+	return nil
+}
+
+// writeJSONString writes the quoted string without escaping the HTML characters.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	// Encode appends a newline.
+	buf.Truncate(buf.Len() - 1)
+}
+
+func writeJSONFloat(buf *bytes.Buffer, f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString(`"NaN"`)
+	case math.IsInf(f, 1):
+		buf.WriteString(`"Infinity"`)
+	case math.IsInf(f, -1):
+		buf.WriteString(`"-Infinity"`)
+	default:
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, bitSize))
+	}
+}
+
+// DecodeJSON decodes the proto3 JSON mapping into the message, replacing the fields in the input.
+// Both the JSON names and the names as written are accepted. A null value leaves the field unset.
+func (c *Codec) DecodeJSON(b []byte, m *Message) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if d.More() {
+		return fmt.Errorf("found more values but expected one JSON value")
+	}
+	return c.decodeJSONMessage(v, m)
+}
+
+func (c *Codec) decodeJSONMessage(v interface{}, m *Message) error {
+	if wkt, ok := wellKnownTypes[m.Type.FullName]; ok {
+		return wkt.decode(c, v, m)
+	}
+
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("found %s but expected an object of %s", jsonKind(v), m.Type.FullName)
+	}
+	for name, value := range object {
+		f := jsonField(m.Type, name)
+		if f == nil {
+			if c.discardUnknown {
+				continue
+			}
+			return fmt.Errorf("found %q but expected a field of %s", name, m.Type.FullName)
+		}
+		if err := c.decodeJSONField(f, value, m); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func jsonField(t *schema.Message, name string) *schema.Field {
+	for _, f := range t.Fields {
+		if f.JSONName == name || f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (c *Codec) decodeJSONField(f *schema.Field, value interface{}, m *Message) error {
+	k, err := kindOf(f.Type)
+	if err != nil {
+		return err
+	}
+	if value == nil && !acceptsNull(f.Type) {
+		m.Clear(f.Name)
+		return nil
+	}
+
+	switch {
+	case f.IsMap():
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("found %s but expected an object", jsonKind(value))
+		}
+		entries := make(map[interface{}]interface{})
+		for key, v := range object {
+			parsedKey, err := c.decodeJSONValue(f.Type, kind(f.KeyType), key)
+			if err != nil {
+				return err
+			}
+			if entries[parsedKey], err = c.decodeJSONValue(f.Type, k, v); err != nil {
+				return err
+			}
+		}
+		m.values[f.Name] = entries
+		return nil
+	case f.Repeated:
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("found %s but expected an array", jsonKind(value))
+		}
+		list := make([]interface{}, 0, len(array))
+		for _, v := range array {
+			parsed, err := c.decodeJSONValue(f.Type, k, v)
+			if err != nil {
+				return err
+			}
+			list = append(list, parsed)
+		}
+		m.values[f.Name] = list
+		return nil
+	}
+
+	if f.Oneof != nil {
+		for _, other := range f.Oneof.Fields {
+			if other != f && m.Has(other.Name) {
+				return fmt.Errorf("found %s but expected only one field of the oneof %s", other.Name, f.Oneof.Name)
+			}
+		}
+	}
+	parsed, err := c.decodeJSONValue(f.Type, k, value)
+	if err != nil {
+		return err
+	}
+	return m.Set(f.Name, parsed)
+}
+
+// acceptsNull reports whether null is a value of the type rather than an unset field.
+func acceptsNull(t *schema.Ref) bool {
+	return t.FullName == "google.protobuf.Value" || t.FullName == "google.protobuf.NullValue"
+}
+
+// decodeJSONValue decodes a value of the kind. A map key is given as a string.
+func (c *Codec) decodeJSONValue(t *schema.Ref, k kind, v interface{}) (interface{}, error) {
+	switch k {
+	case kindMessage:
+		sub := NewMessage(t.Message)
+		if err := c.decodeJSONMessage(v, sub); err != nil {
+			return nil, err
+		}
+		return sub, nil
+	case kindEnum:
+		return decodeJSONEnum(t.Enum, v)
+	case "bool":
+		switch v {
+		case true, "true":
+			return true, nil
+		case false, "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("found %s but expected a bool", jsonKind(v))
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("found %s but expected a string", jsonKind(v))
+		}
+		return s, nil
+	case "bytes":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("found %s but expected a base64 string", jsonKind(v))
+		}
+		return decodeBase64(s)
+	case "float", "double":
+		bitSize := 64
+		if k == "float" {
+			bitSize = 32
+		}
+		f, err := decodeJSONFloat(v, bitSize)
+		if err != nil {
+			return nil, err
+		}
+		if k == "float" {
+			return float32(f), nil
+		}
+		return f, nil
+	}
+	return decodeJSONInt(k, v)
+}
+
+func decodeJSONEnum(e *schema.Enum, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return int32(0), nil
+	case string:
+		for _, value := range e.Values {
+			if value.Name == v {
+				return int32(value.Number), nil
+			}
+		}
+		return nil, fmt.Errorf("found %q but expected a value of %s", v, e.FullName)
+	case json.Number:
+		n, err := strconv.ParseInt(string(v), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("found %s but expected an enum number", v)
+		}
+		return int32(n), nil
+	}
+	return nil, fmt.Errorf("found %s but expected a value of %s", jsonKind(v), e.FullName)
+}
+
+// decodeJSONFloat decodes a number, or a string of a number, "NaN", "Infinity" or "-Infinity".
+func decodeJSONFloat(v interface{}, bitSize int) (float64, error) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = string(v)
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		s = v
+	default:
+		return 0, fmt.Errorf("found %s but expected a number", jsonKind(v))
+	}
+	f, err := strconv.ParseFloat(s, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("found %q but expected a number", s)
+	}
+	return f, nil
+}
+
+// decodeJSONInt decodes a number or a string of a number, which may have an exponent but must be an integer.
+func decodeJSONInt(k kind, v interface{}) (interface{}, error) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return nil, fmt.Errorf("found %s but expected an integer", jsonKind(v))
+	}
+
+	signed, bitSize := true, 64
+	switch k {
+	case "int32", "sint32", "sfixed32":
+		bitSize = 32
+	case "uint32", "fixed32":
+		signed, bitSize = false, 32
+	case "uint64", "fixed64":
+		signed = false
+	}
+
+	if strings.ContainsAny(s, ".eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f != math.Trunc(f) {
+			return nil, fmt.Errorf("found %q but expected an integer", s)
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if signed {
+		n, err := strconv.ParseInt(s, 10, bitSize)
+		if err != nil {
+			return nil, fmt.Errorf("found %q but expected a %d-bit integer", s, bitSize)
+		}
+		if bitSize == 32 {
+			return int32(n), nil
+		}
+		return n, nil
+	}
+	n, err := strconv.ParseUint(s, 10, bitSize)
+	if err != nil {
+		return nil, fmt.Errorf("found %q but expected an unsigned %d-bit integer", s, bitSize)
+	}
+	if bitSize == 32 {
+		return uint32(n), nil
+	}
+	return n, nil
+}
+
+// decodeBase64 decodes the standard or the URL-safe encoding, with or without the padding.
+func decodeBase64(s string) ([]byte, error) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.URLEncoding
+	}
+	if len(s)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("found %q but expected base64", s)
+	}
+	return b, nil
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a bool"
+	case json.Number:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,718 @@
+package dynamic
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// EncodeText encodes the message in the text format.
+// The fields are in the order of the field numbers and the unknown fields are dropped.
+func (c *Codec) EncodeText(m *Message) ([]byte, error) {
+	w := &textWriter{indent: c.indent}
+	if err := w.message(m); err != nil {
+		return nil, err
+	}
+	if w.indent != "" && 0 < w.buf.Len() {
+		w.buf.WriteByte('\n')
+	}
+	return w.buf.Bytes(), nil
+}
+
+type textWriter struct {
+	buf    bytes.Buffer
+	indent string
+	depth  int
+}
+
+// next separates the next field or the closing brace from the previous output.
+func (w *textWriter) next() {
+	if w.buf.Len() == 0 {
+		return
+	}
+	if w.indent == "" {
+		w.buf.WriteByte(' ')
+		return
+	}
+	w.buf.WriteByte('\n')
+	w.buf.WriteString(strings.Repeat(w.indent, w.depth))
+}
+
+func (w *textWriter) message(m *Message) error {
+	for _, f := range m.Fields() {
+		k, err := kindOf(f.Type)
+		if err != nil {
+			return err
+		}
+		value := m.values[f.Name]
+		switch {
+		case f.IsMap():
+			entries := value.(map[interface{}]interface{})
+			for _, key := range sortedKeys(entries) {
+				w.next()
+				w.buf.WriteString(f.Name + " {")
+				w.depth++
+				w.next()
+				w.buf.WriteString("key: ")
+				w.scalar(nil, kind(f.KeyType), key)
+				if err := w.field("value", f.Type, k, entries[key]); err != nil {
+					return err
+				}
+				w.depth--
+				w.next()
+				w.buf.WriteByte('}')
+			}
+		case f.Repeated:
+			for _, v := range value.([]interface{}) {
+				if err := w.field(f.Name, f.Type, k, v); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := w.field(f.Name, f.Type, k, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *textWriter) field(name string, t *schema.Ref, k kind, v interface{}) error {
+	w.next()
+	if k != kindMessage {
+		w.buf.WriteString(name + ": ")
+		w.scalar(t, k, v)
+		return nil
+	}
+	w.buf.WriteString(name + " {")
+	w.depth++
+	if err := w.message(v.(*Message)); err != nil {
+		return err
+	}
+	w.depth--
+	w.next()
+	w.buf.WriteByte('}')
+	return nil
+}
+
+func (w *textWriter) scalar(t *schema.Ref, k kind, v interface{}) {
+	switch k {
+	case kindEnum:
+		for _, value := range t.Enum.Values {
+			if value.Number == int(v.(int32)) {
+				w.buf.WriteString(value.Name)
+				return
+			}
+		}
+		fmt.Fprint(&w.buf, v)
+	case "float":
+		w.buf.WriteString(formatTextFloat(float64(v.(float32)), 32))
+	case "double":
+		w.buf.WriteString(formatTextFloat(v.(float64), 64))
+	case "string":
+		w.buf.WriteString(quoteText([]byte(v.(string)), false))
+	case "bytes":
+		w.buf.WriteString(quoteText(v.([]byte), true))
+	default:
+		fmt.Fprint(&w.buf, v)
+	}
+}
+
+func formatTextFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// quoteText quotes the string with C-style escapes. The bytes outside printable ASCII are octal-escaped,
+// except the UTF-8 of a string.
+func quoteText(b []byte, binary bool) string {
+	var s strings.Builder
+	s.WriteByte('"')
+	for _, c := range b {
+		switch c {
+		case '\n':
+			s.WriteString(`\n`)
+		case '\r':
+			s.WriteString(`\r`)
+		case '\t':
+			s.WriteString(`\t`)
+		case '"':
+			s.WriteString(`\"`)
+		case '\'':
+			s.WriteString(`\'`)
+		case '\\':
+			s.WriteString(`\\`)
+		default:
+			if (0x20 <= c && c < 0x7f) || (!binary && utf8.RuneSelf <= c) {
+				s.WriteByte(c)
+			} else {
+				fmt.Fprintf(&s, `\%03o`, c)
+			}
+		}
+	}
+	s.WriteByte('"')
+	return s.String()
+}
+
+// DecodeText decodes the text format into the message. A singular field is replaced and a repeated field is appended to.
+// The extensions and the expanded form of google.protobuf.Any are unsupported.
+func (c *Codec) DecodeText(b []byte, m *Message) error {
+	tokens, err := lexText(string(b))
+	if err != nil {
+		return err
+	}
+	p := &textParser{Codec: c, tokens: tokens}
+	return p.message(m, "")
+}
+
+const (
+	textEOF = iota
+	textIdent
+	textNumber
+	textString
+	textSymbol
+)
+
+type textToken struct {
+	kind int
+	text string
+	line int
+	col  int
+}
+
+func (t *textToken) String() string {
+	if t.kind == textEOF {
+		return "EOF"
+	}
+	return strconv.Quote(t.text)
+}
+
+func lexText(src string) ([]*textToken, error) {
+	var tokens []*textToken
+	line, col := 1, 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := i
+		kind := textSymbol
+		switch {
+		case c == '\n':
+			line, col = line+1, 1
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			col++
+			continue
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case isTextLetter(c):
+			kind = textIdent
+			for i < len(src) && (isTextLetter(src[i]) || isTextDigit(src[i])) {
+				i++
+			}
+		case isTextDigit(c) || (c == '.' && i+1 < len(src) && isTextDigit(src[i+1])):
+			kind = textNumber
+			hex := strings.HasPrefix(strings.ToLower(src[i:]), "0x")
+			for i < len(src) {
+				d := src[i]
+				if isTextLetter(d) || isTextDigit(d) || d == '.' ||
+					((d == '+' || d == '-') && !hex && (src[i-1] == 'e' || src[i-1] == 'E')) {
+					i++
+					continue
+				}
+				break
+			}
+		case c == '"' || c == '\'':
+			kind = textString
+			for i++; ; i++ {
+				if len(src) <= i || src[i] == '\n' {
+					return nil, fmt.Errorf("%d:%d: found an unterminated string but expected %c", line, col, c)
+				}
+				if src[i] == '\\' {
+					i++
+					continue
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+			}
+		case strings.IndexByte("{}<>[]:,;-", c) >= 0:
+			i++
+		default:
+			return nil, fmt.Errorf("%d:%d: found %q but expected a token", line, col, c)
+		}
+		tokens = append(tokens, &textToken{kind: kind, text: src[start:i], line: line, col: col})
+		col += i - start
+	}
+	return append(tokens, &textToken{kind: textEOF, line: line, col: col}), nil
+}
+
+func isTextLetter(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isTextDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+type textParser struct {
+	*Codec
+	tokens []*textToken
+	// depth is the nesting of the messages.
+	depth int
+}
+
+func (p *textParser) peek() *textToken {
+	return p.tokens[0]
+}
+
+func (p *textParser) next() *textToken {
+	t := p.tokens[0]
+	if t.kind != textEOF {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+// accept consumes the next token if it is the symbol.
+func (p *textParser) accept(symbol string) bool {
+	if t := p.peek(); t.kind == textSymbol && t.text == symbol {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func unexpected(t *textToken, expected string) error {
+	return fmt.Errorf("%d:%d: found %s but expected %s", t.line, t.col, t, expected)
+}
+
+// message parses the fields until the end symbol, or EOF if end is empty.
+func (p *textParser) message(m *Message, end string) error {
+	for {
+		t := p.next()
+		switch {
+		case t.kind == textEOF && end == "":
+			return nil
+		case t.kind == textSymbol && t.text == end:
+			return nil
+		case t.kind != textIdent:
+			if end == "" {
+				return unexpected(t, "a field name")
+			}
+			return unexpected(t, "a field name or "+strconv.Quote(end))
+		}
+
+		f := m.Field(t.text)
+		if f == nil {
+			if !p.discardUnknown {
+				return fmt.Errorf("%d:%d: found %q but expected a field of %s", t.line, t.col, t.text, m.Type.FullName)
+			}
+			if err := p.skipField(); err != nil {
+				return err
+			}
+		} else if err := p.field(m, f); err != nil {
+			return err
+		}
+		if !p.accept(",") {
+			p.accept(";")
+		}
+	}
+}
+
+func (p *textParser) field(m *Message, f *schema.Field) error {
+	k, err := kindOf(f.Type)
+	if err != nil {
+		return err
+	}
+	colon := p.accept(":")
+	if !colon && !f.IsMap() && k != kindMessage {
+		return unexpected(p.peek(), `":"`)
+	}
+
+	var values []interface{}
+	if f.Repeated && p.accept("[") {
+		for !p.accept("]") {
+			if 0 < len(values) && !p.accept(",") {
+				return unexpected(p.peek(), `"," or "]"`)
+			}
+			v, err := p.element(f, k)
+			if err != nil {
+				return err
+			}
+			values = append(values, v)
+		}
+	} else {
+		v, err := p.element(f, k)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+	}
+
+	switch {
+	case f.IsMap():
+		entries, _ := m.values[f.Name].(map[interface{}]interface{})
+		if entries == nil {
+			entries = make(map[interface{}]interface{})
+			m.values[f.Name] = entries
+		}
+		for _, v := range values {
+			entry := v.([2]interface{})
+			entries[entry[0]] = entry[1]
+		}
+		return nil
+	case f.Repeated:
+		list, _ := m.values[f.Name].([]interface{})
+		m.values[f.Name] = append(list, values...)
+		return nil
+	}
+	return m.Set(f.Name, values[0])
+}
+
+// element parses a single value of the field, which is a [2]interface{} of the key and the value of a map entry.
+func (p *textParser) element(f *schema.Field, k kind) (interface{}, error) {
+	if f.IsMap() {
+		return p.mapEntry(f, k)
+	}
+	if k == kindMessage {
+		end, err := p.open()
+		if err != nil {
+			return nil, err
+		}
+		sub := NewMessage(f.Type.Message)
+		if err := p.message(sub, end); err != nil {
+			return nil, err
+		}
+		p.depth--
+		return sub, nil
+	}
+	return p.scalar(f.Type, k)
+}
+
+// open consumes "{" or "<" and returns the closing symbol. It increments the depth, which the caller decrements
+// after the closing symbol.
+func (p *textParser) open() (string, error) {
+	t := p.peek()
+	var end string
+	switch {
+	case p.accept("{"):
+		end = "}"
+	case p.accept("<"):
+		end = ">"
+	default:
+		return "", unexpected(t, `"{" or "<"`)
+	}
+	if maxDepth <= p.depth {
+		return "", fmt.Errorf("%d:%d: %w", t.line, t.col, errTooDeep)
+	}
+	p.depth++
+	return end, nil
+}
+
+func (p *textParser) mapEntry(f *schema.Field, k kind) (interface{}, error) {
+	end, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+	keyKind := kind(f.KeyType)
+	entry := [2]interface{}{zeroValue(keyKind), nil}
+	for !p.accept(end) {
+		t := p.next()
+		switch {
+		case t.kind == textIdent && t.text == "key":
+			if !p.accept(":") {
+				return nil, unexpected(p.peek(), `":"`)
+			}
+			if entry[0], err = p.scalar(f.Type, keyKind); err != nil {
+				return nil, err
+			}
+		case t.kind == textIdent && t.text == "value":
+			if !p.accept(":") && k != kindMessage {
+				return nil, unexpected(p.peek(), `":"`)
+			}
+			if entry[1], err = p.element(&schema.Field{Type: f.Type}, k); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, unexpected(t, `"key", "value" or `+strconv.Quote(end))
+		}
+		if !p.accept(",") {
+			p.accept(";")
+		}
+	}
+	p.depth--
+	if entry[1] == nil {
+		if k == kindMessage {
+			entry[1] = NewMessage(f.Type.Message)
+		} else {
+			entry[1] = zeroValue(k)
+		}
+	}
+	return entry, nil
+}
+
+// skipField skips the value of an unknown field.
+func (p *textParser) skipField() error {
+	colon := p.accept(":")
+	if p.accept("[") {
+		for !p.accept("]") {
+			if p.peek().kind == textEOF {
+				return unexpected(p.peek(), `"]"`)
+			}
+			if err := p.skipValue(colon); err != nil {
+				return err
+			}
+			p.accept(",")
+		}
+		return nil
+	}
+	return p.skipValue(colon)
+}
+
+func (p *textParser) skipValue(colon bool) error {
+	if t := p.peek(); t.kind == textSymbol && (t.text == "{" || t.text == "<") {
+		end, err := p.open()
+		if err != nil {
+			return err
+		}
+		for !p.accept(end) {
+			t := p.next()
+			if t.kind != textIdent {
+				return unexpected(t, "a field name or "+strconv.Quote(end))
+			}
+			if err := p.skipField(); err != nil {
+				return err
+			}
+			if !p.accept(",") {
+				p.accept(";")
+			}
+		}
+		p.depth--
+		return nil
+	}
+	if !colon {
+		return unexpected(p.peek(), `":"`)
+	}
+	p.accept("-")
+	t := p.next()
+	switch t.kind {
+	case textString:
+		for p.peek().kind == textString {
+			p.next()
+		}
+	case textIdent, textNumber:
+	default:
+		return unexpected(t, "a value")
+	}
+	return nil
+}
+
+// scalar parses a value of a scalar or enum kind.
+func (p *textParser) scalar(t *schema.Ref, k kind) (interface{}, error) {
+	switch k {
+	case "string", "bytes":
+		tok := p.next()
+		if tok.kind != textString {
+			return nil, unexpected(tok, "a string")
+		}
+		var b []byte
+		for {
+			s, err := unquoteText(tok.text)
+			if err != nil {
+				return nil, fmt.Errorf("%d:%d: %w", tok.line, tok.col, err)
+			}
+			b = append(b, s...)
+			if p.peek().kind != textString {
+				break
+			}
+			tok = p.next()
+		}
+		if k == "bytes" {
+			return b, nil
+		}
+		if !utf8.Valid(b) {
+			return nil, fmt.Errorf("%d:%d: found invalid UTF-8 but expected a string", tok.line, tok.col)
+		}
+		return string(b), nil
+	case "bool":
+		tok := p.next()
+		switch tok.text {
+		case "true", "True", "t", "1":
+			return true, nil
+		case "false", "False", "f", "0":
+			return false, nil
+		}
+		return nil, unexpected(tok, "a bool")
+	}
+
+	sign := ""
+	if p.accept("-") {
+		sign = "-"
+	}
+	tok := p.next()
+	switch {
+	case k == kindEnum && tok.kind == textIdent && sign == "":
+		for _, value := range t.Enum.Values {
+			if value.Name == tok.text {
+				return int32(value.Number), nil
+			}
+		}
+		return nil, fmt.Errorf("%d:%d: found %q but expected a value of %s", tok.line, tok.col, tok.text, t.Enum.FullName)
+	case (k == "float" || k == "double") && tok.kind == textIdent:
+		switch strings.ToLower(tok.text) {
+		case "inf", "infinity":
+			if sign == "" {
+				return floatValue(k, math.Inf(1)), nil
+			}
+			return floatValue(k, math.Inf(-1)), nil
+		case "nan":
+			return floatValue(k, math.NaN()), nil
+		}
+	case tok.kind == textNumber:
+		text := sign + tok.text
+		if k == "float" || k == "double" {
+			bitSize := 64
+			if k == "float" {
+				bitSize = 32
+			}
+			if !strings.HasPrefix(strings.ToLower(tok.text), "0x") {
+				text = strings.TrimRight(text, "fF")
+			}
+			f, err := strconv.ParseFloat(text, bitSize)
+			if err != nil {
+				if n, intErr := strconv.ParseInt(text, 0, 64); intErr == nil {
+					return floatValue(k, float64(n)), nil
+				}
+				return nil, fmt.Errorf("%d:%d: found %q but expected a number", tok.line, tok.col, text)
+			}
+			return floatValue(k, f), nil
+		}
+		return parseTextInt(k, text, tok)
+	}
+	return nil, unexpected(tok, "a value of "+string(k))
+}
+
+func floatValue(k kind, f float64) interface{} {
+	if k == "float" {
+		return float32(f)
+	}
+	return f
+}
+
+func parseTextInt(k kind, text string, tok *textToken) (interface{}, error) {
+	switch k {
+	case "int32", "sint32", "sfixed32", kindEnum:
+		n, err := strconv.ParseInt(text, 0, 32)
+		if err == nil {
+			return int32(n), nil
+		}
+	case "int64", "sint64", "sfixed64":
+		n, err := strconv.ParseInt(text, 0, 64)
+		if err == nil {
+			return n, nil
+		}
+	case "uint32", "fixed32":
+		n, err := strconv.ParseUint(text, 0, 32)
+		if err == nil {
+			return uint32(n), nil
+		}
+	case "uint64", "fixed64":
+		n, err := strconv.ParseUint(text, 0, 64)
+		if err == nil {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("%d:%d: found %q but expected a value of %s", tok.line, tok.col, text, k)
+}
+
+// unquoteText unquotes a string literal with C-style escapes.
+func unquoteText(quoted string) ([]byte, error) {
+	s := quoted[1 : len(quoted)-1]
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b = append(b, c)
+			continue
+		}
+		i++
+		if len(s) <= i {
+			return nil, fmt.Errorf("found %s but expected an escape sequence", quoted)
+		}
+		switch c = s[i]; c {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'a':
+			b = append(b, '\a')
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'v':
+			b = append(b, '\v')
+		case '?', '\\', '\'', '"':
+			b = append(b, c)
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			n := 0
+			j := i
+			for ; j < len(s) && j < i+3 && '0' <= s[j] && s[j] <= '7'; j++ {
+				n = n*8 + int(s[j]-'0')
+			}
+			if 255 < n {
+				return nil, fmt.Errorf("found %s but expected an octal escape up to \\377", quoted)
+			}
+			b = append(b, byte(n))
+			i = j - 1
+		case 'x', 'u', 'U':
+			size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+			if c == 'x' {
+				// \x takes one or two hex digits.
+				size = 1
+				if i+2 < len(s) && isHex(s[i+2]) {
+					size = 2
+				}
+			}
+			if len(s) < i+1+size {
+				return nil, fmt.Errorf("found %s but expected %d hex digits after \\%c", quoted, size, c)
+			}
+			n, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("found %s but expected hex digits after \\%c", quoted, c)
+			}
+			if c == 'x' {
+				b = append(b, byte(n))
+			} else {
+				b = utf8.AppendRune(b, rune(n))
+			}
+			i += size
+		default:
+			return nil, fmt.Errorf("found %s but expected a valid escape sequence", quoted)
+		}
+	}
+	return b, nil
+}
+
+func isHex(c byte) bool {
+	return isTextDigit(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
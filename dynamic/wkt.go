@@ -0,0 +1,364 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// wellKnownType is the special JSON representation of a well-known type.
+type wellKnownType struct {
+	encode func(c *Codec, buf *bytes.Buffer, m *Message) error
+	decode func(c *Codec, v interface{}, m *Message) error
+}
+
+var wellKnownTypes map[string]wellKnownType
+
+func init() {
+	wrapper := wellKnownType{encode: encodeWrapper, decode: decodeWrapper}
+	wellKnownTypes = map[string]wellKnownType{
+		"google.protobuf.Any":         {encode: encodeAny, decode: decodeAny},
+		"google.protobuf.Timestamp":   {encode: encodeTimestamp, decode: decodeTimestamp},
+		"google.protobuf.Duration":    {encode: encodeDuration, decode: decodeDuration},
+		"google.protobuf.FieldMask":   {encode: encodeFieldMask, decode: decodeFieldMask},
+		"google.protobuf.Struct":      {encode: encodeContainer("fields"), decode: decodeContainer("fields")},
+		"google.protobuf.ListValue":   {encode: encodeContainer("values"), decode: decodeContainer("values")},
+		"google.protobuf.Value":       {encode: encodeStructValue, decode: decodeStructValue},
+		"google.protobuf.DoubleValue": wrapper,
+		"google.protobuf.FloatValue":  wrapper,
+		"google.protobuf.Int64Value":  wrapper,
+		"google.protobuf.UInt64Value": wrapper,
+		"google.protobuf.Int32Value":  wrapper,
+		"google.protobuf.UInt32Value": wrapper,
+		"google.protobuf.BoolValue":   wrapper,
+		"google.protobuf.StringValue": wrapper,
+		"google.protobuf.BytesValue":  wrapper,
+	}
+}
+
+// field returns the field of the well-known type, which fails if the definition does not have it.
+func field(m *Message, name string) (*schema.Field, error) {
+	f := m.Field(name)
+	if f == nil {
+		return nil, fmt.Errorf("found no field %q but expected one in %s", name, m.Type.FullName)
+	}
+	return f, nil
+}
+
+func encodeWrapper(c *Codec, buf *bytes.Buffer, m *Message) error {
+	f, err := field(m, "value")
+	if err != nil {
+		return err
+	}
+	k, err := kindOf(f.Type)
+	if err != nil {
+		return err
+	}
+	return c.encodeJSONValue(buf, f.Type, k, m.Get("value"))
+}
+
+func decodeWrapper(c *Codec, v interface{}, m *Message) error {
+	f, err := field(m, "value")
+	if err != nil {
+		return err
+	}
+	k, err := kindOf(f.Type)
+	if err != nil {
+		return err
+	}
+	value, err := c.decodeJSONValue(f.Type, k, v)
+	if err != nil {
+		return err
+	}
+	return m.Set("value", value)
+}
+
+const (
+	minTimestamp = -62135596800 // 0001-01-01T00:00:00Z
+	maxTimestamp = 253402300799 // 9999-12-31T23:59:59Z
+)
+
+func encodeTimestamp(c *Codec, buf *bytes.Buffer, m *Message) error {
+	seconds, _ := m.Get("seconds").(int64)
+	nanos, _ := m.Get("nanos").(int32)
+	if seconds < minTimestamp || maxTimestamp < seconds || nanos < 0 || 1e9 <= nanos {
+		return fmt.Errorf("found the timestamp %ds %dns but expected 0001-01-01 to 9999-12-31", seconds, nanos)
+	}
+	t := time.Unix(seconds, int64(nanos)).UTC()
+	writeJSONString(buf, t.Format("2006-01-02T15:04:05")+fraction(nanos)+"Z")
+	return nil
+}
+
+func decodeTimestamp(c *Codec, v interface{}, m *Message) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("found %s but expected an RFC 3339 string", jsonKind(v))
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.Unix() < minTimestamp || maxTimestamp < t.Unix() {
+		return fmt.Errorf("found %q but expected an RFC 3339 timestamp", s)
+	}
+	if err := m.Set("seconds", t.Unix()); err != nil {
+		return err
+	}
+	return m.Set("nanos", int32(t.Nanosecond()))
+}
+
+// fraction returns the fractional seconds of the nanoseconds with 0, 3, 6 or 9 digits.
+func fraction(nanos int32) string {
+	if nanos == 0 {
+		return ""
+	}
+	s := fmt.Sprintf(".%09d", nanos)
+	for strings.HasSuffix(s, "000") {
+		s = strings.TrimSuffix(s, "000")
+	}
+	return s
+}
+
+const maxDurationSeconds = 315576000000
+
+func encodeDuration(c *Codec, buf *bytes.Buffer, m *Message) error {
+	seconds, _ := m.Get("seconds").(int64)
+	nanos, _ := m.Get("nanos").(int32)
+	if seconds < -maxDurationSeconds || maxDurationSeconds < seconds || nanos <= -1e9 || 1e9 <= nanos ||
+		(seconds < 0 && 0 < nanos) || (0 < seconds && nanos < 0) {
+		return fmt.Errorf("found the duration %ds %dns but expected a valid one", seconds, nanos)
+	}
+	sign := ""
+	if seconds < 0 || nanos < 0 {
+		sign = "-"
+	}
+	if seconds < 0 {
+		seconds = -seconds
+	}
+	if nanos < 0 {
+		nanos = -nanos
+	}
+	writeJSONString(buf, sign+strconv.FormatInt(seconds, 10)+fraction(nanos)+"s")
+	return nil
+}
+
+func decodeDuration(c *Codec, v interface{}, m *Message) error {
+	s, ok := v.(string)
+	if !ok || !strings.HasSuffix(s, "s") {
+		return fmt.Errorf("found %v but expected a duration like \"1.5s\"", v)
+	}
+	text := strings.TrimSuffix(s, "s")
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+	whole, frac, _ := strings.Cut(text, ".")
+	seconds, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || whole == "" || strings.HasPrefix(whole, "+") || maxDurationSeconds < seconds || 9 < len(frac) {
+		return fmt.Errorf("found %q but expected a duration like \"1.5s\"", s)
+	}
+	var nanos int64
+	if frac != "" {
+		if nanos, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 32); err != nil || strings.HasPrefix(frac, "-") {
+			return fmt.Errorf("found %q but expected a duration like \"1.5s\"", s)
+		}
+	}
+	if negative {
+		seconds, nanos = -seconds, -nanos
+	}
+	if err := m.Set("seconds", seconds); err != nil {
+		return err
+	}
+	return m.Set("nanos", int32(nanos))
+}
+
+func encodeFieldMask(c *Codec, buf *bytes.Buffer, m *Message) error {
+	paths, _ := m.Get("paths").([]interface{})
+	var camel []string
+	for _, path := range paths {
+		camel = append(camel, schema.JSONName(path.(string)))
+	}
+	writeJSONString(buf, strings.Join(camel, ","))
+	return nil
+}
+
+func decodeFieldMask(c *Codec, v interface{}, m *Message) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("found %s but expected a string of comma-separated paths", jsonKind(v))
+	}
+	var paths []interface{}
+	for _, path := range strings.Split(s, ",") {
+		if path == "" {
+			continue
+		}
+		var b strings.Builder
+		for _, r := range path {
+			if 'A' <= r && r <= 'Z' {
+				b.WriteByte('_')
+				r += 'a' - 'A'
+			}
+			b.WriteRune(r)
+		}
+		paths = append(paths, b.String())
+	}
+	return m.Set("paths", paths)
+}
+
+// encodeContainer encodes a Struct or a ListValue as the JSON value of its only field.
+func encodeContainer(name string) func(c *Codec, buf *bytes.Buffer, m *Message) error {
+	return func(c *Codec, buf *bytes.Buffer, m *Message) error {
+		f, err := field(m, name)
+		if err != nil {
+			return err
+		}
+		if !m.Has(name) {
+			if f.IsMap() {
+				buf.WriteString("{}")
+			} else {
+				buf.WriteString("[]")
+			}
+			return nil
+		}
+		return c.encodeJSONField(buf, f, m.values[name])
+	}
+}
+
+func decodeContainer(name string) func(c *Codec, v interface{}, m *Message) error {
+	return func(c *Codec, v interface{}, m *Message) error {
+		f, err := field(m, name)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return fmt.Errorf("found null but expected a value of %s", m.Type.FullName)
+		}
+		return c.decodeJSONField(f, v, m)
+	}
+}
+
+func encodeStructValue(c *Codec, buf *bytes.Buffer, m *Message) error {
+	for _, f := range m.Fields() {
+		switch f.Name {
+		case "null_value":
+			buf.WriteString("null")
+			return nil
+		case "number_value", "string_value", "bool_value", "struct_value", "list_value":
+			return c.encodeJSONField(buf, f, m.values[f.Name])
+		}
+	}
+	return fmt.Errorf("found no kind but expected one to be set in %s", m.Type.FullName)
+}
+
+func decodeStructValue(c *Codec, v interface{}, m *Message) error {
+	var name string
+	switch v.(type) {
+	case nil:
+		name = "null_value"
+	case bool:
+		name = "bool_value"
+	case json.Number:
+		name = "number_value"
+	case string:
+		name = "string_value"
+	case map[string]interface{}:
+		name = "struct_value"
+	case []interface{}:
+		name = "list_value"
+	}
+	f, err := field(m, name)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return m.Set(name, int32(0))
+	}
+	return c.decodeJSONField(f, v, m)
+}
+
+// resolveAny returns the message type of the type URL, like "type.googleapis.com/foo.Bar".
+func (c *Codec) resolveAny(typeURL string) (*schema.Message, error) {
+	if c.schema == nil {
+		return nil, fmt.Errorf("found the type URL %q but expected WithSchema to resolve it", typeURL)
+	}
+	t := c.schema.Message(typeURL[strings.LastIndexByte(typeURL, '/')+1:])
+	if t == nil {
+		return nil, fmt.Errorf("found the type URL %q but expected a message in the schema", typeURL)
+	}
+	return t, nil
+}
+
+func encodeAny(c *Codec, buf *bytes.Buffer, m *Message) error {
+	typeURL, _ := m.Get("type_url").(string)
+	value, _ := m.Get("value").([]byte)
+	if typeURL == "" && len(value) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+	t, err := c.resolveAny(typeURL)
+	if err != nil {
+		return err
+	}
+	sub := NewMessage(t)
+	if err := decodeMessage(value, sub, 0); err != nil {
+		return err
+	}
+
+	var inner bytes.Buffer
+	if err := c.encodeJSONMessage(&inner, sub); err != nil {
+		return err
+	}
+	buf.WriteString(`{"@type":`)
+	writeJSONString(buf, typeURL)
+	if _, ok := wellKnownTypes[t.FullName]; ok {
+		buf.WriteString(`,"value":`)
+		buf.Write(inner.Bytes())
+	} else if fields := inner.Bytes()[1 : inner.Len()-1]; 0 < len(fields) {
+		buf.WriteByte(',')
+		buf.Write(fields)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func decodeAny(c *Codec, v interface{}, m *Message) error {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("found %s but expected an object of %s", jsonKind(v), m.Type.FullName)
+	}
+	if len(object) == 0 {
+		return nil
+	}
+	typeURL, ok := object["@type"].(string)
+	if !ok {
+		return fmt.Errorf("found no \"@type\" but expected the type URL of %s", m.Type.FullName)
+	}
+	t, err := c.resolveAny(typeURL)
+	if err != nil {
+		return err
+	}
+
+	sub := NewMessage(t)
+	if _, ok := wellKnownTypes[t.FullName]; ok {
+		err = c.decodeJSONMessage(object["value"], sub)
+	} else {
+		fields := make(map[string]interface{}, len(object)-1)
+		for name, value := range object {
+			if name != "@type" {
+				fields[name] = value
+			}
+		}
+		err = c.decodeJSONMessage(fields, sub)
+	}
+	if err != nil {
+		return err
+	}
+	value, err := appendMessage(nil, sub)
+	if err != nil {
+		return err
+	}
+	if err := m.Set("type_url", typeURL); err != nil {
+		return err
+	}
+	return m.Set("value", value)
+}
@@ -0,0 +1,111 @@
+// Package edit applies the text edits of the sources, which the lint fixes, the refactorings and the import
+// organization compute, and tokenizes a source to find the offsets of its statements.
+package edit
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// Edit replaces the bytes of the source from the offset Start to End by NewText.
+// An Edit with Start equal to End inserts NewText.
+type Edit struct {
+	Start   int
+	End     int
+	NewText string
+}
+
+// Overlaps reports whether the edits replace the same bytes or start at the same offset.
+func (e *Edit) Overlaps(other *Edit) bool {
+	return e.Start == other.Start || (e.Start < other.End && other.Start < e.End)
+}
+
+// Apply applies the edits to src in the order of their offsets. It returns an error if an edit is out of src
+// or overlaps the preceding one.
+func Apply(src []byte, edits []*Edit) ([]byte, error) {
+	sorted := append([]*Edit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	var b bytes.Buffer
+	last := 0
+	for _, e := range sorted {
+		if e.Start < last || e.End < e.Start || len(src) < e.End {
+			return nil, fmt.Errorf("found an overlapping edit at offset %d", e.Start)
+		}
+		b.Write(src[last:e.Start])
+		b.WriteString(e.NewText)
+		last = e.End
+	}
+	b.Write(src[last:])
+	return b.Bytes(), nil
+}
+
+// ApplyFiles applies the edits to the sources keyed by the same paths. It returns the edited sources of the paths
+// which have edits, and an error if a path has no source or two edits of a file overlap.
+func ApplyFiles(sources map[string][]byte, edits map[string][]*Edit) (map[string][]byte, error) {
+	edited := make(map[string][]byte)
+	for path, fileEdits := range edits {
+		src, ok := sources[path]
+		if !ok {
+			return nil, fmt.Errorf("found no source of %s", path)
+		}
+		b, err := Apply(src, fileEdits)
+		if err != nil {
+			return nil, fmt.Errorf("%w of %s", err, path)
+		}
+		edited[path] = b
+	}
+	return edited, nil
+}
+
+// Source is the tokenized source of a file.
+type Source struct {
+	Src   []byte
+	Items []*tokenizer.Item
+	// index maps an offset to the index of the token there.
+	index map[int]int
+}
+
+// NewSource tokenizes src tolerantly, so that a source which cannot be parsed still has its tokens.
+func NewSource(src []byte) *Source {
+	items, _ := tokenizer.Tokenize(bytes.NewReader(src), tokenizer.WithTolerant(true))
+	s := &Source{
+		Src:   src,
+		Items: items,
+		index: make(map[int]int),
+	}
+	for i, item := range items {
+		s.index[item.Pos.Offset] = i
+	}
+	return s
+}
+
+// Index returns the index of the token at the offset.
+func (s *Source) Index(offset int) (int, bool) {
+	i, ok := s.index[offset]
+	return i, ok
+}
+
+// StatementEnd returns the offset after the ";" of the statement starting at the offset, and after its inline
+// comment if any.
+func (s *Source) StatementEnd(offset int, inline *parser.Comment) (int, bool) {
+	i, ok := s.index[offset]
+	if !ok {
+		return 0, false
+	}
+	for ; i < len(s.Items); i++ {
+		if s.Items[i].Token == tokenizer.TSEMICOLON {
+			end := s.Items[i].End.Offset
+			if inline != nil {
+				end = inline.Meta.Pos.Offset + len(inline.Raw)
+			}
+			return end, true
+		}
+	}
+	return 0, false
+}
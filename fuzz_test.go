@@ -0,0 +1,117 @@
+package protoparser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/printer"
+)
+
+func addTestdataSeeds(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("_testdata", "*.proto"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(content))
+	}
+}
+
+// countVisitor visits every element to make sure that the parsed tree is well-formed.
+type countVisitor struct {
+	count int
+}
+
+func (v *countVisitor) VisitComment(*parser.Comment)                    { v.count++ }
+func (v *countVisitor) VisitEmptyStatement(*parser.EmptyStatement) bool { v.count++; return true }
+func (v *countVisitor) VisitEnum(*parser.Enum) bool                     { v.count++; return true }
+func (v *countVisitor) VisitEnumField(*parser.EnumField) bool           { v.count++; return true }
+func (v *countVisitor) VisitExtend(*parser.Extend) bool                 { v.count++; return true }
+func (v *countVisitor) VisitField(*parser.Field) bool                   { v.count++; return true }
+func (v *countVisitor) VisitImport(*parser.Import) bool                 { v.count++; return true }
+func (v *countVisitor) VisitMapField(*parser.MapField) bool             { v.count++; return true }
+func (v *countVisitor) VisitMessage(*parser.Message) bool               { v.count++; return true }
+func (v *countVisitor) VisitOneof(*parser.Oneof) bool                   { v.count++; return true }
+func (v *countVisitor) VisitOneofField(*parser.OneofField) bool         { v.count++; return true }
+func (v *countVisitor) VisitOption(*parser.Option) bool                 { v.count++; return true }
+func (v *countVisitor) VisitPackage(*parser.Package) bool               { v.count++; return true }
+func (v *countVisitor) VisitReserved(*parser.Reserved) bool             { v.count++; return true }
+func (v *countVisitor) VisitRPC(*parser.RPC) bool                       { v.count++; return true }
+func (v *countVisitor) VisitService(*parser.Service) bool               { v.count++; return true }
+func (v *countVisitor) VisitSyntax(*parser.Syntax) bool                 { v.count++; return true }
+
+func FuzzParse(f *testing.F) {
+	addTestdataSeeds(f)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, permissive := range []bool{true, false} {
+			for _, bodyIncludingComments := range []bool{true, false} {
+				got, err := protoparser.Parse(
+					strings.NewReader(input),
+					protoparser.WithPermissive(permissive),
+					protoparser.WithBodyIncludingComments(bodyIncludingComments),
+				)
+				if err != nil {
+					continue
+				}
+
+				got.Accept(&countVisitor{})
+				if bodyIncludingComments {
+					// unordered.Proto has no place for the body comments.
+					continue
+				}
+				if _, err := protoparser.UnorderedInterpret(got); err != nil {
+					t.Errorf("got err %v, but want nil", err)
+				}
+			}
+		}
+	})
+}
+
+func FuzzParseRoundTrip(f *testing.F) {
+	addTestdataSeeds(f)
+	f.Add(`syntax = "proto3";
+service S {
+  rpc Get(R) returns (R) {
+    option (google.api.http) = {
+      get: "/v1/a"
+      additional_bindings { post: "/v1/b" body: "*" }
+      additional_bindings { body: "r" put: "/v1/c" }
+    };
+  }
+}
+`)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		// The permissive mode parses the google.api.http options with their additional bindings.
+		first, err := protoparser.Parse(strings.NewReader(input), protoparser.WithPermissive(true))
+		if err != nil {
+			return
+		}
+		printed, err := printer.Sprint(first)
+		if err != nil {
+			t.Fatalf("got err %v, but want nil", err)
+		}
+
+		second, err := protoparser.Parse(strings.NewReader(printed), protoparser.WithPermissive(true))
+		if err != nil {
+			t.Fatalf("got err %v, but want nil. printed=%s", err, printed)
+		}
+		reprinted, err := printer.Sprint(second)
+		if err != nil {
+			t.Fatalf("got err %v, but want nil", err)
+		}
+
+		if printed != reprinted {
+			t.Errorf("got %s, but want %s", reprinted, printed)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package highlight
+
+// Class is a category of a piece of the input.
+type Class int
+
+// The Class of a Span is one of these.
+const (
+	// ClassNone is whitespace and anything else not categorized.
+	ClassNone Class = iota
+	ClassKeyword
+	// ClassType is a type of a field or a message type of an rpc or an extend.
+	ClassType
+	// ClassName is a name of a package, message, enum, service, rpc or oneof.
+	ClassName
+	// ClassFieldName is a name of a field or an enum value.
+	ClassFieldName
+	// ClassOptionName is a name of an option or a field of an option literal.
+	ClassOptionName
+	ClassNumber
+	ClassString
+	ClassComment
+	ClassPunctuation
+	// ClassIllegal is a piece the tokenizer failed to recognize.
+	ClassIllegal
+)
+
+var classNames = map[Class]string{
+	ClassNone:        "none",
+	ClassKeyword:     "keyword",
+	ClassType:        "type",
+	ClassName:        "name",
+	ClassFieldName:   "field-name",
+	ClassOptionName:  "option-name",
+	ClassNumber:      "number",
+	ClassString:      "string",
+	ClassComment:     "comment",
+	ClassPunctuation: "punctuation",
+	ClassIllegal:     "illegal",
+}
+
+// String returns the name of the class, which is also used as the CSS class.
+func (c Class) String() string {
+	return classNames[c]
+}
@@ -0,0 +1,253 @@
+package highlight
+
+import (
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// classifier refines the classes of tokens by visiting the parsed elements.
+type classifier struct {
+	items   []*tokenizer.Item
+	classes []Class
+	// index maps an offset to the index of the token there.
+	index map[int]int
+}
+
+func newClassifier(items []*tokenizer.Item) *classifier {
+	c := &classifier{
+		items:   items,
+		classes: make([]Class, len(items)),
+		index:   make(map[int]int, len(items)),
+	}
+	for i, item := range items {
+		c.classes[i] = tokenClass(item.Token)
+		c.index[item.Pos.Offset] = i
+	}
+	return c
+}
+
+// tokenClass returns the class of a token without the context.
+func tokenClass(tok tokenizer.Token) Class {
+	switch {
+	case tokenizer.IsKeyword(tok), tok == tokenizer.TBOOLLIT:
+		return ClassKeyword
+	case tok == tokenizer.TINTLIT, tok == tokenizer.TFLOATLIT:
+		return ClassNumber
+	case tok == tokenizer.TSTRLIT:
+		return ClassString
+	case tok == tokenizer.TIDENT, tok == tokenizer.TEOF:
+		return ClassNone
+	case tok == tokenizer.TILLEGAL:
+		return ClassIllegal
+	default:
+		return ClassPunctuation
+	}
+}
+
+// at returns the index of the token at pos, or len(items) if there is none.
+func (c *classifier) at(pos meta.Position) int {
+	if i, ok := c.index[pos.Offset]; ok {
+		return i
+	}
+	return len(c.items)
+}
+
+func (c *classifier) is(i int, tok tokenizer.Token) bool {
+	return i < len(c.items) && c.items[i].Token == tok
+}
+
+// set sets the class of the token at i and returns the next index.
+func (c *classifier) set(i int, class Class) int {
+	if i < len(c.items) {
+		c.classes[i] = class
+	}
+	return i + 1
+}
+
+// skip returns the index after the token at i if it is tok.
+func (c *classifier) skip(i int, tok tokenizer.Token) int {
+	if c.is(i, tok) {
+		return i + 1
+	}
+	return i
+}
+
+// fullIdent sets class to the tokens from i which make up text, and returns the next index.
+func (c *classifier) fullIdent(i int, text string, class Class) int {
+	for ; text != "" && i < len(c.items); i++ {
+		t := c.items[i].Text
+		if t == "" || !strings.HasPrefix(text, t) {
+			break
+		}
+		c.classes[i] = class
+		text = text[len(t):]
+	}
+	return i
+}
+
+// fieldRest classifies `fieldName "=" fieldNumber [ "[" fieldOptions "]" ]` from i.
+func (c *classifier) fieldRest(i int) {
+	i = c.set(i, ClassFieldName)
+	i = c.skip(i, tokenizer.TEQUALS)
+	if c.is(i, tokenizer.TMINUS) {
+		i = c.set(i, ClassNumber)
+	}
+	i = c.set(i, ClassNumber)
+	if c.is(i, tokenizer.TLEFTSQUARE) {
+		c.options(i)
+	}
+}
+
+// options classifies the option names of an option statement or field options starting at i.
+func (c *classifier) options(i int) {
+	// names is a stack which tells whether the elements of each bracket start with a name.
+	var names []bool
+	name := true
+	for ; i < len(c.items); i++ {
+		item := c.items[i]
+		switch item.Token {
+		case tokenizer.TSEMICOLON:
+			if len(names) == 0 {
+				return
+			}
+		case tokenizer.TEQUALS, tokenizer.TCOLON:
+			name = false
+		case tokenizer.TLEFTCURLY:
+			names = append(names, true)
+			name = true
+		case tokenizer.TLEFTSQUARE:
+			// A square bracket after "=" or ":" is a list of values.
+			names = append(names, name)
+		case tokenizer.TRIGHTCURLY, tokenizer.TRIGHTSQUARE:
+			if len(names) == 0 {
+				return
+			}
+			names = names[:len(names)-1]
+			if len(names) == 0 && item.Token == tokenizer.TRIGHTSQUARE {
+				return
+			}
+			name = false
+		case tokenizer.TCOMMA:
+			name = len(names) == 0 || names[len(names)-1]
+		case tokenizer.TIDENT, tokenizer.TDOT:
+			// A text format literal may omit the commas between the fields.
+			inLiteral := 0 < len(names) && names[len(names)-1]
+			if name || (inLiteral && (c.is(i+1, tokenizer.TCOLON) || c.is(i+1, tokenizer.TLEFTCURLY))) {
+				c.classes[i] = ClassOptionName
+			}
+		default:
+			if name && tokenizer.IsKeyword(item.Token) {
+				c.classes[i] = ClassOptionName
+			}
+		}
+	}
+}
+
+func (c *classifier) VisitComment(*parser.Comment) {}
+
+func (c *classifier) VisitEmptyStatement(*parser.EmptyStatement) bool {
+	return true
+}
+
+func (c *classifier) VisitEnum(e *parser.Enum) bool {
+	i := c.at(e.Meta.Pos)
+	c.set(i+1, ClassName)
+	return true
+}
+
+func (c *classifier) VisitEnumField(f *parser.EnumField) bool {
+	c.fieldRest(c.at(f.Meta.Pos))
+	return true
+}
+
+func (c *classifier) VisitExtend(e *parser.Extend) bool {
+	i := c.at(e.Meta.Pos)
+	c.fullIdent(i+1, e.MessageType, ClassType)
+	return true
+}
+
+func (c *classifier) VisitField(f *parser.Field) bool {
+	i := c.skip(c.at(f.Meta.Pos), tokenizer.TREPEATED)
+	i = c.fullIdent(i, f.Type, ClassType)
+	c.fieldRest(i)
+	return true
+}
+
+func (c *classifier) VisitImport(*parser.Import) bool {
+	return true
+}
+
+func (c *classifier) VisitMapField(m *parser.MapField) bool {
+	i := c.at(m.Meta.Pos) + 1
+	i = c.skip(i, tokenizer.TLESS)
+	i = c.fullIdent(i, m.KeyType, ClassType)
+	i = c.skip(i, tokenizer.TCOMMA)
+	i = c.fullIdent(i, m.Type, ClassType)
+	i = c.skip(i, tokenizer.TGREATER)
+	c.fieldRest(i)
+	return true
+}
+
+func (c *classifier) VisitMessage(m *parser.Message) bool {
+	i := c.at(m.Meta.Pos)
+	c.set(i+1, ClassName)
+	return true
+}
+
+func (c *classifier) VisitOneof(o *parser.Oneof) bool {
+	i := c.at(o.Meta.Pos)
+	c.set(i+1, ClassName)
+	return true
+}
+
+func (c *classifier) VisitOneofField(f *parser.OneofField) bool {
+	i := c.fullIdent(c.at(f.Meta.Pos), f.Type, ClassType)
+	c.fieldRest(i)
+	return true
+}
+
+func (c *classifier) VisitOption(o *parser.Option) bool {
+	c.options(c.at(o.Meta.Pos) + 1)
+	return true
+}
+
+func (c *classifier) VisitPackage(p *parser.Package) bool {
+	i := c.at(p.Meta.Pos)
+	c.fullIdent(i+1, p.Name, ClassName)
+	return true
+}
+
+func (c *classifier) VisitReserved(*parser.Reserved) bool {
+	return true
+}
+
+func (c *classifier) VisitRPC(r *parser.RPC) bool {
+	i := c.at(r.Meta.Pos)
+	i = c.set(i+1, ClassName)
+	i = c.skip(i, tokenizer.TLEFTPAREN)
+	i = c.skip(i, tokenizer.TSTREAM)
+	i = c.fullIdent(i, r.RPCRequest.MessageType, ClassType)
+	i = c.skip(i, tokenizer.TRIGHTPAREN)
+	i = c.skip(i, tokenizer.TRETURNS)
+	i = c.skip(i, tokenizer.TLEFTPAREN)
+	i = c.skip(i, tokenizer.TSTREAM)
+	c.fullIdent(i, r.RPCResponse.MessageType, ClassType)
+
+	for _, option := range r.Options {
+		c.VisitOption(option)
+	}
+	return true
+}
+
+func (c *classifier) VisitService(s *parser.Service) bool {
+	i := c.at(s.Meta.Pos)
+	c.set(i+1, ClassName)
+	return true
+}
+
+func (c *classifier) VisitSyntax(*parser.Syntax) bool {
+	return true
+}
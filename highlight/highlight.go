@@ -0,0 +1,63 @@
+// Package highlight renders a Protocol Buffer file with syntax highlighting.
+package highlight
+
+import (
+	"bytes"
+	"io"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// Span is a classified piece of the input.
+type Span struct {
+	Class Class
+	Text  string
+	// Pos is the position of the first character.
+	Pos meta.Position
+}
+
+// Classify splits the input into Spans, whose texts concatenated are the input itself.
+//
+// Tokens are classified by the parsed elements, so that the same identifier is a type in one place and
+// a field name in another. If the input cannot be parsed, they are classified only by the token.
+func Classify(input io.Reader) ([]*Span, error) {
+	src, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := tokenizer.Tokenize(bytes.NewReader(src), tokenizer.WithTolerant(true))
+	if err != nil {
+		return nil, err
+	}
+	c := newClassifier(items)
+	if proto, err := protoparser.Parse(bytes.NewReader(src)); err == nil {
+		proto.Accept(c)
+	}
+
+	var spans []*Span
+	for i, item := range items {
+		for _, trivia := range item.LeadingTrivia {
+			class := ClassNone
+			if trivia.Kind == tokenizer.TriviaComment {
+				class = ClassComment
+			}
+			spans = append(spans, &Span{
+				Class: class,
+				Text:  trivia.Text,
+				Pos:   trivia.Pos,
+			})
+		}
+		if item.Token == tokenizer.TEOF {
+			break
+		}
+		spans = append(spans, &Span{
+			Class: c.classes[i],
+			Text:  item.Text,
+			Pos:   item.Pos,
+		})
+	}
+	return spans, nil
+}
@@ -0,0 +1,147 @@
+package highlight_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/highlight"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// wantSpans are the classes and texts of the spans except whitespace.
+		wantSpans []string
+	}{
+		{
+			name: "classifying by the parsed elements",
+			input: `syntax = "proto3";
+package foo.v1;
+// Hello is a message.
+message Hello {
+  repeated string message = 1 [(validator.field) = {msg_exists: true, in: ["A"]}];
+  map<string, foo.v1.Hello> m = 2;
+  oneof choice { int32 n = 3; }
+}
+enum E { E_UNSPECIFIED = 0; E_ONE = 1 [deprecated = true]; }
+service S {
+  rpc Get(stream Hello) returns (.foo.v1.Hello) {
+    option (google.api.http) = { get: "/v1" };
+  }
+}
+`,
+			wantSpans: []string{
+				"keyword:syntax", "punctuation:=", `string:"proto3"`, "punctuation:;",
+				"keyword:package", "name:foo", "name:.", "name:v1", "punctuation:;",
+				"comment:// Hello is a message.",
+				"keyword:message", "name:Hello", "punctuation:{",
+				"keyword:repeated", "type:string", "field-name:message", "punctuation:=", "number:1",
+				"punctuation:[", "punctuation:(", "option-name:validator", "option-name:.", "option-name:field", "punctuation:)",
+				"punctuation:=", "punctuation:{", "option-name:msg_exists", "punctuation::", "keyword:true", "punctuation:,",
+				"option-name:in", "punctuation::", "punctuation:[", `string:"A"`, "punctuation:]", "punctuation:}",
+				"punctuation:]", "punctuation:;",
+				"keyword:map", "punctuation:<", "type:string", "punctuation:,", "type:foo", "type:.", "type:v1", "type:.", "type:Hello",
+				"punctuation:>", "field-name:m", "punctuation:=", "number:2", "punctuation:;",
+				"keyword:oneof", "name:choice", "punctuation:{", "type:int32", "field-name:n", "punctuation:=", "number:3",
+				"punctuation:;", "punctuation:}",
+				"punctuation:}",
+				"keyword:enum", "name:E", "punctuation:{", "field-name:E_UNSPECIFIED", "punctuation:=", "number:0", "punctuation:;",
+				"field-name:E_ONE", "punctuation:=", "number:1", "punctuation:[", "option-name:deprecated",
+				"punctuation:=", "keyword:true", "punctuation:]", "punctuation:;", "punctuation:}",
+				"keyword:service", "name:S", "punctuation:{",
+				"keyword:rpc", "name:Get", "punctuation:(", "keyword:stream", "type:Hello", "punctuation:)",
+				"keyword:returns", "punctuation:(", "type:.", "type:foo", "type:.", "type:v1", "type:.", "type:Hello", "punctuation:)",
+				"punctuation:{",
+				"keyword:option", "punctuation:(", "option-name:google", "option-name:.", "option-name:api", "option-name:.",
+				"option-name:http", "punctuation:)", "punctuation:=", "punctuation:{", "option-name:get", "punctuation::",
+				`string:"/v1"`, "punctuation:}", "punctuation:;",
+				"punctuation:}",
+				"punctuation:}",
+			},
+		},
+		{
+			name:  "classifying only by the tokens if the input cannot be parsed",
+			input: "message Hello { string @",
+			wantSpans: []string{
+				"keyword:message", "none:Hello", "punctuation:{", "none:string", "illegal:@",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			spans, err := highlight.Classify(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+
+			var text strings.Builder
+			var got []string
+			for _, span := range spans {
+				text.WriteString(span.Text)
+				if strings.TrimSpace(span.Text) != "" {
+					got = append(got, span.Class.String()+":"+span.Text)
+				}
+			}
+			if text.String() != test.input {
+				t.Errorf("got %q, but want %q", text.String(), test.input)
+			}
+			if !reflect.DeepEqual(got, test.wantSpans) {
+				t.Errorf("got %q, but want %q", got, test.wantSpans)
+			}
+		})
+	}
+}
+
+func TestHighlighter(t *testing.T) {
+	input := "syntax = \"proto3\";\nmessage A { string a = 1; } // <b>\n"
+	tests := []struct {
+		name        string
+		highlighter *highlight.Highlighter
+		render      func(h *highlight.Highlighter, b *bytes.Buffer) error
+		want        string
+	}{
+		{
+			name:        "rendering HTML",
+			highlighter: highlight.NewHighlighter(),
+			render: func(h *highlight.Highlighter, b *bytes.Buffer) error {
+				return h.HTML(b, strings.NewReader(input))
+			},
+			want: `<pre class="pb-proto"><span class="pb-keyword">syntax</span> <span class="pb-punctuation">=</span> ` +
+				`<span class="pb-string">&#34;proto3&#34;</span><span class="pb-punctuation">;</span>` + "\n" +
+				`<span class="pb-keyword">message</span> <span class="pb-name">A</span> ` +
+				`<span class="pb-punctuation">{</span> <span class="pb-type">string</span> <span class="pb-field-name">a</span> ` +
+				`<span class="pb-punctuation">=</span> <span class="pb-number">1</span><span class="pb-punctuation">;</span> ` +
+				`<span class="pb-punctuation">}</span> <span class="pb-comment">// &lt;b&gt;</span>` + "\n</pre>\n",
+		},
+		{
+			name: "rendering ANSI",
+			highlighter: highlight.NewHighlighter(highlight.WithTheme(highlight.Theme{
+				highlight.ClassKeyword: "35",
+				highlight.ClassComment: "2",
+			})),
+			render: func(h *highlight.Highlighter, b *bytes.Buffer) error {
+				return h.ANSI(b, strings.NewReader(input))
+			},
+			want: "\x1b[35msyntax\x1b[0m = \"proto3\";\n\x1b[35mmessage\x1b[0m A { string a = 1; } \x1b[2m// <b>\x1b[0m\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := test.render(test.highlighter, &b)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if b.String() != test.want {
+				t.Errorf("got %q, but want %q", b.String(), test.want)
+			}
+		})
+	}
+}
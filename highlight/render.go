@@ -0,0 +1,118 @@
+package highlight
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"strings"
+)
+
+// Theme maps a class to the ANSI SGR parameters, such as "1;34" for bold blue.
+type Theme map[Class]string
+
+// DefaultTheme is the theme used by default for ANSI.
+var DefaultTheme = Theme{
+	ClassKeyword:    "35",
+	ClassType:       "36",
+	ClassName:       "1;33",
+	ClassFieldName:  "34",
+	ClassOptionName: "32",
+	ClassNumber:     "31",
+	ClassString:     "33",
+	ClassComment:    "2",
+	ClassIllegal:    "1;41",
+}
+
+// Highlighter renders spans.
+type Highlighter struct {
+	classPrefix string
+	theme       Theme
+}
+
+// Option is an option for NewHighlighter.
+type Option func(*Highlighter)
+
+// WithClassPrefix is an option to prefix the CSS classes of HTML. The default is "pb-".
+func WithClassPrefix(classPrefix string) Option {
+	return func(h *Highlighter) {
+		h.classPrefix = classPrefix
+	}
+}
+
+// WithTheme is an option to set the colors of ANSI. The default is DefaultTheme.
+func WithTheme(theme Theme) Option {
+	return func(h *Highlighter) {
+		h.theme = theme
+	}
+}
+
+// NewHighlighter creates a new Highlighter.
+func NewHighlighter(opts ...Option) *Highlighter {
+	h := &Highlighter{
+		classPrefix: "pb-",
+		theme:       DefaultTheme,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// HTML writes the input as HTML, which is a pre element with a span element with a CSS class per classified token.
+// For example, a keyword is <span class="pb-keyword">message</span>.
+func (h *Highlighter) HTML(w io.Writer, input io.Reader) error {
+	spans, err := Classify(input)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`<pre class="` + h.classPrefix + `proto">`)
+	for _, span := range spans {
+		text := html.EscapeString(span.Text)
+		if span.Class == ClassNone {
+			bw.WriteString(text)
+			continue
+		}
+		bw.WriteString(`<span class="` + h.classPrefix + span.Class.String() + `">` + text + `</span>`)
+	}
+	bw.WriteString("</pre>\n")
+	return bw.Flush()
+}
+
+// ANSI writes the input colored by ANSI escape sequences.
+func (h *Highlighter) ANSI(w io.Writer, input io.Reader) error {
+	spans, err := Classify(input)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, span := range spans {
+		sgr, ok := h.theme[span.Class]
+		if !ok || sgr == "" {
+			bw.WriteString(span.Text)
+			continue
+		}
+		// Colors each line so that a pager or a diff can show any line alone.
+		for i, line := range strings.Split(span.Text, "\n") {
+			if 0 < i {
+				bw.WriteString("\n")
+			}
+			if line != "" {
+				bw.WriteString("\x1b[" + sgr + "m" + line + "\x1b[0m")
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// HTML writes the input as HTML with the default options.
+func HTML(w io.Writer, input io.Reader) error {
+	return NewHighlighter().HTML(w, input)
+}
+
+// ANSI writes the input colored by ANSI escape sequences with the default options.
+func ANSI(w io.Writer, input io.Reader) error {
+	return NewHighlighter().ANSI(w, input)
+}
@@ -0,0 +1,314 @@
+// Package imports computes the imports which the files of a linked schema need, and rewrites their import blocks
+// to add the missing imports, remove the unused ones and sort them by their locations.
+//
+// An import is needed if its file or one of the files it publicly imports defines a type or an extension used by
+// the importing file. The public imports are re-exports and are always kept, and so are the imports of the files
+// which are not parsed since their use is unknown. A weak import which is not needed is removed like the others.
+package imports
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/depgraph"
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// Import is an import of a file.
+type Import struct {
+	// Location is the canonical location of the imported file, like "foo/bar.proto".
+	Location string
+	Modifier parser.ImportModifier
+	// Import is the existing import. It is nil if the import is missing.
+	Import *schema.Import
+}
+
+// Organizer computes the imports of the files of a schema.
+type Organizer struct {
+	schema *schema.Schema
+	// sources are the sources of the files keyed by their paths.
+	sources     map[string][]byte
+	importPaths []string
+	// locations maps the files to the locations written by their existing imports.
+	locations map[*schema.File]string
+}
+
+// Option is an option for NewOrganizer.
+type Option func(*Organizer)
+
+// WithImportPaths is an option to give the import paths the files were looked up in, like the -I flag of protoc.
+// The location of a file is its path relative to the first import path which has it. Without the import paths,
+// the location is the one written by an existing import of the file, or the path of the file.
+func WithImportPaths(importPaths ...string) Option {
+	return func(o *Organizer) {
+		o.importPaths = importPaths
+	}
+}
+
+// NewOrganizer returns an Organizer of the schema. The sources are keyed by the paths of the files of the schema,
+// and are needed for the files whose imports are rewritten.
+func NewOrganizer(s *schema.Schema, sources map[string][]byte, opts ...Option) *Organizer {
+	o := &Organizer{
+		schema:    s,
+		sources:   sources,
+		locations: make(map[*schema.File]string),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	for _, f := range s.Files {
+		for _, imp := range f.Imports {
+			if _, ok := o.locations[imp.File]; imp.File != nil && !ok {
+				o.locations[imp.File] = path.Clean(writtenLocation(imp.Node))
+			}
+		}
+	}
+	return o
+}
+
+// writtenLocation returns the location written by the import without the quotes.
+func writtenLocation(imp *parser.Import) string {
+	return strings.Trim(imp.Location, `"'`)
+}
+
+// location returns the canonical location of the file.
+func (o *Organizer) location(f *schema.File) string {
+	for _, importPath := range o.importPaths {
+		importPath = path.Clean(importPath)
+		if importPath == "." {
+			return path.Clean(f.Path)
+		}
+		if rel := strings.TrimPrefix(path.Clean(f.Path), importPath+"/"); rel != path.Clean(f.Path) {
+			return rel
+		}
+	}
+	if location, ok := o.locations[f]; ok {
+		return location
+	}
+	return f.Path
+}
+
+// Imports returns the imports which the file needs, sorted by their locations. The existing imports which are
+// needed are kept with their modifiers, and the files which define the used types but are not imported directly
+// or publicly are imported.
+func (o *Organizer) Imports(f *schema.File) []*Import {
+	used := make(map[*schema.File]bool)
+	for _, file := range depgraph.UsedFiles(o.schema, f) {
+		used[file] = true
+	}
+
+	var imports []*Import
+	covered := make(map[*schema.File]bool)
+	kept := make(map[*schema.File]*Import)
+	for _, imp := range f.Imports {
+		if imp.File == nil {
+			imports = append(imports, &Import{Location: writtenLocation(imp.Node), Modifier: imp.Node.Modifier, Import: imp})
+			continue
+		}
+		if k, ok := kept[imp.File]; ok {
+			// A duplicate import is dropped, but makes the kept one public if it is public.
+			if imp.Node.Modifier == parser.ImportModifierPublic {
+				k.Modifier = parser.ImportModifierPublic
+			}
+			continue
+		}
+		closure := depgraph.PublicClosure(imp.File)
+		if imp.Node.Modifier != parser.ImportModifierPublic && !depgraph.UsesAny(used, closure) {
+			continue
+		}
+		k := &Import{Location: o.location(imp.File), Modifier: imp.Node.Modifier, Import: imp}
+		kept[imp.File] = k
+		imports = append(imports, k)
+		for _, file := range closure {
+			covered[file] = true
+		}
+	}
+	for _, file := range depgraph.UsedFiles(o.schema, f) {
+		if covered[file] {
+			continue
+		}
+		imports = append(imports, &Import{Location: o.location(file)})
+		for _, c := range depgraph.PublicClosure(file) {
+			covered[c] = true
+		}
+	}
+
+	sort.SliceStable(imports, func(i, j int) bool {
+		return imports[i].Location < imports[j].Location
+	})
+	return imports
+}
+
+// Organize returns the edits to rewrite the import blocks of the files which are not imported by others and whose
+// imports change, keyed by the paths of the files. The imports are moved to the place of the first import, keeping
+// their comments, and are inserted after the package statement if there is none. The edited files are parsed
+// again to verify them.
+func (o *Organizer) Organize() (map[string][]*edit.Edit, error) {
+	edits := make(map[string][]*edit.Edit)
+	for _, f := range o.schema.Files {
+		if f.Imported {
+			continue
+		}
+		src, ok := o.sources[f.Path]
+		if !ok {
+			return nil, fmt.Errorf("found no source of %s", f.Path)
+		}
+		fileEdits, err := o.organize(f, edit.NewSource(src))
+		if err != nil {
+			return nil, err
+		}
+		edited, err := edit.Apply(src, fileEdits)
+		if err != nil {
+			return nil, fmt.Errorf("%w of %s", err, f.Path)
+		}
+		if bytes.Equal(edited, src) {
+			continue
+		}
+		if _, err := protoparser.Parse(bytes.NewReader(edited), protoparser.WithFilename(f.Path)); err != nil {
+			return nil, fmt.Errorf("failed to parse the organized %s, err %w", f.Path, err)
+		}
+		edits[f.Path] = fileEdits
+	}
+	return edits, nil
+}
+
+func (o *Organizer) organize(f *schema.File, s *edit.Source) ([]*edit.Edit, error) {
+	imports := o.Imports(f)
+	var lines []string
+	for _, imp := range imports {
+		line, err := render(s, imp)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	if len(f.Imports) == 0 {
+		if len(imports) == 0 {
+			return nil, nil
+		}
+		end, err := insertionPoint(s, f.Proto)
+		if err != nil {
+			return nil, err
+		}
+		return []*edit.Edit{{Start: end, End: end, NewText: "\n\n" + strings.Join(lines, "\n")}}, nil
+	}
+
+	// Each import is removed with its comments and its line, and the first one is replaced by the block.
+	var edits []*edit.Edit
+	for i, imp := range f.Imports {
+		start, end, err := span(s, imp.Node)
+		if err != nil {
+			return nil, err
+		}
+		start = lineStart(s.Src, start)
+		wholeLines := false
+		if start == 0 || s.Src[start-1] == '\n' {
+			if next, ok := lineEnd(s.Src, end); ok {
+				end = next
+				wholeLines = true
+			}
+		}
+		e := &edit.Edit{Start: start, End: end}
+		if i == 0 && 0 < len(lines) {
+			e.NewText = strings.Join(lines, "\n")
+			if wholeLines {
+				e.NewText += "\n"
+			}
+		}
+		edits = append(edits, e)
+	}
+	return edits, nil
+}
+
+// span returns the offsets of the import with its comments.
+func span(s *edit.Source, imp *parser.Import) (int, int, error) {
+	start := imp.Meta.Pos.Offset
+	if 0 < len(imp.Comments) {
+		start = imp.Comments[0].Meta.Pos.Offset
+	}
+	end, ok := s.StatementEnd(imp.Meta.Pos.Offset, imp.InlineComment)
+	if !ok {
+		return 0, 0, fmt.Errorf("found no end of the import at %s", imp.Meta.Pos)
+	}
+	return start, end, nil
+}
+
+// render returns the text of the import. An existing import keeps its comments.
+func render(s *edit.Source, imp *Import) (string, error) {
+	statement := "import " + strconv.Quote(imp.Location) + ";"
+	switch imp.Modifier {
+	case parser.ImportModifierPublic:
+		statement = "import public " + strconv.Quote(imp.Location) + ";"
+	case parser.ImportModifierWeak:
+		statement = "import weak " + strconv.Quote(imp.Location) + ";"
+	}
+	if imp.Import == nil {
+		return statement, nil
+	}
+
+	node := imp.Import.Node
+	start, end, err := span(s, node)
+	if err != nil {
+		return "", err
+	}
+	// The inline comment follows the ";".
+	semicolon, _ := s.StatementEnd(node.Meta.Pos.Offset, nil)
+	return string(s.Src[start:node.Meta.Pos.Offset]) + statement + string(s.Src[semicolon:end]), nil
+}
+
+// insertionPoint returns the offset after the package statement, or after the syntax statement if there is none.
+func insertionPoint(s *edit.Source, p *parser.Proto) (int, error) {
+	for _, v := range p.ProtoBody {
+		if n, ok := v.(*parser.Package); ok {
+			return statementEnd(s, n.Meta.Pos, n.InlineComment)
+		}
+	}
+	if p.Syntax != nil {
+		return statementEnd(s, p.Syntax.Meta.Pos, p.Syntax.InlineComment)
+	}
+	return 0, nil
+}
+
+func statementEnd(s *edit.Source, pos meta.Position, inline *parser.Comment) (int, error) {
+	end, ok := s.StatementEnd(pos.Offset, inline)
+	if !ok {
+		return 0, fmt.Errorf("found no end of the statement at %s", pos)
+	}
+	return end, nil
+}
+
+// lineStart returns the start of the line of offset if only spaces precede offset in the line, or offset otherwise.
+func lineStart(src []byte, offset int) int {
+	i := offset
+	for 0 < i && (src[i-1] == ' ' || src[i-1] == '\t') {
+		i--
+	}
+	if i == 0 || src[i-1] == '\n' {
+		return i
+	}
+	return offset
+}
+
+// lineEnd returns the offset after the newline ending the line of offset if only spaces follow offset in the line.
+func lineEnd(src []byte, offset int) (int, bool) {
+	i := offset
+	for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '\r') {
+		i++
+	}
+	if i == len(src) {
+		return i, true
+	}
+	if src[i] == '\n' {
+		return i + 1, true
+	}
+	return offset, false
+}
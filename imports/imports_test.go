@@ -0,0 +1,225 @@
+package imports_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/imports"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+func TestOrganizer_Organize(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";
+package a;
+
+// x is unused.
+import "x.proto";
+import weak "w.proto"; // weak
+// b comes first.
+import "./b.proto";
+import public "c.proto";
+
+message A {
+  b.B b = 1;
+  r.R r = 2;
+  w.W w = 3;
+  e.E e = 4;
+}
+`)},
+		"n.proto": {Data: []byte(`syntax = "proto3";
+package n;
+
+message N {
+  b.B b = 1;
+}
+`)},
+		"o.proto": {Data: []byte(`syntax = "proto3";
+package o;
+import "d.proto";
+message O {
+  r.R r = 1;
+}
+`)},
+		"b.proto": {Data: []byte(`syntax = "proto3";
+package b;
+message B {}
+`)},
+		"c.proto": {Data: []byte(`syntax = "proto3";
+package c;
+message C {}
+`)},
+		"d.proto": {Data: []byte(`syntax = "proto3";
+package d;
+import public "r.proto";
+message D {}
+`)},
+		"e.proto": {Data: []byte(`syntax = "proto3";
+package e;
+message E {}
+`)},
+		"r.proto": {Data: []byte(`syntax = "proto3";
+package r;
+message R {}
+`)},
+		"w.proto": {Data: []byte(`syntax = "proto3";
+package w;
+message W {}
+`)},
+		"x.proto": {Data: []byte(`syntax = "proto3";
+package x;
+message X {}
+`)},
+	}
+	paths := []string{"a.proto", "n.proto", "o.proto", "e.proto"}
+	s, err := schema.Load(context.Background(), fsys, paths, protoparser.WithImportPaths("."))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	sources := make(map[string][]byte)
+	for path, file := range fsys {
+		sources[path] = file.Data
+	}
+
+	edits, err := imports.NewOrganizer(s, sources, imports.WithImportPaths(".")).Organize()
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	edited, err := edit.ApplyFiles(sources, edits)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "remove, add and sort the imports keeping the comments and the modifiers",
+			path: "a.proto",
+			want: `syntax = "proto3";
+package a;
+
+// b comes first.
+import "b.proto";
+import public "c.proto";
+import "e.proto";
+import "r.proto";
+import weak "w.proto"; // weak
+
+message A {
+  b.B b = 1;
+  r.R r = 2;
+  w.W w = 3;
+  e.E e = 4;
+}
+`,
+		},
+		{
+			name: "insert the imports after the package",
+			path: "n.proto",
+			want: `syntax = "proto3";
+package n;
+
+import "b.proto";
+
+message N {
+  b.B b = 1;
+}
+`,
+		},
+		{
+			name: "keep the import of a public import",
+			path: "o.proto",
+		},
+		{
+			name: "keep the file without imports",
+			path: "e.proto",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := edited[test.path]
+			if test.want == "" {
+				if ok {
+					t.Errorf("got %v, but want no edits", string(got))
+				}
+				return
+			}
+			if string(got) != test.want {
+				t.Errorf("got %v, but want %v", string(got), test.want)
+			}
+		})
+	}
+}
+
+func TestOrganizer_Organize_writtenLocations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";
+package a;
+import "google/protobuf/descriptor.proto";
+import "./c.proto";
+import "b.proto";
+message A {
+  b.B b = 1;
+  c.C c = 2;
+  d.D d = 3;
+}
+`)},
+		"b.proto": {Data: []byte(`syntax = "proto3";
+package b;
+message B {}
+`)},
+		"c.proto": {Data: []byte(`syntax = "proto3";
+package c;
+message C {}
+`)},
+		"d.proto": {Data: []byte(`syntax = "proto3";
+package d;
+message D {}
+`)},
+	}
+	// The error is the import of descriptor.proto which is not found.
+	s, _ := schema.Load(context.Background(), fsys, []string{"a.proto", "d.proto"}, protoparser.WithImportPaths("."))
+	if s == nil {
+		t.Fatalf("got no schema")
+	}
+	sources := make(map[string][]byte)
+	for path, file := range fsys {
+		sources[path] = file.Data
+	}
+
+	// Without the import paths, the locations are the written ones without the quotes, or the paths of the files.
+	edits, err := imports.NewOrganizer(s, sources).Organize()
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	edited, err := edit.ApplyFiles(sources, edits)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	want := `syntax = "proto3";
+package a;
+import "b.proto";
+import "c.proto";
+import "d.proto";
+import "google/protobuf/descriptor.proto";
+message A {
+  b.B b = 1;
+  c.C c = 2;
+  d.D d = 3;
+}
+`
+	if got := string(edited["a.proto"]); got != want {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if got, ok := edited["d.proto"]; ok {
+		t.Errorf("got %v, but want no edits", string(got))
+	}
+}
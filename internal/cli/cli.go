@@ -0,0 +1,18 @@
+// Package cli has the helpers shared by the commands.
+package cli
+
+import "strings"
+
+// StringsFlag is a flag which can be given multiple times, like "-I a -I b".
+type StringsFlag []string
+
+// String returns the values separated by commas.
+func (f *StringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set appends the value.
+func (f *StringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
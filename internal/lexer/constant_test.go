@@ -1,7 +1,6 @@
 package lexer_test
 
 import (
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -100,7 +99,7 @@ func TestLexer2_ReadConstant(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			lex := lexer.NewLexer(strings.NewReader(test.input))
+			lex := lexer.NewLexer([]byte(test.input))
 			got, pos, err := lex.ReadConstant(true)
 
 			switch {
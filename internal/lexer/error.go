@@ -3,6 +3,8 @@ package lexer
 import (
 	"fmt"
 	"runtime"
+
+	"github.com/thought-machine/go-protoparser/internal/lexer/scanner"
 )
 
 func (lex *Lexer) unexpected(found, expected string) error {
@@ -13,3 +15,40 @@ func (lex *Lexer) unexpected(found, expected string) error {
 	}
 	return fmt.Errorf("found %q but expected [%s]%s", found, expected, debug)
 }
+
+// The names of limits for LimitError.
+const (
+	LimitNestingDepth = "nesting depth"
+	LimitTokenCount   = "token count"
+)
+
+// LimitError is an error which stops the lexer when the input exceeds a limit.
+type LimitError struct {
+	// Limit is the name of the exceeded limit.
+	Limit string
+	// Max is the maximum set by the option.
+	Max int
+	// Pos is the position where the limit was exceeded.
+	Pos scanner.Position
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s exceeds the maximum of %d at %s", e.Limit, e.Max, e.Pos)
+}
+
+// ScanError is an error encountered by the scanner. The lexer continues after it.
+type ScanError struct {
+	// Err is the error returned by the scanner.
+	Err error
+	// Pos is the position of the token being scanned.
+	Pos scanner.Position
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Pos, e.Err)
+}
+
+// Unwrap returns the error returned by the scanner.
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
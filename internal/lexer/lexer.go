@@ -1,8 +1,10 @@
 package lexer
 
 import (
-	"io"
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
 	"path/filepath"
 	"runtime"
 
@@ -20,14 +22,20 @@ type Lexer struct {
 	// Pos is the source position.
 	Pos scanner.Position
 
-	// Error is called for each error encountered. If no Error
-	// function is set, the error is reported to os.Stderr.
-	Error func(lexer *Lexer, err error)
+	scanner      *scanner.Scanner
+	scannerOpts  []scanner.Option
+	scanErr      error
+	scanErrs     []*ScanError
+	errorHandler func(err *ScanError)
+	logger       *slog.Logger
+	debug        bool
 
-	scanner     *scanner.Scanner
-	scannerOpts []scanner.Option
-	scanErr     error
-	debug       bool
+	done       <-chan struct{}
+	ctx        context.Context
+	maxTokens  int
+	tokens     int
+	nextOffset int
+	stopErr    error
 }
 
 // Option is an option for lexer.NewLexer.
@@ -40,6 +48,20 @@ func WithDebug(debug bool) Option {
 	}
 }
 
+// WithErrorHandler is an option to set the handler called for each scan error.
+func WithErrorHandler(errorHandler func(err *ScanError)) Option {
+	return func(l *Lexer) {
+		l.errorHandler = errorHandler
+	}
+}
+
+// WithLogger is an option to log scan errors at the warn level and, in the debug mode, tokens at the debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(l *Lexer) {
+		l.logger = logger
+	}
+}
+
 // WithFilename is an option for scanner.Option.
 func WithFilename(filename string) Option {
 	return func(l *Lexer) {
@@ -47,17 +69,35 @@ func WithFilename(filename string) Option {
 	}
 }
 
-// NewLexer creates a new lexer.
-func NewLexer(input io.Reader, opts ...Option) *Lexer {
+// WithContext is an option to stop scanning when ctx is done.
+func WithContext(ctx context.Context) Option {
+	return func(l *Lexer) {
+		l.ctx = ctx
+		l.done = ctx.Done()
+	}
+}
+
+// WithMaxTokens is an option to stop scanning when the input has more than maxTokens tokens.
+// Zero means no limit.
+func WithMaxTokens(maxTokens int) Option {
+	return func(l *Lexer) {
+		l.maxTokens = maxTokens
+	}
+}
+
+// NewLexer creates a new lexer of src.
+func NewLexer(src []byte, opts ...Option) *Lexer {
 	lex := new(Lexer)
 	for _, opt := range opts {
 		opt(lex)
 	}
 
-	lex.Error = func(_ *Lexer, err error) {
-		log.Printf(`Lexer encountered the error "%v"`, err)
+	if lex.debug && lex.logger == nil {
+		lex.logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
 	}
-	lex.scanner = scanner.NewScanner(input, lex.scannerOpts...)
+	lex.scanner = scanner.NewScanner(src, lex.scannerOpts...)
 	return lex
 }
 
@@ -67,24 +107,97 @@ func (lex *Lexer) Next() {
 		if lex.debug {
 			_, file, line, ok := runtime.Caller(2)
 			if ok {
-				log.Printf(
-					"[DEBUG] Text=[%s], Token=[%v], Pos=[%s] called from %s:%d\n",
-					lex.Text,
-					lex.Token,
-					lex.Pos,
-					filepath.Base(file),
-					line,
+				lex.logger.Debug(
+					"scanned",
+					"text", lex.Text,
+					"token", lex.Token,
+					"pos", lex.Pos.String(),
+					"caller", fmt.Sprintf("%s:%d", filepath.Base(file), line),
 				)
 			}
 		}
 	}()
 
+	if lex.stopErr != nil {
+		lex.Token = scanner.TEOF
+		lex.Text = ""
+		return
+	}
+
 	var err error
 	lex.Token, lex.Text, lex.Pos, err = lex.scanner.Scan()
 	if err != nil {
 		lex.scanErr = err
-		lex.Error(lex, err)
+		lex.reportScanError(err)
 	}
+	lex.checkLimits()
+}
+
+// reportScanError records err and passes it to the error handler and the logger.
+// An error scanned again after UnNext is reported only once.
+func (lex *Lexer) reportScanError(err error) {
+	if n := len(lex.scanErrs); 0 < n && lex.Pos.Offset <= lex.scanErrs[n-1].Pos.Offset {
+		return
+	}
+
+	scanErr := &ScanError{
+		Err: err,
+		Pos: lex.Pos,
+	}
+	lex.scanErrs = append(lex.scanErrs, scanErr)
+	if lex.errorHandler != nil {
+		lex.errorHandler(scanErr)
+	}
+	if lex.logger != nil {
+		lex.logger.Warn("scan error", "pos", lex.Pos.String(), "err", err)
+	}
+}
+
+// ScanErrors returns all errors encountered by the scanner in order of the position.
+func (lex *Lexer) ScanErrors() []*ScanError {
+	return lex.scanErrs
+}
+
+// checkLimits stops the lexer if the context is done or there are too many tokens.
+// A token read again after UnNext is counted only once.
+func (lex *Lexer) checkLimits() {
+	if lex.done != nil {
+		select {
+		case <-lex.done:
+			lex.Stop(lex.ctx.Err())
+			return
+		default:
+		}
+	}
+
+	if lex.maxTokens <= 0 || lex.Token == scanner.TEOF || lex.Pos.Offset < lex.nextOffset {
+		return
+	}
+	lex.tokens++
+	lex.nextOffset = lex.Pos.Offset + 1
+	if lex.maxTokens < lex.tokens {
+		lex.Stop(&LimitError{
+			Limit: LimitTokenCount,
+			Max:   lex.maxTokens,
+			Pos:   lex.Pos,
+		})
+	}
+}
+
+// Stop makes the lexer return only TEOF from now on. StopErr returns err afterwards.
+// The first call wins.
+func (lex *Lexer) Stop(err error) {
+	if lex.stopErr != nil {
+		return
+	}
+	lex.stopErr = err
+	lex.Token = scanner.TEOF
+	lex.Text = ""
+}
+
+// StopErr returns the error passed to Stop, if any.
+func (lex *Lexer) StopErr() error {
+	return lex.stopErr
 }
 
 // NextKeywordOrStrLit scans the read buffer with ScanKeyword or ScanStrLit modes.
@@ -1,7 +1,6 @@
 package lexer_test
 
 import (
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -43,7 +42,7 @@ func TestLexer2_ReadMessageType(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			lex := lexer.NewLexer(strings.NewReader(test.input))
+			lex := lexer.NewLexer([]byte(test.input))
 			got, pos, err := lex.ReadMessageType()
 
 			switch {
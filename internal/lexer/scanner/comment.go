@@ -1,38 +1,40 @@
 package scanner
 
 // comment = ( "//" { [^\n] } "\n" ) |  ( "/*" { any } "*/" )
-func (s *Scanner) scanComment() (string, error) {
-	lit := string(s.read())
+func (s *Scanner) scanComment() error {
+	s.read()
 
 	ch := s.read()
 	switch ch {
 	case '/':
 		for ch != '\n' {
 			if s.isEOF() {
-				return lit, s.unexpected(eof, "\n")
+				return s.unexpected(eof, "\n")
 			}
-			lit += string(ch)
-
 			ch = s.read()
 		}
 	case '*':
 		for {
 			if s.isEOF() {
-				return lit, s.unexpected(eof, "\n")
+				return s.unexpected(eof, "*/")
 			}
-			lit += string(ch)
-
 			ch = s.read()
-			chn := s.peek()
-			if ch == '*' && chn == '/' {
-				lit += string(ch)
-				lit += string(s.read())
+			if ch == '*' && s.peek() == '/' {
+				s.read()
 				break
 			}
 		}
 	default:
-		return "", s.unexpected(ch, "/ or *")
+		return s.unexpected(ch, "/ or *")
 	}
+	return nil
+}
 
-	return lit, nil
+// commentText returns the comment text started at start, excluding the newline terminating a line comment.
+func (s *Scanner) commentText(start Position) string {
+	end := s.pos.Offset
+	if s.src[start.Offset+1] == '/' {
+		end--
+	}
+	return string(s.src[start.Offset:end])
 }
@@ -1,6 +1,5 @@
 package scanner
 
 func (s *Scanner) isEOF() bool {
-	ch := s.peek()
-	return ch == eof
+	return len(s.src) <= s.pos.Offset
 }
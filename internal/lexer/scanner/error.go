@@ -8,5 +8,8 @@ import (
 func (s *Scanner) unexpected(found rune, expected string) error {
 	_, file, line, _ := runtime.Caller(1)
 	message := fmt.Sprintf(" at %s:%d", file, line)
+	if found == eof {
+		return fmt.Errorf("found EOF but expected [%s]%s", expected, message)
+	}
 	return fmt.Errorf("found %q but expected [%s]%s", found, expected, message)
 }
@@ -1,46 +1,69 @@
 package scanner
 
+import "unicode/utf8"
+
 // See
 //  https://developers.google.com/protocol-buffers/docs/reference/proto3-spec#letters_and_digits
 //  https://ascii.cl/
 
-// letter = "A" … "Z" | "a" … "z"
-func isLetter(r rune) bool {
-	if r < 'A' {
-		return false
-	}
+const (
+	classLetter = 1 << iota
+	classDecimalDigit
+	classOctalDigit
+	classHexDigit
+	classSpace
+)
 
-	if r > 'z' {
-		return false
+// charClasses is a precomputed table of the classes each ASCII character belongs to.
+var charClasses = func() [utf8.RuneSelf]uint8 {
+	var t [utf8.RuneSelf]uint8
+	for r := 'A'; r <= 'Z'; r++ {
+		t[r] |= classLetter
 	}
-
-	if r > 'Z' && r < 'a' {
-		return false
+	for r := 'a'; r <= 'z'; r++ {
+		t[r] |= classLetter
+	}
+	for r := '0'; r <= '9'; r++ {
+		t[r] |= classDecimalDigit | classHexDigit
+	}
+	for r := '0'; r <= '7'; r++ {
+		t[r] |= classOctalDigit
+	}
+	for r := 'A'; r <= 'F'; r++ {
+		t[r] |= classHexDigit
+		t[r+'a'-'A'] |= classHexDigit
+	}
+	for _, r := range "\t\n\v\f\r " {
+		t[r] |= classSpace
 	}
+	return t
+}()
 
-	return true
+func hasClass(r rune, class uint8) bool {
+	return 0 <= r && r < utf8.RuneSelf && charClasses[r]&class != 0
+}
+
+// letter = "A" … "Z" | "a" … "z"
+func isLetter(r rune) bool {
+	return hasClass(r, classLetter)
 }
 
 // decimalDigit = "0" … "9"
 func isDecimalDigit(r rune) bool {
-	return '0' <= r && r <= '9'
+	return hasClass(r, classDecimalDigit)
 }
 
 // octalDigit   = "0" … "7"
 func isOctalDigit(r rune) bool {
-	return '0' <= r && r <= '7'
+	return hasClass(r, classOctalDigit)
 }
 
 // hexDigit     = "0" … "9" | "A" … "F" | "a" … "f"
 func isHexDigit(r rune) bool {
-	if '0' <= r && r <= '9' {
-		return true
-	}
-	if 'A' <= r && r <= 'F' {
-		return true
-	}
-	if 'a' <= r && r <= 'f' {
-		return true
-	}
-	return false
+	return hasClass(r, classHexDigit)
+}
+
+// isASCIISpace checks r is a white space in the ASCII range.
+func isASCIISpace(r rune) bool {
+	return hasClass(r, classSpace)
 }
@@ -15,18 +15,14 @@ type Position struct {
 	Line int
 	// Column is a column number, starting at 1 (character count per line)
 	Column int
-
-	// columns is a map which the key is a line number and the value is a column number.
-	columns map[int]int
 }
 
 // NewPosition creates a new Position.
 func NewPosition() *Position {
 	return &Position{
-		Offset:  0,
-		Line:    1,
-		Column:  1,
-		columns: make(map[int]int),
+		Offset: 0,
+		Line:   1,
+		Column: 1,
 	}
 }
 
@@ -42,28 +38,17 @@ func (pos Position) String() string {
 
 // Advance advances the position value.
 func (pos *Position) Advance(r rune) {
-	len := utf8.RuneLen(r)
-	pos.Offset += len
+	pos.advance(r, utf8.RuneLen(r))
+}
 
-	if r == '\n' {
-		pos.columns[pos.Line] = pos.Column
+// advance advances the position value by a character which takes size bytes in the source.
+func (pos *Position) advance(r rune, size int) {
+	pos.Offset += size
 
+	if r == '\n' {
 		pos.Line++
 		pos.Column = 1
 	} else {
 		pos.Column++
 	}
 }
-
-// Revert reverts the position value.
-func (pos *Position) Revert(r rune) {
-	len := utf8.RuneLen(r)
-	pos.Offset -= len
-
-	if r == '\n' {
-		pos.Line--
-		pos.Column = pos.columns[pos.Line]
-	} else {
-		pos.Column--
-	}
-}
@@ -1,21 +1,23 @@
 package scanner
 
 import (
-	"bufio"
-	"io"
 	"unicode"
+	"unicode/utf8"
 )
 
-var eof = rune(0)
+const eof = rune(-1)
 
 // Scanner represents a lexical scanner.
+//
+// The whole input is kept in a byte buffer, so that going back to any former
+// position is just restoring the offset, the line and the column.
 type Scanner struct {
-	r              *bufio.Reader
-	lastReadBuffer []rune
-	lastScanRaw    []rune
+	src []byte
 
 	// pos is a current source position.
-	pos *Position
+	pos Position
+	// lastScanPos is the position where the last Scan started.
+	lastScanPos Position
 
 	// The Mode field controls which tokens are recognized.
 	Mode Mode
@@ -31,117 +33,129 @@ func WithFilename(filename string) Option {
 	}
 }
 
-// NewScanner returns a new instance of Scanner.
-func NewScanner(r io.Reader, opts ...Option) *Scanner {
+// NewScanner returns a new instance of Scanner of src, which is not copied.
+func NewScanner(src []byte, opts ...Option) *Scanner {
 	s := &Scanner{
-		r:   bufio.NewReader(r),
-		pos: NewPosition(),
+		src: src,
+		pos: *NewPosition(),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
+	s.lastScanPos = s.pos
 	return s
 }
 
-func (s *Scanner) read() (r rune) {
-	defer func() {
-		if r == eof {
-			return
-		}
-		s.lastScanRaw = append(s.lastScanRaw, r)
-
-		s.pos.Advance(r)
-	}()
-
-	if 0 < len(s.lastReadBuffer) {
-		var ch rune
-		ch, s.lastReadBuffer = s.lastReadBuffer[len(s.lastReadBuffer)-1], s.lastReadBuffer[:len(s.lastReadBuffer)-1]
-		return ch
+// next decodes the character at the current offset without consuming it.
+func (s *Scanner) next() (rune, int) {
+	if len(s.src) <= s.pos.Offset {
+		return eof, 0
 	}
-	ch, _, err := s.r.ReadRune()
-	if err != nil {
-		return eof
+	if b := s.src[s.pos.Offset]; b < utf8.RuneSelf {
+		return rune(b), 1
 	}
-	return ch
+	return utf8.DecodeRune(s.src[s.pos.Offset:])
 }
 
-func (s *Scanner) unread(ch rune) {
-	s.lastReadBuffer = append(s.lastReadBuffer, ch)
-
-	s.pos.Revert(ch)
+func (s *Scanner) read() rune {
+	ch, size := s.next()
+	if ch != eof {
+		s.pos.advance(ch, size)
+	}
+	return ch
 }
 
 func (s *Scanner) peek() rune {
-	ch := s.read()
-	if ch != eof {
-		s.lastScanRaw = s.lastScanRaw[0 : len(s.lastScanRaw)-1]
-		s.unread(ch)
-	}
+	ch, _ := s.next()
 	return ch
 }
 
+// Mark returns the current position. Passing it to Reset later moves the scanner back there.
+func (s *Scanner) Mark() Position {
+	return s.pos
+}
+
+// Reset moves the scanner to the position returned by Mark.
+func (s *Scanner) Reset(mark Position) {
+	s.pos = mark
+	s.lastScanPos = mark
+}
+
 // UnScan put the last scanned text back to the read buffer.
 func (s *Scanner) UnScan() {
-	var reversedRunes []rune
-	for _, ch := range s.lastScanRaw {
-		reversedRunes = append([]rune{ch}, reversedRunes...)
-	}
-	for _, ch := range reversedRunes {
-		s.unread(ch)
-	}
+	s.pos = s.lastScanPos
 }
 
 // Scan returns the next token and text value.
 func (s *Scanner) Scan() (Token, string, Position, error) {
-	s.lastScanRaw = s.lastScanRaw[:0]
-	return s.scan()
-}
-
-func (s *Scanner) scan() (Token, string, Position, error) {
-	ch := s.peek()
+	s.lastScanPos = s.pos
+	for {
+		ch := s.peek()
+		if ch != eof && (isASCIISpace(ch) || (utf8.RuneSelf <= ch && unicode.IsSpace(ch))) {
+			s.read()
+			continue
+		}
 
-	startPos := *s.pos
+		startPos := s.pos
+		if ch == '/' {
+			err := s.scanComment()
+			if err != nil {
+				return TILLEGAL, "", startPos, err
+			}
+			if s.Mode&ScanComment != 0 {
+				return TCOMMENT, s.commentText(startPos), startPos, nil
+			}
+			continue
+		}
+		return s.scan(ch, startPos)
+	}
+}
 
+func (s *Scanner) scan(ch rune, startPos Position) (Token, string, Position, error) {
 	switch {
-	case unicode.IsSpace(ch):
-		s.read()
-		return s.scan()
-	case s.isEOF():
+	case ch == eof:
 		return TEOF, "", startPos, nil
 	case isLetter(ch):
-		ident := s.scanIdent()
+		s.scanIdent()
+		ident := s.text(startPos)
 		if s.Mode&ScanBoolLit != 0 && isBoolLit(ident) {
 			return TBOOLLIT, ident, startPos, nil
 		}
 		if s.Mode&ScanNumberLit != 0 && isFloatLitKeyword(ident) {
 			return TFLOATLIT, ident, startPos, nil
 		}
-		if s.Mode&ScanKeyword != 0 && asKeywordToken(ident) != TILLEGAL {
-			return asKeywordToken(ident), ident, startPos, nil
+		if s.Mode&ScanKeyword != 0 {
+			if t := asKeywordToken(ident); t != TILLEGAL {
+				return t, ident, startPos, nil
+			}
 		}
 		return TIDENT, ident, startPos, nil
-	case ch == '/':
-		lit, err := s.scanComment()
-		if err != nil {
-			return TILLEGAL, "", startPos, err
-		}
-		if s.Mode&ScanComment != 0 {
-			return TCOMMENT, lit, startPos, nil
-		}
-		return s.scan()
 	case isQuote(ch) && s.Mode&ScanStrLit != 0:
-		lit, err := s.scanStrLit()
+		err := s.scanStrLit()
 		if err != nil {
 			return TILLEGAL, "", startPos, err
 		}
-		return TSTRLIT, lit, startPos, nil
-	case (isDecimalDigit(ch) || ch == '.') && s.Mode&ScanNumberLit != 0:
-		tok, lit, err := s.scanNumberLit()
+		return TSTRLIT, s.text(startPos), startPos, nil
+	case (isDecimalDigit(ch) || (ch == '.' && s.isDecimalDigitAfterDot())) && s.Mode&ScanNumberLit != 0:
+		tok, err := s.scanNumberLit()
 		if err != nil {
 			return TILLEGAL, "", startPos, err
 		}
-		return tok, lit, startPos, nil
+		return tok, s.text(startPos), startPos, nil
 	default:
-		return asMiscToken(ch), string(s.read()), startPos, nil
+		s.read()
+		return asMiscToken(ch), s.text(startPos), startPos, nil
 	}
 }
+
+// isDecimalDigitAfterDot reports whether a decimal digit follows the '.' at the current offset,
+// so that a fullIdent like "foo.bar" is not scanned as a floatLit.
+func (s *Scanner) isDecimalDigitAfterDot() bool {
+	next := s.pos.Offset + 1
+	return next < len(s.src) && isDecimalDigit(rune(s.src[next]))
+}
+
+// text returns the source text from start to the current position.
+func (s *Scanner) text(start Position) string {
+	return string(s.src[start.Offset:s.pos.Offset])
+}
@@ -0,0 +1,72 @@
+package scanner_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/internal/lexer/scanner"
+)
+
+// largeProto generates a proto source which resembles a generated file with n messages.
+func largeProto(n int) string {
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n\npackage bench.v1;\n\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "// Message%d is a generated message.\n", i)
+		fmt.Fprintf(&b, "message Message%d {\n", i)
+		fmt.Fprintf(&b, "  /* id is a unique identifier. */\n")
+		fmt.Fprintf(&b, "  string id = 1 [(validator.field) = {length_gt: 0}];\n")
+		fmt.Fprintf(&b, "  repeated int64 values = 2; // values\n")
+		fmt.Fprintf(&b, "  map<string, double> scores = 3;\n")
+		fmt.Fprintf(&b, "  double ratio = 4 [default = 1.5e10];\n")
+		fmt.Fprintf(&b, "  bytes payload = 5 [json_name = \"payload\\x1f\"];\n")
+		fmt.Fprintf(&b, "  enum Kind%d {\n    KIND_UNSPECIFIED = 0;\n    KIND_ONE = 0x1;\n  }\n", i)
+		b.WriteString("}\n\n")
+	}
+	return b.String()
+}
+
+func BenchmarkScanner_Scan(b *testing.B) {
+	input := largeProto(1000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		s := scanner.NewScanner([]byte(input))
+		s.Mode = scanner.ScanStrLit | scanner.ScanBoolLit | scanner.ScanKeyword | scanner.ScanComment
+		for {
+			token, _, _, err := s.Scan()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if token == scanner.TEOF {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkScanner_UnScan(b *testing.B) {
+	input := largeProto(1000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		s := scanner.NewScanner([]byte(input))
+		s.Mode = scanner.ScanStrLit | scanner.ScanBoolLit | scanner.ScanKeyword | scanner.ScanComment
+		for {
+			s.Scan()
+			s.UnScan()
+			token, _, _, err := s.Scan()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if token == scanner.TEOF {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package scanner_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/internal/lexer/scanner"
+)
+
+func FuzzScanner_Scan(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "..", "_testdata", "*.proto"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(content))
+	}
+
+	modes := []scanner.Mode{
+		0,
+		scanner.ScanIdent | scanner.ScanLit | scanner.ScanKeyword | scanner.ScanComment,
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, mode := range modes {
+			s := scanner.NewScanner([]byte(input))
+			s.Mode = mode
+
+			var prev scanner.Position
+			// Every token except EOF consumes at least one rune.
+			for i := 0; ; i++ {
+				if len(input) < i {
+					t.Fatalf("mode=%v: got no EOF after %d tokens", mode, i)
+				}
+				token, text, pos, _ := s.Scan()
+				if token == scanner.TEOF {
+					break
+				}
+				if pos.Offset < prev.Offset || pos.Line < prev.Line || pos.Line < 1 || pos.Column < 1 {
+					t.Fatalf("mode=%v: got pos %v after %v", mode, pos, prev)
+				}
+				if len(input) < pos.Offset || !strings.HasPrefix(input[pos.Offset:], text) {
+					t.Fatalf("mode=%v: got text %q at offset %d, but it is not in the input", mode, text, pos.Offset)
+				}
+				prev = pos
+			}
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package scanner_test
 
 import (
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer/scanner"
@@ -78,7 +77,7 @@ func TestScanner_Scan(t *testing.T) {
 					},
 				},
 				{
-					token: scanner.TILLEGAL,
+					token: scanner.TMINUS,
 					text:  "-",
 					pos: scanner.Position{
 						Offset: 21,
@@ -473,12 +472,55 @@ fugafuga
 				},
 			},
 		},
+		{
+			name:  "scan a dot not followed by a decimal digit",
+			input: "bench.v1 .5",
+			mode:  scanner.ScanNumberLit,
+			wants: []want{
+				{
+					token: scanner.TIDENT,
+					text:  "bench",
+					pos: scanner.Position{
+						Offset: 0,
+						Line:   1,
+						Column: 1,
+					},
+				},
+				{
+					token: scanner.TDOT,
+					text:  ".",
+					pos: scanner.Position{
+						Offset: 5,
+						Line:   1,
+						Column: 6,
+					},
+				},
+				{
+					token: scanner.TIDENT,
+					text:  "v1",
+					pos: scanner.Position{
+						Offset: 6,
+						Line:   1,
+						Column: 7,
+					},
+				},
+				{
+					token: scanner.TFLOATLIT,
+					text:  ".5",
+					pos: scanner.Position{
+						Offset: 9,
+						Line:   1,
+						Column: 10,
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			s := scanner.NewScanner(strings.NewReader(test.input))
+			s := scanner.NewScanner([]byte(test.input))
 			s.Mode = test.mode
 
 			for _, want := range test.wants {
@@ -537,7 +579,7 @@ func TestScanner_UnScan(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			s := scanner.NewScanner(strings.NewReader(test.input))
+			s := scanner.NewScanner([]byte(test.input))
 			s.Mode = test.mode
 			token, text, pos, err := s.Scan()
 			if err != nil {
@@ -574,3 +616,74 @@ func TestScanner_UnScan(t *testing.T) {
 		})
 	}
 }
+
+func TestScanner_Reset(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		inputSkip     int
+		wantTexts     []string
+		wantResetText string
+		wantResetPos  scanner.Position
+	}{
+		{
+			name:          "reset to the beginning",
+			input:         "message Outer",
+			wantTexts:     []string{"message", "Outer"},
+			wantResetText: "message",
+			wantResetPos: scanner.Position{
+				Offset: 0,
+				Line:   1,
+				Column: 1,
+			},
+		},
+		{
+			name:          "reset across lines and multibyte characters",
+			input:         "'あ'\n  foo\n bar",
+			inputSkip:     1,
+			wantTexts:     []string{"foo", "bar"},
+			wantResetText: "foo",
+			wantResetPos: scanner.Position{
+				Offset: 8,
+				Line:   2,
+				Column: 3,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s := scanner.NewScanner([]byte(test.input))
+			s.Mode = scanner.ScanStrLit
+			for i := 0; i < test.inputSkip; i++ {
+				s.Scan()
+			}
+
+			mark := s.Mark()
+			for _, want := range test.wantTexts {
+				_, text, _, err := s.Scan()
+				if err != nil {
+					t.Errorf("got err %v, but want nil", err)
+					return
+				}
+				if text != want {
+					t.Errorf("got %v, but want %v", text, want)
+				}
+			}
+
+			s.Reset(mark)
+			_, text, pos, err := s.Scan()
+			if err != nil {
+				t.Errorf("got err %v, but want nil", err)
+				return
+			}
+			if text != test.wantResetText {
+				t.Errorf("got %v, but want %v", text, test.wantResetText)
+			}
+			if pos != test.wantResetPos {
+				t.Errorf("got %v, but want %v", pos, test.wantResetPos)
+			}
+		})
+	}
+}
@@ -1,76 +1,71 @@
 package scanner
 
 // strLit = ( "'" { charValue } "'" ) |  ( '"' { charValue } '"' )
-func (s *Scanner) scanStrLit() (string, error) {
+func (s *Scanner) scanStrLit() error {
 	quote := s.read()
-	lit := string(quote)
 
-	ch := s.peek()
-	for ch != quote {
-		cv, err := s.scanCharValue()
+	for s.peek() != quote {
+		err := s.scanCharValue()
 		if err != nil {
-			return "", err
+			return err
 		}
-		lit += cv
-		ch = s.peek()
 	}
 
 	// consume quote
-	lit += string(s.read())
-	return lit, nil
+	s.read()
+	return nil
 }
 
 // charValue = hexEscape | octEscape | charEscape | /[^\0\n\\]/
-func (s *Scanner) scanCharValue() (string, error) {
+func (s *Scanner) scanCharValue() error {
 	ch := s.peek()
 
 	switch ch {
-	case eof, '\n':
-		return "", s.unexpected(ch, `/[^\0\n\\]`)
+	case eof, 0, '\n':
+		return s.unexpected(ch, `/[^\0\n\\]`)
 	case '\\':
-		return s.tryScanEscape(), nil
+		s.tryScanEscape()
+		return nil
 	default:
-		return string(s.read()), nil
+		s.read()
+		return nil
 	}
 }
 
 // hexEscape = '\' ( "x" | "X" ) hexDigit hexDigit
 // octEscape = '\' octalDigit octalDigit octalDigit
 // charEscape = '\' ( "a" | "b" | "f" | "n" | "r" | "t" | "v" | '\' | "'" | '"' )
-func (s *Scanner) tryScanEscape() string {
-	lit := string(s.read())
-
-	isCharEscape := func(r rune) bool {
-		cs := []rune{'a', 'b', 'f', 'n', 'r', 't', 'v', '\\', '\'', '"'}
-		for _, c := range cs {
-			if r == c {
-				return true
-			}
-		}
-		return false
-	}
+func (s *Scanner) tryScanEscape() {
+	s.read()
 
 	ch := s.peek()
 	switch {
 	case ch == 'x' || ch == 'X':
-		lit += string(s.read())
+		s.read()
 
 		for i := 0; i < 2; i++ {
 			if !isHexDigit(s.peek()) {
-				return lit
+				return
 			}
-			lit += string(s.read())
+			s.read()
 		}
 	case isOctalDigit(ch):
 		for i := 0; i < 3; i++ {
 			if !isOctalDigit(s.peek()) {
-				return lit
+				return
 			}
-			lit += string(s.read())
+			s.read()
 		}
 	case isCharEscape(ch):
-		lit += string(s.read())
-		return lit
+		s.read()
+	}
+}
+
+func isCharEscape(r rune) bool {
+	switch r {
+	case 'a', 'b', 'f', 'n', 'r', 't', 'v', '\\', '\'', '"':
+		return true
+	default:
+		return false
 	}
-	return lit
 }
@@ -1,5 +1,7 @@
 package scanner
 
+import "unicode/utf8"
+
 // Token represents a lexical token.
 type Token int
 
@@ -36,6 +38,8 @@ const (
 	TGREATER     // >
 	TCOMMA       // ,
 	TDOT         // .
+	TMINUS       // -
+	TPLUS        // +
 
 	// Keywords
 	TSYNTAX
@@ -58,53 +62,57 @@ const (
 	TADDITIONAL
 )
 
+// miscTokens is a precomputed table from an ASCII character to its token.
+var miscTokens = [utf8.RuneSelf]Token{
+	';':  TSEMICOLON,
+	':':  TCOLON,
+	'=':  TEQUALS,
+	'"':  TQUOTE,
+	'\'': TQUOTE,
+	'(':  TLEFTPAREN,
+	')':  TRIGHTPAREN,
+	'{':  TLEFTCURLY,
+	'}':  TRIGHTCURLY,
+	'[':  TLEFTSQUARE,
+	']':  TRIGHTSQUARE,
+	'<':  TLESS,
+	'>':  TGREATER,
+	',':  TCOMMA,
+	'.':  TDOT,
+	'-':  TMINUS,
+	'+':  TPLUS,
+}
+
 func asMiscToken(ch rune) Token {
-	m := map[rune]Token{
-		';':  TSEMICOLON,
-		':':  TCOLON,
-		'=':  TEQUALS,
-		'"':  TQUOTE,
-		'\'': TQUOTE,
-		'(':  TLEFTPAREN,
-		')':  TRIGHTPAREN,
-		'{':  TLEFTCURLY,
-		'}':  TRIGHTCURLY,
-		'[':  TLEFTSQUARE,
-		']':  TRIGHTSQUARE,
-		'<':  TLESS,
-		'>':  TGREATER,
-		',':  TCOMMA,
-		'.':  TDOT,
-	}
-	if t, ok := m[ch]; ok {
-		return t
+	if 0 <= ch && ch < utf8.RuneSelf {
+		return miscTokens[ch]
 	}
 	return TILLEGAL
 }
 
-func asKeywordToken(st string) Token {
-	m := map[string]Token{
-		"syntax":              TSYNTAX,
-		"service":             TSERVICE,
-		"rpc":                 TRPC,
-		"returns":             TRETURNS,
-		"message":             TMESSAGE,
-		"extend":              TEXTEND,
-		"import":              TIMPORT,
-		"package":             TPACKAGE,
-		"option":              TOPTION,
-		"repeated":            TREPEATED,
-		"weak":                TWEAK,
-		"public":              TPUBLIC,
-		"oneof":               TONEOF,
-		"map":                 TMAP,
-		"reserved":            TRESERVED,
-		"enum":                TENUM,
-		"stream":              TSTREAM,
-		"additional_bindings": TADDITIONAL,
-	}
+var keywordTokens = map[string]Token{
+	"syntax":              TSYNTAX,
+	"service":             TSERVICE,
+	"rpc":                 TRPC,
+	"returns":             TRETURNS,
+	"message":             TMESSAGE,
+	"extend":              TEXTEND,
+	"import":              TIMPORT,
+	"package":             TPACKAGE,
+	"option":              TOPTION,
+	"repeated":            TREPEATED,
+	"weak":                TWEAK,
+	"public":              TPUBLIC,
+	"oneof":               TONEOF,
+	"map":                 TMAP,
+	"reserved":            TRESERVED,
+	"enum":                TENUM,
+	"stream":              TSTREAM,
+	"additional_bindings": TADDITIONAL,
+}
 
-	if t, ok := m[st]; ok {
+func asKeywordToken(st string) Token {
+	if t, ok := keywordTokens[st]; ok {
 		return t
 	}
 	return TILLEGAL
@@ -0,0 +1,387 @@
+// Package jsonschema generates JSON Schema draft 2020-12 from linked messages,
+// following the proto3 JSON mapping. Like the proto3 JSON parsers, the schemas accept the original field names
+// as well as the JSON names.
+//
+// See https://protobuf.dev/programming-guides/proto3/#json
+package jsonschema
+
+import (
+	"fmt"
+
+	"github.com/thought-machine/go-protoparser/doc"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// Draft is the URI of the meta-schema of the generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema, which is marshaled with encoding/json.
+type Schema map[string]interface{}
+
+// Defs are the definitions of the messages and the enums by full name.
+type Defs map[string]Schema
+
+// Generator generates the schemas.
+type Generator struct {
+	protoNames bool
+	validators bool
+	refPrefix  string
+}
+
+// Option is an option for NewGenerator.
+type Option func(*Generator)
+
+// WithProtoNames is an option to use the field names as written rather than the lowerCamelCase JSON names
+// in the required properties.
+func WithProtoNames(protoNames bool) Option {
+	return func(g *Generator) {
+		g.protoNames = protoNames
+	}
+}
+
+// WithValidators is an option to add the constraints of the (validator.field) options of go-proto-validators,
+// like int_gt, length_lt, regex, repeated_count_max and msg_exists.
+func WithValidators(validators bool) Option {
+	return func(g *Generator) {
+		g.validators = validators
+	}
+}
+
+// WithRefPrefix is an option to set the prefix of the references to the definitions. The default is "#/$defs/".
+// It is for the callers placing the Defs elsewhere, like "#/components/schemas/" of OpenAPI.
+func WithRefPrefix(refPrefix string) Option {
+	return func(g *Generator) {
+		g.refPrefix = refPrefix
+	}
+}
+
+// NewGenerator creates a new Generator.
+func NewGenerator(options ...Option) *Generator {
+	g := &Generator{
+		refPrefix: "#/$defs/",
+	}
+	for _, opt := range options {
+		opt(g)
+	}
+	return g
+}
+
+// Generate generates the schema of the message. The schema refers to the definitions in "$defs" by full name,
+// like "#/$defs/foo.Bar", including the message itself.
+// It fails if the message refers to a type which is neither linked nor a well-known type.
+func (g *Generator) Generate(message *schema.Message) (Schema, error) {
+	defs := make(Defs)
+	root, err := g.Message(defs, message)
+	if err != nil {
+		return nil, err
+	}
+	root["$schema"] = Draft
+	root["$defs"] = defs
+	return root, nil
+}
+
+// Message adds the definitions of the message and the types it refers to to defs,
+// and returns the reference to the message.
+func (g *Generator) Message(defs Defs, message *schema.Message) (Schema, error) {
+	b := &builder{
+		Generator: g,
+		defs:      defs,
+	}
+	if err := b.message(message); err != nil {
+		return nil, err
+	}
+	return Schema{"$ref": g.ref(message.FullName)}, nil
+}
+
+// Field returns the schema of the field, and adds the definitions of the types it refers to to defs.
+func (g *Generator) Field(defs Defs, field *schema.Field) (Schema, error) {
+	b := &builder{
+		Generator: g,
+		defs:      defs,
+	}
+	s, _, err := b.field(field)
+	return s, err
+}
+
+// Type returns the schema of a value of the referenced type, and adds the definitions of the types it refers to
+// to defs. A well-known type is found by its full name, so it does not need to be linked.
+func (g *Generator) Type(defs Defs, t *schema.Ref) (Schema, error) {
+	b := &builder{
+		Generator: g,
+		defs:      defs,
+	}
+	s, _, err := b.typeSchema(t)
+	return s, err
+}
+
+// IsWellKnown reports whether the full name is of a well-known type which has a special JSON representation,
+// like "google.protobuf.Timestamp".
+func IsWellKnown(fullName string) bool {
+	_, _, ok := wellKnownSchema(fullName)
+	return ok
+}
+
+type builder struct {
+	*Generator
+	defs Defs
+}
+
+func (g *Generator) ref(fullName string) string {
+	return g.refPrefix + fullName
+}
+
+// message adds the definition of the message and the types it refers to.
+func (b *builder) message(m *schema.Message) error {
+	if _, ok := b.defs[m.FullName]; ok {
+		return nil
+	}
+	properties := make(map[string]interface{})
+	s := Schema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	describe(s, doc.Of(m.Node))
+	b.defs[m.FullName] = s
+
+	var required []string
+	var requirements []interface{}
+	for _, f := range m.Fields {
+		name, alias := b.names(f)
+		fs, req, err := b.field(f)
+		if err != nil {
+			return err
+		}
+		properties[name] = fs
+		if alias != "" {
+			properties[alias] = fs
+		}
+		switch {
+		case req && alias == "":
+			required = append(required, name)
+		case req:
+			requirements = append(requirements, b.requirement(f))
+		}
+	}
+	if 0 < len(required) {
+		s["required"] = required
+	}
+
+	for _, oneof := range m.Oneofs {
+		// At most one field of a oneof is set.
+		var alternatives []interface{}
+		for _, f := range oneof.Fields {
+			alternatives = append(alternatives, b.requirement(f))
+		}
+		requirements = append(requirements, Schema{
+			"oneOf": append(alternatives, Schema{"not": Schema{"anyOf": alternatives}}),
+		})
+	}
+	if 0 < len(requirements) {
+		s["allOf"] = requirements
+	}
+	return nil
+}
+
+// names returns the property name of the field, and the other name which the proto3 JSON parsers accept too,
+// or "" if the JSON name is the field name.
+func (b *builder) names(f *schema.Field) (string, string) {
+	name, alias := f.JSONName, f.Name
+	if b.protoNames {
+		name, alias = f.Name, f.JSONName
+	}
+	if alias == name {
+		alias = ""
+	}
+	return name, alias
+}
+
+// requirement returns the schema requiring the field by either of its names.
+func (b *builder) requirement(f *schema.Field) Schema {
+	name, alias := b.names(f)
+	if alias == "" {
+		return Schema{"required": []string{name}}
+	}
+	return Schema{"anyOf": []interface{}{
+		Schema{"required": []string{name}},
+		Schema{"required": []string{alias}},
+	}}
+}
+
+// field returns the schema of the field and whether it is required by a validator option.
+func (b *builder) field(f *schema.Field) (Schema, bool, error) {
+	value, kind, err := b.typeSchema(f.Type)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s := value
+	var array Schema
+	switch {
+	case f.IsMap():
+		s = Schema{
+			"type":                 "object",
+			"additionalProperties": value,
+		}
+		if names := keySchema(f.KeyType); names != nil {
+			s["propertyNames"] = names
+		}
+	case f.Repeated:
+		array = Schema{
+			"type":  "array",
+			"items": value,
+		}
+		s = array
+	}
+
+	var required bool
+	if b.validators {
+		var constants []string
+		for _, option := range f.Options {
+			if option.OptionName == validatorOptionName {
+				constants = append(constants, option.Constant)
+			}
+		}
+		required = applyValidators(constants, kind, value, array)
+	}
+	for _, option := range f.Options {
+		if option.OptionName == "deprecated" && option.Constant == "true" {
+			s["deprecated"] = true
+		}
+	}
+	describe(s, doc.Of(f.Node))
+	return s, required, nil
+}
+
+// typeSchema returns the schema of a single value of the type and its kind, like "integer", "int64" or "string",
+// which selects the applicable validator constraints.
+func (b *builder) typeSchema(t *schema.Ref) (Schema, string, error) {
+	if s, kind, ok := scalarSchema(t.Name); ok {
+		return s, kind, nil
+	}
+	if s, kind, ok := wellKnownSchema(t.FullName); ok {
+		return s, kind, nil
+	}
+	switch {
+	case t.Message != nil:
+		if err := b.message(t.Message); err != nil {
+			return nil, "", err
+		}
+		return Schema{"$ref": b.ref(t.FullName)}, "object", nil
+	case t.Enum != nil:
+		if _, ok := b.defs[t.FullName]; !ok {
+			var names []string
+			for _, v := range t.Enum.Values {
+				names = append(names, v.Name)
+			}
+			s := Schema{
+				"type": "string",
+				"enum": names,
+			}
+			describe(s, doc.Of(t.Enum.Node))
+			b.defs[t.FullName] = s
+		}
+		return Schema{"$ref": b.ref(t.FullName)}, "string", nil
+	}
+	return nil, "", fmt.Errorf("%s: %w %q", t.Pos, schema.ErrUndefined, t.Name)
+}
+
+func describe(s Schema, d *doc.Doc) {
+	if text := d.Text(); text != "" {
+		s["description"] = text
+	}
+	if d.Has(doc.TagDeprecated) {
+		s["deprecated"] = true
+	}
+}
+
+var (
+	int32Min  = -1 << 31
+	int32Max  = 1<<31 - 1
+	uint32Max = 1<<32 - 1
+)
+
+// scalarSchema returns the schema of the scalar type.
+// The 64-bit integers are strings, and the floating point numbers can be "NaN", "Infinity" or "-Infinity".
+func scalarSchema(name string) (Schema, string, bool) {
+	switch name {
+	case "int32", "sint32", "sfixed32":
+		return Schema{"type": "integer", "minimum": int32Min, "maximum": int32Max}, "integer", true
+	case "uint32", "fixed32":
+		return Schema{"type": "integer", "minimum": 0, "maximum": uint32Max}, "integer", true
+	case "int64", "sint64", "sfixed64":
+		return Schema{"type": "string", "pattern": "^-?[0-9]+$"}, "int64", true
+	case "uint64", "fixed64":
+		return Schema{"type": "string", "pattern": "^[0-9]+$"}, "int64", true
+	case "double", "float":
+		return Schema{"anyOf": []interface{}{
+			Schema{"type": "number"},
+			Schema{"enum": []string{"NaN", "Infinity", "-Infinity"}},
+		}}, "number", true
+	case "bool":
+		return Schema{"type": "boolean"}, "boolean", true
+	case "string":
+		return Schema{"type": "string"}, "string", true
+	case "bytes":
+		return Schema{"type": "string", "contentEncoding": "base64"}, "bytes", true
+	}
+	return nil, "", false
+}
+
+// wellKnownSchema returns the schema of the well-known type which has a special JSON representation.
+func wellKnownSchema(fullName string) (Schema, string, bool) {
+	switch fullName {
+	case "google.protobuf.Timestamp":
+		return Schema{"type": "string", "format": "date-time"}, "timestamp", true
+	case "google.protobuf.Duration":
+		return Schema{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]{1,9})?s$`}, "duration", true
+	case "google.protobuf.FieldMask":
+		return Schema{"type": "string"}, "fieldmask", true
+	case "google.protobuf.Struct":
+		return Schema{"type": "object"}, "object", true
+	case "google.protobuf.ListValue":
+		return Schema{"type": "array"}, "array", true
+	case "google.protobuf.Value":
+		return Schema{}, "value", true
+	case "google.protobuf.NullValue":
+		return Schema{"type": "null"}, "null", true
+	case "google.protobuf.Empty":
+		return Schema{"type": "object", "maxProperties": 0}, "object", true
+	case "google.protobuf.Any":
+		return Schema{
+			"type":       "object",
+			"properties": map[string]interface{}{"@type": Schema{"type": "string"}},
+			"required":   []string{"@type"},
+		}, "object", true
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return scalarSchema("double")
+	case "google.protobuf.Int64Value":
+		return scalarSchema("int64")
+	case "google.protobuf.UInt64Value":
+		return scalarSchema("uint64")
+	case "google.protobuf.Int32Value":
+		return scalarSchema("int32")
+	case "google.protobuf.UInt32Value":
+		return scalarSchema("uint32")
+	case "google.protobuf.BoolValue":
+		return scalarSchema("bool")
+	case "google.protobuf.StringValue":
+		return scalarSchema("string")
+	case "google.protobuf.BytesValue":
+		return scalarSchema("bytes")
+	}
+	return nil, "", false
+}
+
+// keySchema returns the schema of the property names of a map with the key type, or nil for string keys.
+func keySchema(keyType string) Schema {
+	switch keyType {
+	case "bool":
+		return Schema{"enum": []string{"true", "false"}}
+	case "int32", "int64", "sint32", "sint64", "sfixed32", "sfixed64":
+		return Schema{"pattern": "^-?[0-9]+$"}
+	case "uint32", "uint64", "fixed32", "fixed64":
+		return Schema{"pattern": "^[0-9]+$"}
+	}
+	return nil
+}
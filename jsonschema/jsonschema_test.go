@@ -0,0 +1,206 @@
+package jsonschema_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/thought-machine/go-protoparser/jsonschema"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var testFS = fstest.MapFS{
+	"item.proto": {Data: []byte(`syntax = "proto3";
+package itempb;
+import "google/protobuf/timestamp.proto";
+
+// Item is an item.
+message Item {
+  // Image is an image.
+  message Image {
+    int64 display_order = 1 [(validator.field) = {int_gt: 0}];
+    bytes binary = 2 [(validator.field) = {length_gt: 0}];
+    int32 width = 3 [(validator.field) = {int_gt: 0, int_lt: 4097}];
+  }
+  string item_name = 1 [(validator.field) = {length_gt: 0, length_lt: 65}, (validator.field) = {regex: "^[a-z]+$"}];
+  repeated Image images = 2 [(validator.field) = {repeated_count_max: 10}];
+  map<int32, Status> statuses = 3;
+  google.protobuf.Timestamp created_at = 4 [(validator.field) = {msg_exists : true}];
+  oneof condition {
+    double score = 5 [(validator.field) = {float_gte: 0.5}];
+    Item parent = 6;
+  }
+  uint32 count = 7 [deprecated = true];
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 1;
+}
+
+message Broken {
+  Missing missing = 1;
+}
+`)},
+}
+
+func load(t *testing.T) *schema.Schema {
+	s, _ := schema.Load(context.Background(), testFS, []string{"item.proto"})
+	if s == nil {
+		t.Fatalf("got nil, but want the schema")
+	}
+	return s
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	imageProperties := `{
+		"displayOrder": {"type": "string", "pattern": "^-?[0-9]+$"},
+		"display_order": {"type": "string", "pattern": "^-?[0-9]+$"},
+		"binary": {"type": "string", "contentEncoding": "base64"},
+		"width": {"type": "integer", "minimum": -2147483648, "maximum": 2147483647}
+	}`
+	imagePropertiesWithValidators := `{
+		"displayOrder": {"type": "string", "pattern": "^-?[0-9]+$"},
+		"display_order": {"type": "string", "pattern": "^-?[0-9]+$"},
+		"binary": {"type": "string", "contentEncoding": "base64"},
+		"width": {"type": "integer", "minimum": -2147483648, "maximum": 2147483647, "exclusiveMinimum": 0, "exclusiveMaximum": 4097}
+	}`
+	score := `{"anyOf": [{"type": "number"}, {"enum": ["NaN", "Infinity", "-Infinity"]}]}`
+	scoreWithValidators := `{"anyOf": [{"type": "number"}, {"enum": ["NaN", "Infinity", "-Infinity"]}], "minimum": 0.5}`
+
+	tests := []struct {
+		name    string
+		options []jsonschema.Option
+		want    string
+	}{
+		{
+			// The original field names are accepted too, like the proto3 JSON parsers do.
+			name: "generating without the validators",
+			want: `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/itempb.Item",
+				"$defs": {
+					"itempb.Item": {
+						"type": "object",
+						"description": "Item is an item.",
+						"additionalProperties": false,
+						"properties": {
+							"itemName": {"type": "string"},
+							"item_name": {"type": "string"},
+							"images": {"type": "array", "items": {"$ref": "#/$defs/itempb.Item.Image"}},
+							"statuses": {
+								"type": "object",
+								"additionalProperties": {"$ref": "#/$defs/itempb.Status"},
+								"propertyNames": {"pattern": "^-?[0-9]+$"}
+							},
+							"createdAt": {"type": "string", "format": "date-time"},
+							"created_at": {"type": "string", "format": "date-time"},
+							"score": ` + score + `,
+							"parent": {"$ref": "#/$defs/itempb.Item"},
+							"count": {"type": "integer", "minimum": 0, "maximum": 4294967295, "deprecated": true}
+						},
+						"allOf": [{
+							"oneOf": [
+								{"required": ["score"]},
+								{"required": ["parent"]},
+								{"not": {"anyOf": [{"required": ["score"]}, {"required": ["parent"]}]}}
+							]
+						}]
+					},
+					"itempb.Item.Image": {
+						"type": "object",
+						"description": "Image is an image.",
+						"additionalProperties": false,
+						"properties": ` + imageProperties + `
+					},
+					"itempb.Status": {"type": "string", "enum": ["STATUS_UNSPECIFIED", "STATUS_OK"]}
+				}
+			}`,
+		},
+		{
+			name: "generating with the validators and the proto names",
+			options: []jsonschema.Option{
+				jsonschema.WithValidators(true),
+				jsonschema.WithProtoNames(true),
+			},
+			want: `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/itempb.Item",
+				"$defs": {
+					"itempb.Item": {
+						"type": "object",
+						"description": "Item is an item.",
+						"additionalProperties": false,
+						"properties": {
+							"item_name": {"type": "string", "minLength": 1, "maxLength": 64, "pattern": "^[a-z]+$"},
+							"itemName": {"type": "string", "minLength": 1, "maxLength": 64, "pattern": "^[a-z]+$"},
+							"images": {"type": "array", "items": {"$ref": "#/$defs/itempb.Item.Image"}, "maxItems": 10},
+							"statuses": {
+								"type": "object",
+								"additionalProperties": {"$ref": "#/$defs/itempb.Status"},
+								"propertyNames": {"pattern": "^-?[0-9]+$"}
+							},
+							"created_at": {"type": "string", "format": "date-time"},
+							"createdAt": {"type": "string", "format": "date-time"},
+							"score": ` + scoreWithValidators + `,
+							"parent": {"$ref": "#/$defs/itempb.Item"},
+							"count": {"type": "integer", "minimum": 0, "maximum": 4294967295, "deprecated": true}
+						},
+						"allOf": [{
+							"anyOf": [{"required": ["created_at"]}, {"required": ["createdAt"]}]
+						}, {
+							"oneOf": [
+								{"required": ["score"]},
+								{"required": ["parent"]},
+								{"not": {"anyOf": [{"required": ["score"]}, {"required": ["parent"]}]}}
+							]
+						}]
+					},
+					"itempb.Item.Image": {
+						"type": "object",
+						"description": "Image is an image.",
+						"additionalProperties": false,
+						"properties": ` + imagePropertiesWithValidators + `
+					},
+					"itempb.Status": {"type": "string", "enum": ["STATUS_UNSPECIFIED", "STATUS_OK"]}
+				}
+			}`,
+		},
+	}
+
+	s := load(t)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := jsonschema.NewGenerator(test.options...).Generate(s.Message("itempb.Item"))
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+
+			gotJSON, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal(gotJSON, &gotValue); err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &wantValue); err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("got %s, but want %s", gotJSON, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_undefined(t *testing.T) {
+	_, err := jsonschema.NewGenerator().Generate(load(t).Message("itempb.Broken"))
+	if !errors.Is(err, schema.ErrUndefined) {
+		t.Errorf("got %v, but want %v", err, schema.ErrUndefined)
+	}
+}
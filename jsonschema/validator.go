@@ -0,0 +1,148 @@
+package jsonschema
+
+import (
+	"strconv"
+	"strings"
+)
+
+// validatorOptionName is the field option of go-proto-validators.
+// See https://github.com/mwitkow/go-proto-validators/blob/master/validator.proto
+const validatorOptionName = "(validator.field)"
+
+// validatorRules parses the constant of a validator option, like `{int_gt:0,regex:"[a-z]+"}`,
+// into the values by the rule names. A string value is unquoted.
+func validatorRules(constant string) map[string]string {
+	rules := make(map[string]string)
+	s := strings.TrimSpace(constant)
+	s = strings.TrimPrefix(s, "{")
+	s = strings.TrimSuffix(s, "}")
+	for s != "" {
+		colon := strings.IndexByte(s, ':')
+		if colon < 0 {
+			break
+		}
+		name := strings.TrimSpace(strings.TrimLeft(s[:colon], ", "))
+		value, rest := splitValue(strings.TrimSpace(s[colon+1:]))
+		if u, err := strconv.Unquote(value); err == nil {
+			value = u
+		}
+		rules[name] = value
+		s = strings.TrimLeft(rest, ", ")
+	}
+	return rules
+}
+
+// splitValue splits s into the first value and the rest.
+// The value is a quoted string, a bracketed "{...}" or "[...]" or a token ended by a comma or a space.
+func splitValue(s string) (string, string) {
+	if s == "" {
+		return "", ""
+	}
+	switch s[0] {
+	case '"', '\'':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case s[0]:
+				return s[:i+1], s[i+1:]
+			}
+		}
+		return s, ""
+	case '{', '[':
+		depth := 0
+		for i := 0; i < len(s); i++ {
+			switch s[i] {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return s[:i+1], s[i+1:]
+				}
+			}
+		}
+		return s, ""
+	}
+	if i := strings.IndexAny(s, ", "); 0 <= i {
+		return s[:i], s[i:]
+	}
+	return s, ""
+}
+
+// applyValidators adds the constraints of the validator options to the schema of a single value of the field type
+// and to the schema of the container, which is the array of a repeated field or the message of the field.
+func applyValidators(constants []string, kind string, value Schema, array Schema) (required bool) {
+	for _, constant := range constants {
+		for name, v := range validatorRules(constant) {
+			switch name {
+			case "msg_exists":
+				required = v == "true"
+			case "repeated_count_min":
+				setInt(array, "minItems", v, 0)
+			case "repeated_count_max":
+				setInt(array, "maxItems", v, 0)
+			case "regex":
+				if kind == "string" {
+					value["pattern"] = v
+				}
+			case "string_not_empty":
+				if kind == "string" && v == "true" {
+					value["minLength"] = 1
+				}
+			case "length_gt":
+				if kind == "string" {
+					setInt(value, "minLength", v, 1)
+				}
+			case "length_lt":
+				if kind == "string" {
+					setInt(value, "maxLength", v, -1)
+				}
+			case "length_eq":
+				if kind == "string" {
+					setInt(value, "minLength", v, 0)
+					setInt(value, "maxLength", v, 0)
+				}
+			case "uuid_ver":
+				if kind == "string" {
+					value["format"] = "uuid"
+				}
+			case "int_gt":
+				if kind == "integer" {
+					setInt(value, "exclusiveMinimum", v, 0)
+				}
+			case "int_lt":
+				if kind == "integer" {
+					setInt(value, "exclusiveMaximum", v, 0)
+				}
+			case "float_gt":
+				setFloat(value, "exclusiveMinimum", v, kind)
+			case "float_lt":
+				setFloat(value, "exclusiveMaximum", v, kind)
+			case "float_gte":
+				setFloat(value, "minimum", v, kind)
+			case "float_lte":
+				setFloat(value, "maximum", v, kind)
+			}
+		}
+	}
+	return required
+}
+
+func setInt(s Schema, keyword string, value string, delta int64) {
+	if s == nil {
+		return
+	}
+	if n, err := strconv.ParseInt(value, 0, 64); err == nil {
+		s[keyword] = n + delta
+	}
+}
+
+func setFloat(s Schema, keyword string, value string, kind string) {
+	if kind != "number" {
+		return
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		s[keyword] = f
+	}
+}
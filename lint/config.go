@@ -0,0 +1,119 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Config is the configuration of a Linter read from a YAML or JSON file, like
+//
+//	enable: [FIELD_NAMES_LOWER_SNAKE_CASE, SERVICES_HAVE_COMMENT]
+//	severities:
+//	  SERVICES_HAVE_COMMENT: error
+//	exclude:
+//	  - third_party
+//	  - "*_test.proto"
+//	report_unused_suppressions: true
+type Config struct {
+	// Enable are the names of the checked rules. All rules are checked if it is empty.
+	Enable []string `json:"enable"`
+	// Disable are the names of the rules which are not checked even if they are enabled.
+	Disable []string `json:"disable"`
+	// Severities maps the rule names to the severity names, like "warning".
+	Severities map[string]string `json:"severities"`
+	// Exclude are the slash-separated patterns of path.Match for the files which are not checked.
+	// A pattern excludes the files which it matches and the files in the directories which it matches.
+	// A pattern without a slash is matched against the base names, like "*_test.proto".
+	Exclude []string `json:"exclude"`
+	// ReportUnusedSuppressions reports the suppression directives which suppress no finding.
+	ReportUnusedSuppressions bool `json:"report_unused_suppressions"`
+}
+
+// ParseConfig parses a config in JSON if it starts with "{", and in YAML otherwise.
+// Only the block mappings and sequences, the flow sequences and the scalars of YAML are supported.
+func ParseConfig(data []byte) (*Config, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		v, err := parseYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	config := &Config{}
+	if err := dec.Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to decode the config, err %w", err)
+	}
+	return config, nil
+}
+
+// Options returns the options of NewLinter to check the rules of the registry as configured.
+// It returns an error if the config has an unknown rule, severity or an invalid pattern.
+func (c *Config) Options(registry *Registry) ([]Option, error) {
+	for _, names := range [][]string{c.Enable, c.Disable} {
+		for _, name := range names {
+			if _, ok := registry.Lookup(name); !ok {
+				return nil, fmt.Errorf("found an unknown rule %q", name)
+			}
+		}
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("found an invalid pattern %q, err %w", pattern, err)
+		}
+	}
+
+	options := []Option{
+		WithRegistry(registry),
+		WithUnusedSuppressions(c.ReportUnusedSuppressions),
+	}
+	for name, severityName := range c.Severities {
+		if _, ok := registry.Lookup(name); !ok && name != UnusedSuppression {
+			return nil, fmt.Errorf("found an unknown rule %q", name)
+		}
+		severity, err := ParseSeverity(severityName)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, WithSeverity(name, severity))
+	}
+
+	if len(c.Enable) == 0 && len(c.Disable) == 0 {
+		return options, nil
+	}
+	var enabled []string
+	for _, rule := range registry.Rules() {
+		name := rule.Name()
+		if (len(c.Enable) == 0 || contains(c.Enable, name)) && !contains(c.Disable, name) {
+			enabled = append(enabled, name)
+		}
+	}
+	return append(options, WithRules(enabled...)), nil
+}
+
+// Excluded reports whether the file is excluded by the patterns of Exclude.
+func (c *Config) Excluded(filename string) bool {
+	filename = path.Clean(toSlash(filename))
+	for _, pattern := range c.Exclude {
+		pattern = strings.TrimSuffix(pattern, "/")
+		// Match the file and then its directories, like "a/b/c.proto", "a/b" and "a".
+		for name := filename; name != "." && name != "/"; name = path.Dir(name) {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+			if !strings.Contains(pattern, "/") {
+				if ok, _ := path.Match(pattern, path.Base(name)); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// maxFixPasses is the maximum number of passes of Fix.
+const maxFixPasses = 10
+
+// Edit replaces the bytes of the source from the offset Start to End by NewText.
+type Edit = edit.Edit
+
+// ApplyEdits applies the edits of the findings to src in the order of the findings. The edits of a finding
+// are skipped if one of them overlaps an edit of a preceding finding, or an edit at the same offset.
+// It returns the edited source and the findings whose edits are applied.
+func ApplyEdits(src []byte, findings []*Finding) ([]byte, []*Finding) {
+	var edits []*Edit
+	var applied []*Finding
+	for _, f := range findings {
+		if len(f.Edits) == 0 || overlapsAny(f.Edits, edits) {
+			continue
+		}
+		edits = append(edits, f.Edits...)
+		applied = append(applied, f)
+	}
+	if len(applied) == 0 {
+		return src, nil
+	}
+
+	// The edits do not overlap.
+	fixed, _ := edit.Apply(src, edits)
+	return fixed, applied
+}
+
+func overlapsAny(edits, accepted []*Edit) bool {
+	for i, e := range edits {
+		for _, other := range accepted {
+			if e.Overlaps(other) {
+				return true
+			}
+		}
+		for _, other := range edits[:i] {
+			if e.Overlaps(other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Fix checks src and applies the edits of the findings until no edit is left, since the edits which
+// overlap are applied by the next pass. The result of every pass is parsed again to verify it, and
+// an error is returned if it cannot be parsed. It returns the fixed source and its remaining findings.
+// A missing trailing ";" is inserted first, since the rules check parsed files. Any other error of the parser
+// is returned.
+func (l *Linter) Fix(src []byte, options ...protoparser.Option) ([]byte, []*Finding, error) {
+	src, p, err := fixSemicolons(src, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for pass := 0; pass < maxFixPasses; pass++ {
+		findings := l.LintSource(p, src)
+		fixed, applied := ApplyEdits(src, findings)
+		if len(applied) == 0 {
+			return src, findings, nil
+		}
+		p, err = protoparser.Parse(bytes.NewReader(fixed), options...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse the fixed source, err %w", err)
+		}
+		src = fixed
+	}
+	return src, l.LintSource(p, src), nil
+}
+
+// fixSemicolons parses src, and inserts a ";" after the token before the one where the parser expects it,
+// until src is parsed or the parser fails otherwise. It returns the fixed source and its parsed file.
+func fixSemicolons(src []byte, options ...protoparser.Option) ([]byte, *parser.Proto, error) {
+	last := -1
+	for {
+		p, err := protoparser.Parse(bytes.NewReader(src), options...)
+		unexpected := expectedSemicolon(err)
+		if unexpected == nil {
+			return src, p, err
+		}
+		end := -1
+		for _, item := range edit.NewSource(src).Items {
+			if unexpected.Pos.Offset < item.End.Offset {
+				break
+			}
+			end = item.End.Offset
+		}
+		// The source is not fixed if the ";" is expected before the first token or the last inserted one.
+		if end <= last {
+			return nil, nil, err
+		}
+		last = end
+		src, _ = edit.Apply(src, []*Edit{{Start: end, End: end, NewText: ";"}})
+	}
+}
+
+// expectedSemicolon returns the error of the parser expecting ";", which may be one of the errors of
+// the alternatives of a statement.
+func expectedSemicolon(err error) *parser.UnexpectedError {
+	switch e := err.(type) {
+	case *parser.UnexpectedError:
+		if e.Expected == ";" {
+			return e
+		}
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if unexpected := expectedSemicolon(err); unexpected != nil {
+				return unexpected
+			}
+		}
+	case interface{ Unwrap() error }:
+		return expectedSemicolon(e.Unwrap())
+	}
+	return nil
+}
+
+// nameToken returns the token of the name of the statement at pos. The name is the token before "="
+// for a field, and the first token otherwise. It returns nil without the source.
+func (c *Context) nameToken(pos meta.Position, field bool) *tokenizer.Item {
+	if c.source == nil {
+		return nil
+	}
+	i, ok := c.source.Index(pos.Offset)
+	if !ok {
+		return nil
+	}
+	items := c.source.Items
+	if field {
+		for ; i+1 < len(items) && items[i+1].Token != tokenizer.TEQUALS; i++ {
+			if items[i+1].Token == tokenizer.TSEMICOLON {
+				return nil
+			}
+		}
+		if len(items) <= i+1 {
+			return nil
+		}
+	}
+	return items[i]
+}
+
+// rename returns the edit to rename the statement at pos, or nil without the source.
+func (c *Context) rename(pos meta.Position, field bool, name string) []*Edit {
+	item := c.nameToken(pos, field)
+	if item == nil {
+		return nil
+	}
+	return []*Edit{{Start: item.Pos.Offset, End: item.End.Offset, NewText: name}}
+}
+
+// statementEnd returns the offset after the ";" of the statement at pos, and after its inline comment if any.
+func (c *Context) statementEnd(pos meta.Position, inline *parser.Comment) (int, bool) {
+	return c.source.StatementEnd(pos.Offset, inline)
+}
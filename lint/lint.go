@@ -0,0 +1,381 @@
+// Package lint checks parsed files against a set of rules.
+//
+// A Rule is given every node of a file together with a Context, which knows the enclosing nodes and reports
+// the findings. The rules are looked up in a Registry, and DefaultRegistry has the built-in style rules.
+//
+// The findings can be suppressed by directives in the comments, followed by the rule names or by nothing
+// for all rules:
+//
+//	// protolint:disable RULE...            suppresses them until "protolint:enable RULE..." or the end of the file.
+//	// protolint:disable:next-line RULE...  in the leading comments suppresses them on the line of the node.
+//	int32 count = 1; // protolint:disable:this RULE...  as an inline comment does the same.
+//
+// The findings of the rules which can fix them carry the Edits, which Linter.Fix applies. The rules check
+// parsed files, so Linter.Fix inserts the missing trailing ";" of the statements before checking them.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// Severity is the severity of a finding.
+type Severity int
+
+// The severities in the increasing order.
+const (
+	Info Severity = iota + 1
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// ParseSeverity parses the name of a severity, like "warning".
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{Info, Warning, Error} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("found %q but expected info, warning or error", name)
+}
+
+// Finding is a problem reported by a rule.
+type Finding struct {
+	// Rule is the name of the rule.
+	Rule     string
+	Severity Severity
+	Message  string
+	Pos      meta.Position
+	// Edits fix the problem. They are set only by the rules which can fix it, if the source is given
+	// to LintSource.
+	Edits []*Edit
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Pos, f.Severity, f.Message, f.Rule)
+}
+
+// Rule is a check of the nodes.
+type Rule interface {
+	// Name is the unique name of the rule, like "FIELD_NAMES_LOWER_SNAKE_CASE".
+	Name() string
+	// Doc is a one-line description of the rule.
+	Doc() string
+	// Check checks the node and reports the problems by c.Report. The node is the *parser.Proto first,
+	// followed by its syntax and all nodes of its body in the order of the declaration.
+	Check(c *Context, node parser.Visitee)
+}
+
+type funcRule struct {
+	name  string
+	doc   string
+	check func(c *Context, node parser.Visitee)
+}
+
+// NewRule returns a Rule which calls check.
+func NewRule(name, doc string, check func(c *Context, node parser.Visitee)) Rule {
+	return &funcRule{name: name, doc: doc, check: check}
+}
+
+func (r *funcRule) Name() string {
+	return r.name
+}
+
+func (r *funcRule) Doc() string {
+	return r.doc
+}
+
+func (r *funcRule) Check(c *Context, node parser.Visitee) {
+	r.check(c, node)
+}
+
+// Context is the scope of the checked node.
+type Context struct {
+	file     *parser.Proto
+	source   *edit.Source
+	pkg      string
+	parents  []parser.Visitee
+	rule     Rule
+	severity Severity
+	findings []*Finding
+}
+
+// File returns the checked file.
+func (c *Context) File() *parser.Proto {
+	return c.file
+}
+
+// Filename returns the name of the checked file, if any.
+func (c *Context) Filename() string {
+	if c.file.Meta == nil {
+		return ""
+	}
+	return c.file.Meta.Filename
+}
+
+// Package returns the package name of the checked file. It is empty if the file has no package statement.
+func (c *Context) Package() string {
+	return c.pkg
+}
+
+// Parents returns the nodes enclosing the checked node, the outermost first. It is empty for a node
+// at the top level and for the file.
+func (c *Context) Parents() []parser.Visitee {
+	return c.parents
+}
+
+// Parent returns the innermost node enclosing the checked node, or nil.
+func (c *Context) Parent() parser.Visitee {
+	if len(c.parents) == 0 {
+		return nil
+	}
+	return c.parents[len(c.parents)-1]
+}
+
+// Source returns the source of the checked file. It is nil unless the file is checked by LintSource.
+func (c *Context) Source() []byte {
+	if c.source == nil {
+		return nil
+	}
+	return c.source.Src
+}
+
+// Report reports a finding of the rule at pos. The returned Finding can be given the Edits to fix it.
+func (c *Context) Report(pos meta.Position, format string, args ...interface{}) *Finding {
+	f := &Finding{
+		Rule:     c.rule.Name(),
+		Severity: c.severity,
+		Message:  fmt.Sprintf(format, args...),
+		Pos:      pos,
+	}
+	c.findings = append(c.findings, f)
+	return f
+}
+
+// Registry is a set of rules with their default severities.
+type Registry struct {
+	rules      []Rule
+	byName     map[string]Rule
+	severities map[string]Severity
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		byName:     make(map[string]Rule),
+		severities: make(map[string]Severity),
+	}
+}
+
+// DefaultRegistry returns a new Registry with the built-in rules.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, b := range builtins {
+		if err := r.Register(b.rule, b.severity); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds the rule reported with the severity by default.
+func (r *Registry) Register(rule Rule, severity Severity) error {
+	if _, ok := r.byName[rule.Name()]; ok {
+		return fmt.Errorf("found a duplicate rule %q", rule.Name())
+	}
+	r.rules = append(r.rules, rule)
+	r.byName[rule.Name()] = rule
+	r.severities[rule.Name()] = severity
+	return nil
+}
+
+// Rules returns the rules in the order of the registration.
+func (r *Registry) Rules() []Rule {
+	return r.rules
+}
+
+// Lookup returns the rule of the name.
+func (r *Registry) Lookup(name string) (Rule, bool) {
+	rule, ok := r.byName[name]
+	return rule, ok
+}
+
+// Severity returns the default severity of the rule.
+func (r *Registry) Severity(name string) Severity {
+	return r.severities[name]
+}
+
+// Linter checks files by the rules of a Registry.
+type Linter struct {
+	registry *Registry
+	// enabled is nil if all rules are enabled.
+	enabled      map[string]bool
+	severities   map[string]Severity
+	reportUnused bool
+}
+
+// Option is an option for NewLinter.
+type Option func(*Linter)
+
+// WithRegistry is an option to check the rules of the registry instead of DefaultRegistry.
+func WithRegistry(registry *Registry) Option {
+	return func(l *Linter) {
+		l.registry = registry
+	}
+}
+
+// WithRules is an option to check only the named rules. All rules are checked by default.
+func WithRules(names ...string) Option {
+	return func(l *Linter) {
+		l.enabled = make(map[string]bool)
+		for _, name := range names {
+			l.enabled[name] = true
+		}
+	}
+}
+
+// WithSeverity is an option to report the findings of the rule with the severity instead of its default.
+func WithSeverity(name string, severity Severity) Option {
+	return func(l *Linter) {
+		l.severities[name] = severity
+	}
+}
+
+// WithUnusedSuppressions is an option to report the suppression directives which suppress no finding
+// as findings of UnusedSuppression. The severity is Warning unless set by WithSeverity.
+func WithUnusedSuppressions(report bool) Option {
+	return func(l *Linter) {
+		l.reportUnused = report
+	}
+}
+
+// NewLinter creates a new Linter.
+func NewLinter(options ...Option) *Linter {
+	l := &Linter{
+		severities: make(map[string]Severity),
+	}
+	for _, opt := range options {
+		opt(l)
+	}
+	if l.registry == nil {
+		l.registry = DefaultRegistry()
+	}
+	return l
+}
+
+// Lint checks the file and returns the findings sorted by their positions.
+// The findings suppressed by the directives in the comments are left out, see the package documentation.
+func (l *Linter) Lint(p *parser.Proto) []*Finding {
+	return l.LintSource(p, nil)
+}
+
+// LintSource is like Lint, but the findings can have the Edits to fix them. src is the source which p is parsed from.
+func (l *Linter) LintSource(p *parser.Proto, src []byte) []*Finding {
+	var s *edit.Source
+	if src != nil {
+		s = edit.NewSource(src)
+	}
+	var pkg string
+	for _, v := range p.ProtoBody {
+		if n, ok := v.(*parser.Package); ok {
+			pkg = n.Name
+		}
+	}
+
+	var findings []*Finding
+	for _, rule := range l.registry.Rules() {
+		if !l.isEnabled(rule.Name()) {
+			continue
+		}
+		c := &Context{
+			file:     p,
+			source:   s,
+			pkg:      pkg,
+			rule:     rule,
+			severity: l.severity(rule.Name()),
+		}
+		walk(p, nil, func(node parser.Visitee, parents []parser.Visitee) {
+			c.parents = parents
+			rule.Check(c, node)
+		})
+		findings = append(findings, c.findings...)
+	}
+
+	suppressions := collectSuppressions(p)
+	findings = suppressions.filter(findings)
+	if l.reportUnused {
+		findings = append(findings, suppressions.unused(l)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Pos.Offset < findings[j].Pos.Offset
+	})
+	return findings
+}
+
+func (l *Linter) isEnabled(name string) bool {
+	return l.enabled == nil || l.enabled[name]
+}
+
+func (l *Linter) severity(name string) Severity {
+	if severity, ok := l.severities[name]; ok {
+		return severity
+	}
+	if name == UnusedSuppression {
+		return Warning
+	}
+	return l.registry.Severity(name)
+}
+
+// walk calls fn with the node and then with its children. parents are the nodes enclosing the node,
+// the outermost first.
+func walk(node parser.Visitee, parents []parser.Visitee, fn func(node parser.Visitee, parents []parser.Visitee)) {
+	fn(node, parents)
+
+	var children []parser.Visitee
+	switch n := node.(type) {
+	case *parser.Proto:
+		if n.Syntax != nil {
+			children = append(children, n.Syntax)
+		}
+		children = append(children, n.ProtoBody...)
+	case *parser.Message:
+		children = n.MessageBody
+	case *parser.Enum:
+		children = n.EnumBody
+	case *parser.Extend:
+		children = n.ExtendBody
+	case *parser.Service:
+		children = n.ServiceBody
+	case *parser.Oneof:
+		for _, field := range n.OneofFields {
+			children = append(children, field)
+		}
+	case *parser.RPC:
+		for _, option := range n.Options {
+			children = append(children, option)
+		}
+	}
+
+	if _, ok := node.(*parser.Proto); !ok {
+		parents = append(parents[:len(parents):len(parents)], node)
+	}
+	for _, child := range children {
+		walk(child, parents, fn)
+	}
+}
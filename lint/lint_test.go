@@ -0,0 +1,484 @@
+package lint_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/lint"
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+const src = `syntax = "proto3";
+package item.v1;
+
+message item_list {
+  message Entry {
+    string itemName = 1;
+    map<string, int32> ItemCounts = 2;
+    oneof condition {
+      double Score = 3;
+    }
+  }
+}
+
+enum HTTPStatus {
+  HTTP_STATUS_NONE = 0;
+  HTTP_STATUS_OK = 1;
+  NotFound = 2;
+}
+
+// Items manages the items.
+service Items {
+  rpc Get(GetRequest) returns (GetResponse);
+  rpc List(ItemsListRequest) returns (item.v1.Entries);
+}
+
+service Undocumented {}
+`
+
+func parse(t *testing.T, filename string) *parser.Proto {
+	got, err := protoparser.Parse(strings.NewReader(src), protoparser.WithFilename(filename))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	return got
+}
+
+type finding struct {
+	rule     string
+	severity lint.Severity
+	line     int
+	message  string
+}
+
+func simplify(findings []*lint.Finding) []finding {
+	var got []finding
+	for _, f := range findings {
+		got = append(got, finding{f.Rule, f.Severity, f.Pos.Line, f.Message})
+	}
+	return got
+}
+
+func TestLinter_Lint(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		options  []lint.Option
+		want     []finding
+	}{
+		{
+			name:     "all rules",
+			filename: "protos/itemList.proto",
+			want: []finding{
+				{lint.FileNamesLowerSnakeCase, lint.Warning, 1, `file name "itemList.proto" should be lower_snake_case like "item_list.proto"`},
+				{lint.PackageDirectoryMatch, lint.Warning, 2, `package "item.v1" should be in a directory ending with "item/v1", but the file is in "protos"`},
+				{lint.MessageNamesUpperCamelCase, lint.Error, 4, `message name "item_list" should be UpperCamelCase like "ItemList"`},
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 6, `field name "itemName" should be lower_snake_case like "item_name"`},
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 7, `field name "ItemCounts" should be lower_snake_case like "item_counts"`},
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 9, `field name "Score" should be lower_snake_case like "score"`},
+				{lint.EnumZeroValueUnspecified, lint.Warning, 15, `zero value "HTTP_STATUS_NONE" should be named like "HTTP_STATUS_UNSPECIFIED"`},
+				{lint.EnumValueNamesUpperSnake, lint.Error, 17, `enum value name "NotFound" should be UPPER_SNAKE_CASE like "NOT_FOUND"`},
+				{lint.EnumValueNamesPrefix, lint.Warning, 17, `enum value name "NotFound" should be prefixed with "HTTP_STATUS_"`},
+				{lint.RPCResponseStandardName, lint.Warning, 23, `response "item.v1.Entries" of RPC "List" should be named "ListResponse"`},
+				{lint.ServicesHaveComment, lint.Info, 26, `service "Undocumented" should have a comment`},
+			},
+		},
+		{
+			name:     "selected rules and severities",
+			filename: "item/v1/item.proto",
+			options: []lint.Option{
+				lint.WithRules(lint.FieldNamesLowerSnakeCase, lint.PackageDirectoryMatch, lint.ServicesHaveComment),
+				lint.WithSeverity(lint.ServicesHaveComment, lint.Error),
+			},
+			want: []finding{
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 6, `field name "itemName" should be lower_snake_case like "item_name"`},
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 7, `field name "ItemCounts" should be lower_snake_case like "item_counts"`},
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 9, `field name "Score" should be lower_snake_case like "score"`},
+				{lint.ServicesHaveComment, lint.Error, 26, `service "Undocumented" should have a comment`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got := simplify(lint.NewLinter(test.options...).Lint(parse(t, test.filename)))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLinter_customRule(t *testing.T) {
+	var parents []string
+	rule := lint.NewRule("NESTED_MESSAGES", "Messages are not nested.", func(c *lint.Context, node parser.Visitee) {
+		m, ok := node.(*parser.Message)
+		if !ok || c.Parent() == nil {
+			return
+		}
+		for _, p := range c.Parents() {
+			parents = append(parents, p.(*parser.Message).MessageName)
+		}
+		c.Report(m.Meta.Pos, "message %q in package %q is nested", m.MessageName, c.Package())
+	})
+	registry := lint.NewRegistry()
+	if err := registry.Register(rule, lint.Warning); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if err := registry.Register(rule, lint.Warning); err == nil {
+		t.Errorf("got nil, but want an error for the duplicate")
+	}
+
+	got := simplify(lint.NewLinter(lint.WithRegistry(registry)).Lint(parse(t, "item/v1/item.proto")))
+	want := []finding{
+		{"NESTED_MESSAGES", lint.Warning, 5, `message "Entry" in package "item.v1" is nested`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if want := []string{"item_list"}; !reflect.DeepEqual(parents, want) {
+		t.Errorf("got %v, but want %v", parents, want)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, want := range []lint.Severity{lint.Info, lint.Warning, lint.Error} {
+		got, err := lint.ParseSeverity(want.String())
+		if err != nil || got != want {
+			t.Errorf("got %v and err %v, but want %v", got, err, want)
+		}
+	}
+	if _, err := lint.ParseSeverity("fatal"); err == nil {
+		t.Errorf("got nil, but want an error")
+	}
+}
+
+const suppressed = `// protolint:disable ENUM_VALUE_NAMES_PREFIX
+syntax = "proto3";
+package item.v1;
+
+message Item {
+  // The name.
+  // protolint:disable:next-line FIELD_NAMES_LOWER_SNAKE_CASE
+  string itemName = 1;
+  int32 ItemCount = 2; // protolint:disable:this
+  int32 ItemSize = 3;
+  string title = 4; // protolint:disable:this FIELD_NAMES_LOWER_SNAKE_CASE
+  // protolint:disable:next-line UNKNOWN_RULE SERVICES_HAVE_COMMENT
+  string note = 5;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  OK = 1;
+}
+
+// protolint:enable ENUM_VALUE_NAMES_PREFIX
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+  GOOD = 1;
+}
+`
+
+func TestLinter_suppressions(t *testing.T) {
+	p, err := protoparser.Parse(strings.NewReader(suppressed), protoparser.WithFilename("item/v1/item.proto"))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options []lint.Option
+		want    []finding
+	}{
+		{
+			name: "suppressed",
+			want: []finding{
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 10, `field name "ItemSize" should be lower_snake_case like "item_size"`},
+				{lint.EnumValueNamesPrefix, lint.Warning, 24, `enum value name "GOOD" should be prefixed with "KIND_"`},
+			},
+		},
+		{
+			name: "unused suppressions",
+			options: []lint.Option{
+				lint.WithRules(lint.FieldNamesLowerSnakeCase, lint.EnumValueNamesPrefix),
+				lint.WithUnusedSuppressions(true),
+			},
+			want: []finding{
+				{lint.FieldNamesLowerSnakeCase, lint.Error, 10, `field name "ItemSize" should be lower_snake_case like "item_size"`},
+				{lint.UnusedSuppression, lint.Warning, 11, `suppression of "FIELD_NAMES_LOWER_SNAKE_CASE" matches no finding`},
+				{lint.UnusedSuppression, lint.Warning, 12, `suppression of "UNKNOWN_RULE" matches no finding`},
+				{lint.EnumValueNamesPrefix, lint.Warning, 24, `enum value name "GOOD" should be prefixed with "KIND_"`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got := simplify(lint.NewLinter(test.options...).Lint(p))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	want := &lint.Config{
+		Enable:                   []string{lint.FieldNamesLowerSnakeCase, lint.ServicesHaveComment},
+		Disable:                  []string{lint.ServicesHaveComment},
+		Severities:               map[string]string{lint.FieldNamesLowerSnakeCase: "warning"},
+		Exclude:                  []string{"third_party/", "*_test.proto", "a #b"},
+		ReportUnusedSuppressions: true,
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "YAML",
+			input: `# The rules.
+enable: [FIELD_NAMES_LOWER_SNAKE_CASE, SERVICES_HAVE_COMMENT]
+disable:
+- SERVICES_HAVE_COMMENT
+severities:
+  FIELD_NAMES_LOWER_SNAKE_CASE: warning # was error
+exclude:
+  - third_party/
+  - "*_test.proto"
+  - 'a #b'
+report_unused_suppressions: true
+`,
+		},
+		{
+			name: "JSON",
+			input: `{
+  "enable": ["FIELD_NAMES_LOWER_SNAKE_CASE", "SERVICES_HAVE_COMMENT"],
+  "disable": ["SERVICES_HAVE_COMMENT"],
+  "severities": {"FIELD_NAMES_LOWER_SNAKE_CASE": "warning"},
+  "exclude": ["third_party/", "*_test.proto", "a #b"],
+  "report_unused_suppressions": true
+}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := lint.ParseConfig([]byte(test.input))
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, but want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseConfig_errors(t *testing.T) {
+	for _, input := range []string{
+		"enable:\n\t- A\n",
+		"enable: [A\n",
+		"unknown: true\n",
+		"enable: A\nenable: B\n",
+		"enable:\n  - A\n    - B\n",
+	} {
+		if _, err := lint.ParseConfig([]byte(input)); err == nil {
+			t.Errorf("got nil, but want an error for %q", input)
+		}
+	}
+}
+
+func TestConfig_Options(t *testing.T) {
+	config, err := lint.ParseConfig([]byte(`
+enable: [FIELD_NAMES_LOWER_SNAKE_CASE, SERVICES_HAVE_COMMENT]
+disable: [SERVICES_HAVE_COMMENT]
+severities:
+  FIELD_NAMES_LOWER_SNAKE_CASE: warning
+`))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	options, err := config.Options(lint.DefaultRegistry())
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	got := simplify(lint.NewLinter(options...).Lint(parse(t, "item/v1/item.proto")))
+	want := []finding{
+		{lint.FieldNamesLowerSnakeCase, lint.Warning, 6, `field name "itemName" should be lower_snake_case like "item_name"`},
+		{lint.FieldNamesLowerSnakeCase, lint.Warning, 7, `field name "ItemCounts" should be lower_snake_case like "item_counts"`},
+		{lint.FieldNamesLowerSnakeCase, lint.Warning, 9, `field name "Score" should be lower_snake_case like "score"`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+
+	for _, config := range []*lint.Config{
+		{Enable: []string{"UNKNOWN"}},
+		{Severities: map[string]string{lint.ServicesHaveComment: "fatal"}},
+		{Exclude: []string{"["}},
+	} {
+		if _, err := config.Options(lint.DefaultRegistry()); err == nil {
+			t.Errorf("got nil, but want an error for %v", config)
+		}
+	}
+}
+
+func TestConfig_Excluded(t *testing.T) {
+	config := &lint.Config{Exclude: []string{"third_party/", "*_test.proto", "api/*/internal"}}
+	for filename, want := range map[string]bool{
+		"third_party/google/api.proto": true,
+		"third_party.proto":            false,
+		"item/item_test.proto":         true,
+		"api/v1/internal/a.proto":      true,
+		"api/v1/public/a.proto":        false,
+		"item/item.proto":              false,
+	} {
+		if got := config.Excluded(filename); got != want {
+			t.Errorf("got %v, but want %v for %s", got, want, filename)
+		}
+	}
+}
+
+func TestLinter_Fix(t *testing.T) {
+	input := `syntax = "proto3";
+package item.v1;
+
+// The storage.
+import "storage.proto";
+import "google/protobuf/timestamp.proto"; // For the time.
+import "common.proto";
+
+message Item {
+  string itemName = 1 [json_name = "itemName"];
+  map<string, int32> ItemCounts = 2;
+  ItemName ItemName = 3;
+}
+
+enum HTTPStatus {
+  NONE = 0;
+  HTTP_STATUS_OK = 1;
+  NotFound = 2;
+}
+`
+	want := `syntax = "proto3";
+package item.v1;
+
+import "common.proto";
+import "google/protobuf/timestamp.proto"; // For the time.
+// The storage.
+import "storage.proto";
+
+message Item {
+  string item_name = 1 [json_name = "itemName"];
+  map<string, int32> item_counts = 2;
+  ItemName item_name = 3;
+}
+
+enum HTTPStatus {
+  HTTP_STATUS_UNSPECIFIED = 0;
+  HTTP_STATUS_OK = 1;
+  HTTP_STATUS_NOT_FOUND = 2;
+}
+`
+	got, findings, err := lint.NewLinter().Fix([]byte(input), protoparser.WithFilename("item/v1/item.proto"))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got %v, but want %v", string(got), want)
+	}
+	if len(findings) != 0 {
+		t.Errorf("got %v, but want none", simplify(findings))
+	}
+
+	// The result of a broken fix is not returned.
+	broken := lint.NewRule("BROKEN", "Removes the syntax.", func(c *lint.Context, node parser.Visitee) {
+		if s, ok := node.(*parser.Syntax); ok {
+			f := c.Report(s.Meta.Pos, "broken")
+			f.Edits = []*lint.Edit{{Start: s.Meta.Pos.Offset, End: s.Meta.Pos.Offset + len("syntax"), NewText: "}"}}
+		}
+	})
+	registry := lint.NewRegistry()
+	if err := registry.Register(broken, lint.Error); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if got, _, err := lint.NewLinter(lint.WithRegistry(registry)).Fix([]byte(input)); err == nil {
+		t.Errorf("got %v, but want an error", string(got))
+	}
+}
+
+func TestLinter_Fix_semicolons(t *testing.T) {
+	input := `syntax = "proto3"
+package item.v1
+
+import "common.proto"
+
+message Item {
+  string item_name = 1 // The name.
+  map<string, int32> item_counts = 2
+  reserved 3
+  option deprecated = true
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0
+}
+`
+	want := `syntax = "proto3";
+package item.v1;
+
+import "common.proto";
+
+message Item {
+  string item_name = 1; // The name.
+  map<string, int32> item_counts = 2;
+  reserved 3;
+  option deprecated = true;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+}
+`
+	got, _, err := lint.NewLinter().Fix([]byte(input), protoparser.WithFilename("item/v1/item.proto"))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got %v, but want %v", string(got), want)
+	}
+
+	// A source which is broken otherwise is not fixed.
+	if got, _, err := lint.NewLinter().Fix([]byte("syntax = \"proto3\"\nmessage Item {\n  string = 1\n}\n")); err == nil {
+		t.Errorf("got %v, but want an error", string(got))
+	}
+}
+
+func TestApplyEdits(t *testing.T) {
+	src := []byte("abcdef")
+	findings := []*lint.Finding{
+		{Rule: "A", Edits: []*lint.Edit{{Start: 1, End: 3, NewText: "X"}}},
+		{Rule: "B", Edits: []*lint.Edit{{Start: 2, End: 4, NewText: "Y"}}},
+		{Rule: "C", Edits: []*lint.Edit{{Start: 4, End: 4, NewText: "Z"}, {Start: 5, End: 6, NewText: ""}}},
+		{Rule: "D", Edits: []*lint.Edit{{Start: 4, End: 5, NewText: "W"}}},
+		{Rule: "E"},
+	}
+	got, applied := lint.ApplyEdits(src, findings)
+	if want := "aXdZe"; string(got) != want {
+		t.Errorf("got %v, but want %v", string(got), want)
+	}
+	var rules []string
+	for _, f := range applied {
+		rules = append(rules, f.Rule)
+	}
+	if want := []string{"A", "C"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("got %v, but want %v", rules, want)
+	}
+}
@@ -0,0 +1,303 @@
+package lint
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/thought-machine/go-protoparser/doc"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// The names of the built-in rules.
+const (
+	MessageNamesUpperCamelCase = "MESSAGE_NAMES_UPPER_CAMEL_CASE"
+	FieldNamesLowerSnakeCase   = "FIELD_NAMES_LOWER_SNAKE_CASE"
+	EnumValueNamesUpperSnake   = "ENUM_VALUE_NAMES_UPPER_SNAKE_CASE"
+	EnumValueNamesPrefix       = "ENUM_VALUE_NAMES_PREFIX"
+	EnumZeroValueUnspecified   = "ENUM_ZERO_VALUE_UNSPECIFIED"
+	RPCRequestStandardName     = "RPC_REQUEST_STANDARD_NAME"
+	RPCResponseStandardName    = "RPC_RESPONSE_STANDARD_NAME"
+	FileNamesLowerSnakeCase    = "FILE_NAMES_LOWER_SNAKE_CASE"
+	PackageDirectoryMatch      = "PACKAGE_DIRECTORY_MATCH"
+	ServicesHaveComment        = "SERVICES_HAVE_COMMENT"
+	ImportsSorted              = "IMPORTS_SORTED"
+)
+
+var builtins = []struct {
+	rule     Rule
+	severity Severity
+}{
+	{NewRule(MessageNamesUpperCamelCase, "Message names are UpperCamelCase.", checkMessageName), Error},
+	{NewRule(FieldNamesLowerSnakeCase, "Field names are lower_snake_case.", checkFieldName), Error},
+	{NewRule(EnumValueNamesUpperSnake, "Enum value names are UPPER_SNAKE_CASE.", checkEnumValueName), Error},
+	{NewRule(EnumValueNamesPrefix, "Enum value names are prefixed with the UPPER_SNAKE_CASE enum name.", checkEnumValuePrefix), Warning},
+	{NewRule(EnumZeroValueUnspecified, "The zero value of an enum ends with _UNSPECIFIED.", checkEnumZeroValue), Warning},
+	{NewRule(RPCRequestStandardName, "RPC requests are named <RPC>Request or <Service><RPC>Request.", checkRPCRequest), Warning},
+	{NewRule(RPCResponseStandardName, "RPC responses are named <RPC>Response or <Service><RPC>Response.", checkRPCResponse), Warning},
+	{NewRule(FileNamesLowerSnakeCase, "File names are lower_snake_case.proto.", checkFileName), Warning},
+	{NewRule(PackageDirectoryMatch, "Files are in the directory matching their package, like foo/v1 for foo.v1.", checkPackageDirectory), Warning},
+	{NewRule(ServicesHaveComment, "Services have a leading comment.", checkServiceComment), Info},
+	{NewRule(ImportsSorted, "Imports are sorted by their locations.", checkImportsSorted), Warning},
+}
+
+var (
+	upperCamelCase = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	lowerSnakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	upperSnakeCase = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+)
+
+func checkMessageName(c *Context, node parser.Visitee) {
+	m, ok := node.(*parser.Message)
+	if !ok || upperCamelCase.MatchString(m.MessageName) {
+		return
+	}
+	c.Report(m.Meta.Pos, "message name %q should be UpperCamelCase like %q", m.MessageName, toUpperCamelCase(m.MessageName))
+}
+
+func checkFieldName(c *Context, node parser.Visitee) {
+	var name string
+	var pos meta.Position
+	switch n := node.(type) {
+	case *parser.Field:
+		name, pos = n.FieldName, n.Meta.Pos
+	case *parser.MapField:
+		name, pos = n.MapName, n.Meta.Pos
+	case *parser.OneofField:
+		name, pos = n.FieldName, n.Meta.Pos
+	default:
+		return
+	}
+	if lowerSnakeCase.MatchString(name) {
+		return
+	}
+	want := toLowerSnakeCase(name)
+	f := c.Report(pos, "field name %q should be lower_snake_case like %q", name, want)
+	f.Edits = c.rename(pos, true, want)
+}
+
+func checkEnumValueName(c *Context, node parser.Visitee) {
+	v, ok := node.(*parser.EnumField)
+	if !ok || upperSnakeCase.MatchString(v.Ident) {
+		return
+	}
+	want := toUpperSnakeCase(v.Ident)
+	f := c.Report(v.Meta.Pos, "enum value name %q should be UPPER_SNAKE_CASE like %q", v.Ident, want)
+	f.Edits = c.rename(v.Meta.Pos, false, want)
+}
+
+func checkEnumValuePrefix(c *Context, node parser.Visitee) {
+	v, ok := node.(*parser.EnumField)
+	if !ok {
+		return
+	}
+	e, ok := c.Parent().(*parser.Enum)
+	if !ok {
+		return
+	}
+	prefix := toUpperSnakeCase(e.EnumName) + "_"
+	if strings.HasPrefix(v.Ident, prefix) {
+		return
+	}
+	f := c.Report(v.Meta.Pos, "enum value name %q should be prefixed with %q", v.Ident, prefix)
+	if item := c.nameToken(v.Meta.Pos, false); item != nil {
+		f.Edits = []*Edit{{Start: item.Pos.Offset, End: item.Pos.Offset, NewText: prefix}}
+	}
+}
+
+func checkEnumZeroValue(c *Context, node parser.Visitee) {
+	v, ok := node.(*parser.EnumField)
+	if !ok {
+		return
+	}
+	if n, err := strconv.ParseInt(v.Number, 0, 64); err != nil || n != 0 {
+		return
+	}
+	if strings.HasSuffix(v.Ident, "_UNSPECIFIED") {
+		return
+	}
+	e, ok := c.Parent().(*parser.Enum)
+	if !ok {
+		return
+	}
+	want := toUpperSnakeCase(e.EnumName) + "_UNSPECIFIED"
+	f := c.Report(v.Meta.Pos, "zero value %q should be named like %q", v.Ident, want)
+	for _, body := range e.EnumBody {
+		if other, ok := body.(*parser.EnumField); ok && other.Ident == want {
+			// Renaming it would make a duplicate.
+			return
+		}
+	}
+	f.Edits = c.rename(v.Meta.Pos, false, want)
+}
+
+func checkRPCRequest(c *Context, node parser.Visitee) {
+	if r, ok := node.(*parser.RPC); ok {
+		checkRPCMessage(c, r, r.RPCRequest.MessageType, "request", "Request")
+	}
+}
+
+func checkRPCResponse(c *Context, node parser.Visitee) {
+	if r, ok := node.(*parser.RPC); ok {
+		checkRPCMessage(c, r, r.RPCResponse.MessageType, "response", "Response")
+	}
+}
+
+// checkRPCMessage checks that the name of the request or response type ends with the RPC name and suffix,
+// optionally preceded by the service name.
+func checkRPCMessage(c *Context, r *parser.RPC, messageType, kind, suffix string) {
+	name := messageType[strings.LastIndex(messageType, ".")+1:]
+	want := r.RPCName + suffix
+	if name == want {
+		return
+	}
+	if s, ok := c.Parent().(*parser.Service); ok && name == s.ServiceName+want {
+		return
+	}
+	c.Report(r.Meta.Pos, "%s %q of RPC %q should be named %q", kind, messageType, r.RPCName, want)
+}
+
+func checkFileName(c *Context, node parser.Visitee) {
+	if _, ok := node.(*parser.Proto); !ok || c.Filename() == "" {
+		return
+	}
+	base := strings.TrimSuffix(path.Base(toSlash(c.Filename())), ".proto")
+	if lowerSnakeCase.MatchString(base) {
+		return
+	}
+	pos := meta.Position{Filename: c.Filename(), Line: 1, Column: 1}
+	c.Report(pos, "file name %q should be lower_snake_case like %q", base+".proto", toLowerSnakeCase(base)+".proto")
+}
+
+func checkPackageDirectory(c *Context, node parser.Visitee) {
+	p, ok := node.(*parser.Package)
+	if !ok || c.Filename() == "" {
+		return
+	}
+	want := strings.ReplaceAll(p.Name, ".", "/")
+	dir := path.Dir(toSlash(c.Filename()))
+	if dir == want || strings.HasSuffix(dir, "/"+want) {
+		return
+	}
+	c.Report(p.Meta.Pos, "package %q should be in a directory ending with %q, but the file is in %q", p.Name, want, dir)
+}
+
+func checkServiceComment(c *Context, node parser.Visitee) {
+	s, ok := node.(*parser.Service)
+	if !ok || doc.Of(s).Text() != "" {
+		return
+	}
+	c.Report(s.Meta.Pos, "service %q should have a comment", s.ServiceName)
+}
+
+func toSlash(filename string) string {
+	return strings.ReplaceAll(filename, `\`, "/")
+}
+
+// words splits the name at the underscores and at the case changes, like "HTTPServer_id" into
+// "HTTP", "Server" and "id".
+func words(name string) []string {
+	var words []string
+	for _, part := range strings.Split(name, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			lowerToUpper := !unicode.IsUpper(prev) && unicode.IsUpper(cur)
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, string(runes[start:]))
+		}
+	}
+	return words
+}
+
+func toUpperCamelCase(name string) string {
+	var b strings.Builder
+	for _, w := range words(name) {
+		w = strings.ToLower(w)
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+func toLowerSnakeCase(name string) string {
+	return strings.ToLower(strings.Join(words(name), "_"))
+}
+
+func toUpperSnakeCase(name string) string {
+	return strings.ToUpper(strings.Join(words(name), "_"))
+}
+
+// checkImportsSorted checks that the imports are sorted. The fix sorts them if they are consecutive,
+// moving the comments together.
+func checkImportsSorted(c *Context, node parser.Visitee) {
+	p, ok := node.(*parser.Proto)
+	if !ok {
+		return
+	}
+	var imports []*parser.Import
+	consecutive := true
+	last := -1
+	for i, v := range p.ProtoBody {
+		if n, ok := v.(*parser.Import); ok {
+			if 0 <= last && last != i-1 {
+				consecutive = false
+			}
+			imports = append(imports, n)
+			last = i
+		}
+	}
+	sorted := append([]*parser.Import(nil), imports...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Location < sorted[j].Location
+	})
+	first := -1
+	for i := range imports {
+		if imports[i] != sorted[i] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return
+	}
+	f := c.Report(imports[first].Meta.Pos, "import %s should be before %s", sorted[first].Location, imports[first].Location)
+	if c.source == nil || !consecutive {
+		return
+	}
+
+	// The text of an import spans its comments and the statement, and the texts between them are kept.
+	texts := make(map[*parser.Import]string)
+	var starts, ends []int
+	for _, imp := range imports {
+		start := imp.Meta.Pos.Offset
+		if 0 < len(imp.Comments) {
+			start = imp.Comments[0].Meta.Pos.Offset
+		}
+		end, ok := c.statementEnd(imp.Meta.Pos, imp.InlineComment)
+		if !ok || start < 0 || len(c.source.Src) < end || (0 < len(ends) && start < ends[len(ends)-1]) {
+			return
+		}
+		texts[imp] = string(c.source.Src[start:end])
+		starts = append(starts, start)
+		ends = append(ends, end)
+	}
+	var b strings.Builder
+	for i, imp := range sorted {
+		if 0 < i {
+			b.Write(c.source.Src[ends[i-1]:starts[i]])
+		}
+		b.WriteString(texts[imp])
+	}
+	f.Edits = []*Edit{{Start: starts[0], End: ends[len(ends)-1], NewText: b.String()}}
+}
@@ -0,0 +1,205 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// UnusedSuppression is the rule of the findings reported by WithUnusedSuppressions.
+const UnusedSuppression = "UNUSED_SUPPRESSION"
+
+const directivePrefix = "protolint:"
+
+// suppression suppresses the findings of a rule on a range of lines.
+type suppression struct {
+	// rule is empty for all rules.
+	rule        string
+	first, last int
+	// pos is the position of the directive.
+	pos  meta.Position
+	used bool
+}
+
+type suppressions []*suppression
+
+// collectSuppressions collects the suppressions of the directives in the comments of the file.
+func collectSuppressions(p *parser.Proto) suppressions {
+	var all suppressions
+	// open are the suppressions of disable directives which are not enabled yet.
+	var open []*suppression
+	add := func(c *parser.Comment, line int) {
+		for _, d := range parseDirectives(c.Raw) {
+			switch d.kind {
+			case "enable":
+				var rest []*suppression
+				for _, s := range open {
+					if d.rules[0] != "" && !contains(d.rules, s.rule) {
+						rest = append(rest, s)
+						continue
+					}
+					s.last = c.Meta.Pos.Line
+				}
+				open = rest
+			case "disable":
+				for _, rule := range d.rules {
+					s := &suppression{rule: rule, first: c.Meta.Pos.Line, last: math.MaxInt32, pos: c.Meta.Pos}
+					all = append(all, s)
+					open = append(open, s)
+				}
+			case "disable:next-line", "disable:this":
+				for _, rule := range d.rules {
+					all = append(all, &suppression{rule: rule, first: line, last: line, pos: c.Meta.Pos})
+				}
+			}
+		}
+	}
+
+	walk(p, nil, func(node parser.Visitee, _ []parser.Visitee) {
+		if c, ok := node.(*parser.Comment); ok {
+			// A comment in a body is attached to no node, so that the next line follows it.
+			add(c, c.Meta.Pos.Line+1)
+			return
+		}
+		comments, inlines, pos := nodeComments(node)
+		for _, c := range comments {
+			add(c, pos.Line)
+		}
+		for _, c := range inlines {
+			if c != nil {
+				add(c, c.Meta.Pos.Line)
+			}
+		}
+	})
+	return all
+}
+
+// filter returns the findings which are not suppressed.
+func (ss suppressions) filter(findings []*Finding) []*Finding {
+	var rest []*Finding
+	for _, f := range findings {
+		suppressed := false
+		for _, s := range ss {
+			if (s.rule == "" || s.rule == f.Rule) && s.first <= f.Pos.Line && f.Pos.Line <= s.last {
+				s.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			rest = append(rest, f)
+		}
+	}
+	return rest
+}
+
+// unused returns the findings of the suppressions which suppressed nothing. A suppression of a rule
+// which is registered but not enabled is not reported.
+func (ss suppressions) unused(l *Linter) []*Finding {
+	var findings []*Finding
+	for _, s := range ss {
+		if s.used {
+			continue
+		}
+		if _, ok := l.registry.Lookup(s.rule); ok && !l.isEnabled(s.rule) {
+			continue
+		}
+		message := "suppression of all rules matches no finding"
+		if s.rule != "" {
+			message = fmt.Sprintf("suppression of %q matches no finding", s.rule)
+		}
+		findings = append(findings, &Finding{
+			Rule:     UnusedSuppression,
+			Severity: l.severity(UnusedSuppression),
+			Message:  message,
+			Pos:      s.pos,
+		})
+	}
+	return findings
+}
+
+type directive struct {
+	// kind is "disable", "enable", "disable:next-line" or "disable:this".
+	kind string
+	// rules is [""] for all rules.
+	rules []string
+}
+
+// parseDirectives returns the directives in the lines of the raw comment.
+func parseDirectives(raw string) []*directive {
+	text := strings.TrimPrefix(raw, "//")
+	if strings.HasPrefix(raw, "/*") {
+		text = strings.TrimSuffix(strings.TrimPrefix(raw, "/*"), "*/")
+	}
+
+	var directives []*directive
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "* ")
+		if !strings.HasPrefix(line, directivePrefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, directivePrefix))
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "disable", "enable", "disable:next-line", "disable:this":
+		default:
+			continue
+		}
+		d := &directive{kind: fields[0], rules: fields[1:]}
+		if len(d.rules) == 0 {
+			d.rules = []string{""}
+		}
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+// nodeComments returns the leading comments, the inline comments and the position of the node.
+func nodeComments(node parser.Visitee) ([]*parser.Comment, []*parser.Comment, meta.Position) {
+	switch n := node.(type) {
+	case *parser.Syntax:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Package:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Import:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Option:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Message:
+		return n.Comments, []*parser.Comment{n.InlineCommentBehindLeftCurly, n.InlineComment}, n.Meta.Pos
+	case *parser.Field:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.MapField:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Oneof:
+		return n.Comments, []*parser.Comment{n.InlineCommentBehindLeftCurly, n.InlineComment}, n.Meta.Pos
+	case *parser.OneofField:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Enum:
+		return n.Comments, []*parser.Comment{n.InlineCommentBehindLeftCurly, n.InlineComment}, n.Meta.Pos
+	case *parser.EnumField:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Reserved:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	case *parser.Extend:
+		return n.Comments, []*parser.Comment{n.InlineCommentBehindLeftCurly, n.InlineComment}, n.Meta.Pos
+	case *parser.Service:
+		return n.Comments, []*parser.Comment{n.InlineCommentBehindLeftCurly, n.InlineComment}, n.Meta.Pos
+	case *parser.RPC:
+		return n.Comments, []*parser.Comment{n.InlineComment}, n.Meta.Pos
+	}
+	return nil, nil, meta.Position{}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
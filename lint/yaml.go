@@ -0,0 +1,224 @@
+package lint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type yamlLine struct {
+	// number is 1-based.
+	number int
+	indent int
+	text   string
+}
+
+// yamlParser parses the subset of YAML used by the config files: block mappings and sequences nested
+// by indentation, flow sequences like [a, b], plain and quoted scalars, and # comments.
+type yamlParser struct {
+	lines []*yamlLine
+	i     int
+}
+
+// parseYAML returns the document as nested map[string]interface{}, []interface{} and scalars,
+// which encoding/json can marshal.
+func parseYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: found a tab but expected spaces for the indentation", i+1)
+		}
+		p.lines = append(p.lines, &yamlLine{number: i + 1, indent: len(line) - len(text), text: text})
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	v, err := p.block(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.lines) {
+		line := p.lines[p.i]
+		return nil, fmt.Errorf("line %d: found %q but expected no more indentation", line.number, line.text)
+	}
+	return v, nil
+}
+
+// stripYAMLComment removes the comment which starts with # at the beginning or after a space,
+// out of quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block parses a mapping or a sequence whose lines are indented by indent.
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	if isSequenceItem(p.lines[p.i].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isSequenceItem(p.lines[p.i].text) {
+		line := p.lines[p.i]
+		p.i++
+		text := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if text != "" {
+			v, err := yamlScalar(line.number, text)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			continue
+		}
+		v, err := p.nested(indent, false)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent {
+		line := p.lines[p.i]
+		if isSequenceItem(line.text) {
+			return nil, fmt.Errorf("line %d: found %q but expected a key", line.number, line.text)
+		}
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: found %q but expected a key followed by a colon", line.number, line.text)
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("line %d: found a duplicate key %q", line.number, key)
+		}
+		p.i++
+		if rest != "" {
+			v, err := yamlScalar(line.number, rest)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			continue
+		}
+		v, err := p.nested(indent, true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// nested parses the block following a key or a sequence item at indent. The items of a sequence
+// can have the same indentation as the key. It returns nil if there is no block.
+func (p *yamlParser) nested(indent int, key bool) (interface{}, error) {
+	if len(p.lines) <= p.i {
+		return nil, nil
+	}
+	next := p.lines[p.i]
+	if indent < next.indent {
+		return p.block(next.indent)
+	}
+	if key && next.indent == indent && isSequenceItem(next.text) {
+		return p.sequence(indent)
+	}
+	return nil, nil
+}
+
+// splitYAMLKey splits "key: value" or "key:" into the unquoted key and the value.
+func splitYAMLKey(text string) (string, string, bool) {
+	var key, rest string
+	if i := strings.Index(text, ": "); 0 <= i {
+		key, rest = text[:i], strings.TrimSpace(text[i+2:])
+	} else if strings.HasSuffix(text, ":") {
+		key = text[:len(text)-1]
+	} else {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if unquoted, err := unquoteYAML(key); err == nil {
+		key = unquoted
+	}
+	return key, rest, key != ""
+}
+
+// yamlScalar parses a scalar or a flow sequence of scalars.
+func yamlScalar(number int, text string) (interface{}, error) {
+	if strings.HasPrefix(text, "[") {
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("line %d: found %q but expected a closing ]", number, text)
+		}
+		items := []interface{}{}
+		inner := strings.TrimSpace(text[1 : len(text)-1])
+		if inner == "" {
+			return items, nil
+		}
+		for _, item := range strings.Split(inner, ",") {
+			v, err := yamlScalar(number, strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	}
+	if strings.HasPrefix(text, "{") {
+		return nil, fmt.Errorf("line %d: found %q but flow mappings are not supported", number, text)
+	}
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		s, err := unquoteYAML(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: found %q but expected a quoted string", number, text)
+		}
+		return s, nil
+	}
+	switch text {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return text, nil
+}
+
+// unquoteYAML unquotes a double-quoted string with escapes, or a single-quoted string with doubled single quotes.
+func unquoteYAML(text string) (string, error) {
+	if 2 <= len(text) && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		return strconv.Unquote(text)
+	}
+	return "", fmt.Errorf("found %q but expected a quoted string", text)
+}
@@ -0,0 +1,397 @@
+// Package openapi generates OpenAPI 3.1 documents from the google.api.http bindings of linked services.
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/doc"
+	"github.com/thought-machine/go-protoparser/jsonschema"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// Version is the OpenAPI version of the generated documents.
+// OpenAPI 3.1 uses JSON Schema draft 2020-12, which jsonschema generates.
+const Version = "3.1.0"
+
+// Document is an OpenAPI document, which is marshaled with encoding/json.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       *Info                `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem is the operations on a path.
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+}
+
+// Operation is an operation of an RPC.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string            `json:"name"`
+	In          string            `json:"in"`
+	Description string            `json:"description,omitempty"`
+	Required    bool              `json:"required,omitempty"`
+	Schema      jsonschema.Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the content of a media type.
+type MediaType struct {
+	Schema jsonschema.Schema `json:"schema"`
+}
+
+// Components are the schemas of the messages and the enums by full name.
+type Components struct {
+	Schemas jsonschema.Defs `json:"schemas,omitempty"`
+}
+
+// Generator generates the documents.
+type Generator struct {
+	title      string
+	version    string
+	protoNames bool
+	json       *jsonschema.Generator
+}
+
+// Option is an option for NewGenerator.
+type Option func(*Generator)
+
+// WithTitle is an option to set the title of the API. The default is "API".
+func WithTitle(title string) Option {
+	return func(g *Generator) {
+		g.title = title
+	}
+}
+
+// WithVersion is an option to set the version of the API. The default is "1.0.0".
+func WithVersion(version string) Option {
+	return func(g *Generator) {
+		g.version = version
+	}
+}
+
+// WithProtoNames is an option to use the field names as written rather than the lowerCamelCase JSON names
+// in the schemas and the query parameters.
+func WithProtoNames(protoNames bool) Option {
+	return func(g *Generator) {
+		g.protoNames = protoNames
+	}
+}
+
+// NewGenerator creates a new Generator.
+func NewGenerator(options ...Option) *Generator {
+	g := &Generator{
+		title:   "API",
+		version: "1.0.0",
+	}
+	for _, opt := range options {
+		opt(g)
+	}
+	g.json = jsonschema.NewGenerator(
+		jsonschema.WithRefPrefix("#/components/schemas/"),
+		jsonschema.WithProtoNames(g.protoNames),
+	)
+	return g
+}
+
+// Generate generates the document of the services of the files which are not imported.
+// An RPC without the google.api.http option is not in the document.
+func (g *Generator) Generate(s *schema.Schema) (*Document, error) {
+	d := &Document{
+		OpenAPI: Version,
+		Info: &Info{
+			Title:   g.title,
+			Version: g.version,
+		},
+		Paths: make(map[string]*PathItem),
+	}
+	defs := make(jsonschema.Defs)
+	for _, f := range s.Files {
+		if f.Imported {
+			continue
+		}
+		for _, service := range f.Services {
+			for _, rpc := range service.RPCs {
+				if err := g.rpc(d, defs, rpc); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if 0 < len(defs) {
+		d.Components = &Components{Schemas: defs}
+	}
+	return d, nil
+}
+
+func (g *Generator) rpc(d *Document, defs jsonschema.Defs, rpc *schema.RPC) error {
+	rules := rpc.HTTPRules()
+	if len(rules) == 0 {
+		return nil
+	}
+	// A well-known type, like google.protobuf.Empty, is often not linked, but has a schema by its full name.
+	for _, t := range []*schema.Ref{rpc.Request, rpc.Response} {
+		if t.Message == nil && !jsonschema.IsWellKnown(t.FullName) {
+			return fmt.Errorf("%s: %w %q", t.Pos, schema.ErrUndefined, t.Name)
+		}
+	}
+
+	for i, rule := range rules {
+		path, vars := parsePath(rule.Path)
+		op := &Operation{
+			OperationID: rpc.Parent.Name + "_" + rpc.Name,
+			Tags:        []string{rpc.Parent.Name},
+			Responses:   make(map[string]*Response),
+		}
+		if 0 < i {
+			op.OperationID += fmt.Sprint(i + 1)
+		}
+		rpcDoc := doc.Of(rpc.Node)
+		op.Description = rpcDoc.Text()
+		op.Deprecated = rpcDoc.Has(doc.TagDeprecated)
+
+		bound := make(map[string]bool)
+		for _, v := range vars {
+			field, err := lookup(rpc.Request, v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rpc.Node.Meta.Pos, err)
+			}
+			s, err := g.json.Field(defs, field)
+			if err != nil {
+				return err
+			}
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:        v,
+				In:          "path",
+				Description: doc.Of(field.Node).Text(),
+				Required:    true,
+				Schema:      s,
+			})
+			bound[strings.Split(v, ".")[0]] = true
+		}
+
+		switch rule.Body {
+		case "":
+		case "*":
+			s, err := g.json.Type(defs, rpc.Request)
+			if err != nil {
+				return err
+			}
+			op.RequestBody = jsonBody(s)
+		default:
+			field, err := lookup(rpc.Request, rule.Body)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rpc.Node.Meta.Pos, err)
+			}
+			s, err := g.json.Field(defs, field)
+			if err != nil {
+				return err
+			}
+			op.RequestBody = jsonBody(s)
+			bound[field.Name] = true
+		}
+
+		if rule.Body != "*" && rpc.Request.Message != nil {
+			params, err := g.queryParameters(defs, rpc.Request.Message, bound)
+			if err != nil {
+				return err
+			}
+			op.Parameters = append(op.Parameters, params...)
+		}
+
+		var responseSchema jsonschema.Schema
+		if rule.ResponseBody == "" {
+			s, err := g.json.Type(defs, rpc.Response)
+			if err != nil {
+				return err
+			}
+			responseSchema = s
+		} else {
+			field, err := lookup(rpc.Response, rule.ResponseBody)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rpc.Node.Meta.Pos, err)
+			}
+			s, err := g.json.Field(defs, field)
+			if err != nil {
+				return err
+			}
+			responseSchema = s
+		}
+		description := "A successful response."
+		if rpc.ResponseStream {
+			description = "A stream of successful responses."
+		}
+		op.Responses["200"] = &Response{
+			Description: description,
+			Content: map[string]*MediaType{
+				"application/json": {Schema: responseSchema},
+			},
+		}
+
+		item, ok := d.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			d.Paths[path] = item
+		}
+		if err := item.set(rule.Method, op); err != nil {
+			return fmt.Errorf("%s: %w", rpc.Node.Meta.Pos, err)
+		}
+	}
+	return nil
+}
+
+// queryParameters returns the query parameters of the request fields which are not bound to the path or the body.
+// Only the fields of scalar or enum types are parameters, and a repeated one can be given multiple times.
+func (g *Generator) queryParameters(defs jsonschema.Defs, request *schema.Message, bound map[string]bool) ([]*Parameter, error) {
+	var params []*Parameter
+	for _, field := range request.Fields {
+		if bound[field.Name] || field.IsMap() || field.Type.Message != nil || !field.Type.Resolved() {
+			continue
+		}
+		s, err := g.json.Field(defs, field)
+		if err != nil {
+			return nil, err
+		}
+		name := field.JSONName
+		if g.protoNames {
+			name = field.Name
+		}
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: doc.Of(field.Node).Text(),
+			Schema:      s,
+		})
+	}
+	return params, nil
+}
+
+func jsonBody(s jsonschema.Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content: map[string]*MediaType{
+			"application/json": {Schema: s},
+		},
+	}
+}
+
+// set sets the operation of the method, which fails if it is already set or the method is unsupported.
+func (item *PathItem) set(method string, op *Operation) error {
+	var target **Operation
+	switch method {
+	case "GET":
+		target = &item.Get
+	case "PUT":
+		target = &item.Put
+	case "POST":
+		target = &item.Post
+	case "DELETE":
+		target = &item.Delete
+	case "OPTIONS":
+		target = &item.Options
+	case "HEAD":
+		target = &item.Head
+	case "PATCH":
+		target = &item.Patch
+	default:
+		return fmt.Errorf("unsupported HTTP method %q", method)
+	}
+	if *target != nil {
+		return fmt.Errorf("found the duplicate operation %s of %q but expected one", method, (*target).OperationID)
+	}
+	*target = op
+	return nil
+}
+
+// lookup finds the field of the dotted path of field names, like "book.name", in the message of the type.
+func lookup(t *schema.Ref, path string) (*schema.Field, error) {
+	m := t.Message
+	var field *schema.Field
+	for _, name := range strings.Split(path, ".") {
+		switch {
+		case field == nil && m == nil:
+			// The well-known type is not linked.
+			return nil, fmt.Errorf("found %q but expected a field of %s", name, t.FullName)
+		case m == nil:
+			return nil, fmt.Errorf("found %q but expected a message field", field.Name)
+		}
+		field = nil
+		for _, f := range m.Fields {
+			if f.Name == name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil, fmt.Errorf("found %q but expected a field of %s", name, m.FullName)
+		}
+		m = field.Type.Message
+	}
+	return field, nil
+}
+
+// parsePath converts the path template of an HTTP rule into an OpenAPI path and returns the variables.
+// For example, "/v1/{name=shelves/*}:get" becomes "/v1/{name}:get" with the variable "name".
+func parsePath(template string) (string, []string) {
+	var b strings.Builder
+	var vars []string
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			b.WriteString(template)
+			return b.String(), vars
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			b.WriteString(template)
+			return b.String(), vars
+		}
+		end += start
+
+		v := template[start+1 : end]
+		if i := strings.IndexByte(v, '='); 0 <= i {
+			v = v[:i]
+		}
+		v = strings.TrimSpace(v)
+		vars = append(vars, v)
+		b.WriteString(template[:start])
+		b.WriteString("{" + v + "}")
+		template = template[end+1:]
+	}
+}
@@ -0,0 +1,257 @@
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/thought-machine/go-protoparser/openapi"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+func load(t *testing.T, src string) *schema.Schema {
+	s, _ := schema.Load(context.Background(), fstest.MapFS{"library.proto": {Data: []byte(src)}}, []string{"library.proto"})
+	if s == nil {
+		t.Fatalf("got nil, but want the schema")
+	}
+	return s
+}
+
+const library = `syntax = "proto3";
+package library;
+
+service Library {
+  // GetBook returns a book.
+  rpc GetBook(GetBookRequest) returns (Book) {
+    option (google.api.http) = {
+      get: "/v1/{name=shelves/*/books/*}"
+      additional_bindings {
+        get: "/v1/books/{name}"
+      }
+    };
+  }
+  rpc UpdateBook(UpdateBookRequest) returns (Book) {
+    option (google.api.http) = {
+      patch: "/v1/{book.name=shelves/*/books/*}"
+      body: "book"
+    };
+  }
+  rpc ListBooks(ListBooksRequest) returns (stream ListBooksResponse) {
+    option (google.api.http) = {
+      post: "/v1/books:list"
+      body: "*"
+      response_body: "books"
+    };
+  }
+  rpc Internal(GetBookRequest) returns (Book);
+  rpc DeleteBook(GetBookRequest) returns (google.protobuf.Empty) {
+    option (google.api.http) = { delete: "/v1/{name=shelves/*/books/*}" };
+  }
+  rpc Ping(google.protobuf.Empty) returns (google.protobuf.Empty) {
+    option (google.api.http) = { post: "/v1:ping" body: "*" };
+  }
+}
+
+message GetBookRequest {
+  // The name of the book.
+  string name = 1;
+  int32 page_size = 2;
+  Book filter = 3;
+}
+
+// A book.
+message Book {
+  string name = 1;
+}
+
+message UpdateBookRequest {
+  Book book = 1;
+  repeated string update_mask = 2;
+}
+
+message ListBooksRequest {
+  string parent = 1;
+}
+
+message ListBooksResponse {
+  repeated Book books = 1;
+}
+`
+
+func TestGenerator_Generate(t *testing.T) {
+	bookRef := `{"$ref": "#/components/schemas/library.Book"}`
+	nameParam := `{"name": "name", "in": "path", "description": "The name of the book.", "required": true, "schema": {"type": "string", "description": "The name of the book."}}`
+	pageSizeParam := `{"name": "pageSize", "in": "query", "schema": {"type": "integer", "minimum": -2147483648, "maximum": 2147483647}}`
+	// google.protobuf.Empty is not linked, but has the schema of the well-known type.
+	empty := `{"type": "object", "maxProperties": 0}`
+	ok := func(description string, schema string) string {
+		return `{"200": {"description": "` + description + `", "content": {"application/json": {"schema": ` + schema + `}}}}`
+	}
+	want := `{
+		"openapi": "3.1.0",
+		"info": {"title": "Library API", "version": "v1"},
+		"paths": {
+			"/v1/{name}": {
+				"get": {
+					"operationId": "Library_GetBook",
+					"tags": ["Library"],
+					"description": "GetBook returns a book.",
+					"parameters": [` + nameParam + `, ` + pageSizeParam + `],
+					"responses": ` + ok("A successful response.", bookRef) + `
+				},
+				"delete": {
+					"operationId": "Library_DeleteBook",
+					"tags": ["Library"],
+					"parameters": [` + nameParam + `, ` + pageSizeParam + `],
+					"responses": ` + ok("A successful response.", empty) + `
+				}
+			},
+			"/v1/books/{name}": {
+				"get": {
+					"operationId": "Library_GetBook2",
+					"tags": ["Library"],
+					"description": "GetBook returns a book.",
+					"parameters": [` + nameParam + `, ` + pageSizeParam + `],
+					"responses": ` + ok("A successful response.", bookRef) + `
+				}
+			},
+			"/v1/{book.name}": {
+				"patch": {
+					"operationId": "Library_UpdateBook",
+					"tags": ["Library"],
+					"parameters": [
+						{"name": "book.name", "in": "path", "required": true, "schema": {"type": "string"}},
+						{"name": "updateMask", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}}
+					],
+					"requestBody": {"required": true, "content": {"application/json": {"schema": ` + bookRef + `}}},
+					"responses": ` + ok("A successful response.", bookRef) + `
+				}
+			},
+			"/v1/books:list": {
+				"post": {
+					"operationId": "Library_ListBooks",
+					"tags": ["Library"],
+					"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/library.ListBooksRequest"}}}},
+					"responses": ` + ok("A stream of successful responses.", `{"type": "array", "items": `+bookRef+`}`) + `
+				}
+			},
+			"/v1:ping": {
+				"post": {
+					"operationId": "Library_Ping",
+					"tags": ["Library"],
+					"requestBody": {"required": true, "content": {"application/json": {"schema": ` + empty + `}}},
+					"responses": ` + ok("A successful response.", empty) + `
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"library.Book": {
+					"type": "object",
+					"description": "A book.",
+					"additionalProperties": false,
+					"properties": {"name": {"type": "string"}}
+				},
+				"library.ListBooksRequest": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"parent": {"type": "string"}}
+				}
+			}
+		}
+	}`
+
+	g := openapi.NewGenerator(openapi.WithTitle("Library API"), openapi.WithVersion("v1"))
+	got, err := g.Generate(load(t, library))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(gotJSON, &gotValue); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %s, but want %s", gotJSON, want)
+	}
+}
+
+func TestGenerator_Generate_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "binding an unknown field",
+			input: `syntax = "proto3";
+service S {
+  rpc Get(M) returns (M) {
+    option (google.api.http) = { get: "/v1/{missing}" };
+  }
+}
+message M {}
+`,
+			wantErr: `found "missing" but expected a field of M`,
+		},
+		{
+			name: "binding the same operation twice",
+			input: `syntax = "proto3";
+service S {
+  rpc Get(M) returns (M) {
+    option (google.api.http) = { get: "/v1/m" };
+  }
+  rpc List(M) returns (M) {
+    option (google.api.http) = { get: "/v1/m" };
+  }
+}
+message M {}
+`,
+			wantErr: `found the duplicate operation GET of "S_Get" but expected one`,
+		},
+		{
+			name: "referring to an undefined request",
+			input: `syntax = "proto3";
+service S {
+  rpc Get(Missing) returns (M) {
+    option (google.api.http) = { get: "/v1/m" };
+  }
+}
+message M {}
+`,
+			wantErr: `undefined type "Missing"`,
+		},
+		{
+			name: "binding a field of a well-known type which is not linked",
+			input: `syntax = "proto3";
+service S {
+  rpc Get(google.protobuf.Empty) returns (M) {
+    option (google.api.http) = { get: "/v1/{name}" };
+  }
+}
+message M {}
+`,
+			wantErr: `found "name" but expected a field of google.protobuf.Empty`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := openapi.NewGenerator().Generate(load(t, test.input))
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got %v, but want %v", err, test.wantErr)
+			}
+		})
+	}
+}
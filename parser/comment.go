@@ -50,24 +50,26 @@ func (c *Comment) Accept(v Visitor) {
 func (p *Parser) ParseComments() []*Comment {
 	var comments []*Comment
 	for {
-		comment, err := p.parseComment()
-		if err != nil {
-			// ignores the err because the comment is optional.
+		comment := p.parseComment()
+		if comment == nil {
+			// the comment is optional.
 			return comments
 		}
 		comments = append(comments, comment)
 	}
 }
 
+// parseComment returns nil without consuming the token if the next one is not a comment.
+// It doesn't build an error because a comment is always optional and absent in most places.
 // See https://developers.google.com/protocol-buffers/docs/proto3#adding-comments
-func (p *Parser) parseComment() (*Comment, error) {
+func (p *Parser) parseComment() *Comment {
 	p.lex.NextComment()
 	if p.lex.Token == scanner.TCOMMENT {
 		return &Comment{
 			Raw:  p.lex.Text,
 			Meta: meta.NewMeta(p.lex.Pos),
-		}, nil
+		}
 	}
-	defer p.lex.UnNext()
-	return nil, p.unexpected("comment")
+	p.lex.UnNext()
+	return nil
 }
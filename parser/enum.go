@@ -20,6 +20,11 @@ func (e *parseEnumBodyStatementErr) Error() string {
 	)
 }
 
+// Unwrap returns the errors of the alternatives.
+func (e *parseEnumBodyStatementErr) Unwrap() []error {
+	return []error{e.parseEnumFieldErr, e.parseEmptyStatementErr}
+}
+
 // EnumValueOption is an option of a enumField.
 type EnumValueOption struct {
 	OptionName string
@@ -298,24 +303,21 @@ func (p *Parser) parseEnumValueOption() (*EnumValueOption, error) {
 		return nil, p.unexpected("=")
 	}
 
+	var constant string
 	switch p.lex.Peek() {
 	case scanner.TLEFTCURLY:
-		_, opsErr := p.parseGoProtoValidatorFieldOptionConstant()
-		if opsErr != nil {
-			return nil, opsErr
+		constant, err = p.parseGoProtoValidatorFieldOptionConstant()
+		if err != nil {
+			return nil, err
 		}
 	default:
-		constant, _, err := p.lex.ReadConstant(p.permissive)
+		constant, _, err = p.lex.ReadConstant(p.permissive)
 		if err != nil {
 			return nil, err
 		}
-		return &EnumValueOption{
-			OptionName: optionName,
-			Constant:   constant,
-		}, nil
 	}
 	return &EnumValueOption{
 		OptionName: optionName,
-		Constant:   "",
+		Constant:   constant,
 	}, nil
 }
@@ -2,7 +2,6 @@ package parser_test
 
 import (
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -492,6 +491,7 @@ func TestParser_ParseEnum(t *testing.T) {
 						EnumValueOptions: []*parser.EnumValueOption{
 							{
 								OptionName: "(restriction_type_descriptor)",
+								Constant:   `{required_parameters:["Hello" "World"],}`,
 							},
 						},
 						Meta: meta.Meta{
@@ -528,7 +528,7 @@ func TestParser_ParseEnum(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			p := parser.NewParser(
-				lexer.NewLexer(strings.NewReader(test.input)),
+				lexer.NewLexer([]byte(test.input)),
 				parser.WithBodyIncludingComments(test.inputBodyIncludingComments),
 			)
 			got, err := p.ParseEnum()
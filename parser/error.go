@@ -3,10 +3,33 @@ package parser
 import (
 	"fmt"
 	"runtime"
+
+	"github.com/thought-machine/go-protoparser/parser/meta"
 )
 
+// UnexpectedError is the error of a token which the parser does not expect.
+type UnexpectedError struct {
+	// Found is the text of the found token.
+	Found string
+	// Expected describes the expected token, like ";".
+	Expected string
+	// Pos is the position of the found token.
+	Pos meta.Position
+
+	msg string
+}
+
+func (e *UnexpectedError) Error() string {
+	return e.msg
+}
+
 func (p *Parser) unexpected(expected string) error {
 	_, file, line, _ := runtime.Caller(1)
 	msg := fmt.Sprintf(" at %s:%d", file, line)
-	return fmt.Errorf("found %q(Token=%v, Pos=%s) but expected [%s]%s", p.lex.Text, p.lex.Token, p.lex.Pos, expected, msg)
+	return &UnexpectedError{
+		Found:    p.lex.Text,
+		Expected: expected,
+		Pos:      meta.NewPosition(p.lex.Pos),
+		msg:      fmt.Sprintf("found %q(Token=%v, Pos=%s) but expected [%s]%s", p.lex.Text, p.lex.Token, p.lex.Pos, expected, msg),
+	}
 }
@@ -20,6 +20,11 @@ func (e *parseExtendBodyStatementErr) Error() string {
 	)
 }
 
+// Unwrap returns the errors of the alternatives.
+func (e *parseExtendBodyStatementErr) Unwrap() []error {
+	return []error{e.parseFieldErr, e.parseEmptyStatementErr}
+}
+
 // Extend consists of a messageType and an extend body.
 type Extend struct {
 	MessageType string
@@ -2,7 +2,6 @@ package parser_test
 
 import (
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/parser/meta"
@@ -116,7 +115,7 @@ extend google.protobuf.MethodOptions {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			p := parser.NewParser(
-				lexer.NewLexer(strings.NewReader(test.input)),
+				lexer.NewLexer([]byte(test.input)),
 				parser.WithBodyIncludingComments(test.inputBodyIncludingComments),
 			)
 			got, err := p.ParseExtend()
@@ -195,6 +195,10 @@ func (p *Parser) parseGoProtoValidatorFieldOptionConstant() (string, error) {
 	if p.lex.Token != scanner.TLEFTCURLY {
 		return "", p.unexpected("{")
 	}
+	if err := p.enter(); err != nil {
+		return "", err
+	}
+	defer p.leave()
 	ret += p.lex.Text
 
 	for {
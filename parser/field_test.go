@@ -2,7 +2,6 @@ package parser_test
 
 import (
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -177,7 +176,7 @@ func TestParser_ParseField(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			p := parser.NewParser(lexer.NewLexer(strings.NewReader(test.input)), parser.WithPermissive(test.permissive))
+			p := parser.NewParser(lexer.NewLexer([]byte(test.input)), parser.WithPermissive(test.permissive))
 			got, err := p.ParseField()
 			switch {
 			case test.wantErr:
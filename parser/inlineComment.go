@@ -19,8 +19,8 @@ func (p *Parser) MaybeScanInlineComment(
 func (p *Parser) parseInlineComment() *Comment {
 	currentPos := p.lex.Pos
 
-	comment, err := p.parseComment()
-	if err != nil {
+	comment := p.parseComment()
+	if comment == nil {
 		return nil
 	}
 
@@ -2,7 +2,6 @@ package parser_test
 
 import (
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -113,7 +112,7 @@ func TestParser_MaybeScanInlineComment(t *testing.T) {
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			p := parser.NewParser(lexer.NewLexer(strings.NewReader(test.input)))
+			p := parser.NewParser(lexer.NewLexer([]byte(test.input)))
 			_, _ = p.ParseField()
 
 			hasSetter := &mockHasInlineCommentSetter{}
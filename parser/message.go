@@ -20,6 +20,11 @@ func (e *parseMessageBodyStatementErr) Error() string {
 	)
 }
 
+// Unwrap returns the errors of the alternatives.
+func (e *parseMessageBodyStatementErr) Unwrap() []error {
+	return []error{e.parseFieldErr, e.parseEmptyStatementErr}
+}
+
 // Message consists of a message name and a message body.
 type Message struct {
 	MessageName string
@@ -79,6 +84,11 @@ func (p *Parser) ParseMessage() (*Message, error) {
 	}
 	messageName := p.lex.Text
 
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
 	messageBody, inlineLeftCurly, lastPos, err := p.parseMessageBody()
 	if err != nil {
 		return nil, err
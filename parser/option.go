@@ -114,17 +114,26 @@ func (p *Parser) parseCloudEndpointsOptionConstant() (*CloudEndpoint, error) {
 	if p.lex.Token != scanner.TLEFTCURLY {
 		return nil, p.unexpected("{")
 	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
 	var endpointFields []*EndpointFieldOption
 	var addBinding []*AdditionalBinding
 
+	if p.lex.Peek() == scanner.TRIGHTCURLY {
+		p.lex.Next()
+		return &CloudEndpoint{}, nil
+	}
+
 	for {
 		p.lex.NextKeyword()
 		if p.lex.Token == scanner.TADDITIONAL {
-			var addErr error
-			addBinding, addErr = p.ParseAdditionalBindings()
+			binding, addErr := p.ParseAdditionalBindings()
 			if addErr != nil {
 				return nil, addErr
 			}
+			addBinding = append(addBinding, binding)
 		} else {
 			p.lex.UnNext()
 			p.lex.Next()
@@ -165,6 +174,13 @@ func (p *Parser) parseCloudEndpointsOptionConstant() (*CloudEndpoint, error) {
 		p.lex.Next()
 		switch {
 		case p.lex.Token == scanner.TCOMMA:
+			if p.lex.Peek() == scanner.TRIGHTCURLY {
+				p.lex.Next()
+				return &CloudEndpoint{
+					Fields:            endpointFields,
+					AdditionalBinding: addBinding,
+				}, nil
+			}
 		case p.lex.Token == scanner.TRIGHTCURLY:
 			return &CloudEndpoint{
 				Fields:            endpointFields,
@@ -227,22 +243,35 @@ func (p *Parser) parseOptionName() (string, error) {
 	return optionName, nil
 }
 
-// AdditionalBinding store additional binding field details
+// AdditionalBinding is an additional_bindings block of a google.api.http option.
 type AdditionalBinding struct {
-	name   string
-	values []string
+	// Fields are the fields of the block in order.
+	Fields []*AdditionalBindingField
+}
+
+// AdditionalBindingField is a field of an additional_bindings block.
+type AdditionalBindingField struct {
+	// Name is the field name, like "get" or "body".
+	Name string
+	// Values are the constant and the string literals following it.
+	Values []string
 }
 
 // ParseAdditionalBindings parses a block describing additional bindings
-func (p *Parser) ParseAdditionalBindings() ([]*AdditionalBinding, error) {
+//  additionalBindings = "{" { ident ":" constant { strLit } [","] } "}"
+func (p *Parser) ParseAdditionalBindings() (*AdditionalBinding, error) {
 	p.lex.Next()
 	if p.lex.Token != scanner.TLEFTCURLY {
 		return nil, p.unexpected("{")
 	}
 
-	var bindings []*AdditionalBinding
-
+	binding := &AdditionalBinding{}
 	for {
+		if p.lex.Peek() == scanner.TRIGHTCURLY {
+			p.lex.Next()
+			return binding, nil
+		}
+
 		ident, _, identErr := p.lex.ReadFullIdent()
 
 		if identErr != nil {
@@ -274,15 +303,13 @@ func (p *Parser) ParseAdditionalBindings() ([]*AdditionalBinding, error) {
 			values = append(values, p.lex.Text)
 		}
 
-		bindings = append(bindings, &AdditionalBinding{
-			name:   ident,
-			values: values,
+		binding.Fields = append(binding.Fields, &AdditionalBindingField{
+			Name:   ident,
+			Values: values,
 		})
 
-		if p.lex.Peek() == scanner.TRIGHTCURLY {
+		if p.lex.Peek() == scanner.TCOMMA {
 			p.lex.Next()
-			break
 		}
 	}
-	return nil, nil
 }
@@ -2,7 +2,6 @@ package parser_test
 
 import (
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -315,12 +314,115 @@ option (release.service) = {
 				},
 			},
 		},
+		{
+			name:       "parsing a nested option literal with a trailing comma and an empty one",
+			input:      `option (o) = {a: {b: 1,}, c: {}};`,
+			permissive: true,
+			wantOption: &parser.Option{
+				OptionName: "(o)",
+				Endpoint: &parser.CloudEndpoint{
+					Fields: []*parser.EndpointFieldOption{
+						{
+							OptionName: "a",
+							Constant:   "{b:1,}",
+							Meta: meta.Meta{
+								Pos: meta.Position{
+									Offset: 14,
+									Line:   1,
+									Column: 15,
+								},
+							},
+						},
+						{
+							OptionName: "c",
+							Constant:   "{}",
+							Meta: meta.Meta{
+								Pos: meta.Position{
+									Offset: 26,
+									Line:   1,
+									Column: 27,
+								},
+							},
+						},
+					},
+				},
+				Meta: meta.Meta{
+					Pos: meta.Position{
+						Offset: 0,
+						Line:   1,
+						Column: 1,
+					},
+					LastPos: meta.Position{
+						Offset: 32,
+						Line:   1,
+						Column: 33,
+					},
+				},
+			},
+		},
+		{
+			name:       "parsing additional_bindings",
+			input:      `option (google.api.http) = {get: "/v1/a" additional_bindings {get: "/v1/b"} additional_bindings {post: "/v1/c" body: "*"}};`,
+			permissive: true,
+			wantOption: &parser.Option{
+				OptionName: "(google.api.http)",
+				Endpoint: &parser.CloudEndpoint{
+					Fields: []*parser.EndpointFieldOption{
+						{
+							OptionName: "get",
+							Constant:   `"/v1/a"`,
+							Meta: meta.Meta{
+								Pos: meta.Position{
+									Offset: 28,
+									Line:   1,
+									Column: 29,
+								},
+							},
+						},
+					},
+					AdditionalBinding: []*parser.AdditionalBinding{
+						{
+							Fields: []*parser.AdditionalBindingField{
+								{
+									Name:   "get",
+									Values: []string{`"/v1/b"`},
+								},
+							},
+						},
+						{
+							Fields: []*parser.AdditionalBindingField{
+								{
+									Name:   "post",
+									Values: []string{`"/v1/c"`},
+								},
+								{
+									Name:   "body",
+									Values: []string{`"*"`},
+								},
+							},
+						},
+					},
+				},
+				Meta: meta.Meta{
+					Pos: meta.Position{
+						Offset: 0,
+						Line:   1,
+						Column: 1,
+					},
+					LastPos: meta.Position{
+						Offset: 122,
+						Line:   1,
+						Column: 123,
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			p := parser.NewParser(lexer.NewLexer(strings.NewReader(test.input)), parser.WithPermissive(test.permissive))
+			p := parser.NewParser(lexer.NewLexer([]byte(test.input)), parser.WithPermissive(test.permissive))
 			got, err := p.ParseOption()
 			switch {
 			case test.wantErr:
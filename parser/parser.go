@@ -8,6 +8,8 @@ type Parser struct {
 
 	permissive            bool
 	bodyIncludingComments bool
+	maxDepth              int
+	depth                 int
 }
 
 // ConfigOption is an option for Parser.
@@ -28,6 +30,14 @@ func WithBodyIncludingComments(bodyIncludingComments bool) ConfigOption {
 	}
 }
 
+// WithMaxDepth is an option to limit the nesting depth of messages and option literals.
+// Zero means no limit.
+func WithMaxDepth(maxDepth int) ConfigOption {
+	return func(p *Parser) {
+		p.maxDepth = maxDepth
+	}
+}
+
 // NewParser creates a new Parser.
 func NewParser(lex *lexer.Lexer, opts ...ConfigOption) *Parser {
 	p := &Parser{
@@ -45,3 +55,23 @@ func (p *Parser) IsEOF() bool {
 	defer p.lex.UnNext()
 	return p.lex.IsEOF()
 }
+
+// enter increases the nesting depth. It stops the lexer if the depth exceeds the limit.
+func (p *Parser) enter() error {
+	p.depth++
+	if 0 < p.maxDepth && p.maxDepth < p.depth {
+		err := &lexer.LimitError{
+			Limit: lexer.LimitNestingDepth,
+			Max:   p.maxDepth,
+			Pos:   p.lex.Pos,
+		}
+		p.lex.Stop(err)
+		return err
+	}
+	return nil
+}
+
+// leave decreases the nesting depth.
+func (p *Parser) leave() {
+	p.depth--
+}
@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/thought-machine/go-protoparser/internal/lexer/scanner"
+import (
+	"errors"
+
+	"github.com/thought-machine/go-protoparser/internal/lexer/scanner"
+)
 
 // ProtoMeta represents a meta information about the Proto.
 type ProtoMeta struct {
@@ -14,6 +18,8 @@ type Proto struct {
 	// ProtoBody is a slice of sum type consisted of *Import, *Package, *Option, *Message, *Enum, *Service, *Extend and *EmptyStatement.
 	ProtoBody []Visitee
 	Meta      *ProtoMeta
+	// Warnings are the problems which did not stop parsing, such as scan errors.
+	Warnings []*Warning
 }
 
 // Accept dispatches the call to the visitor.
@@ -32,6 +38,28 @@ func (p *Proto) Accept(v Visitor) {
 //
 // See https://developers.google.com/protocol-buffers/docs/reference/proto3-spec#proto_file
 func (p *Parser) ParseProto() (*Proto, error) {
+	proto, err := p.parseProto()
+	if stopErr := p.lex.StopErr(); stopErr != nil {
+		return nil, stopErr
+	}
+
+	var warnings []*Warning
+	for _, scanErr := range p.lex.ScanErrors() {
+		warnings = append(warnings, NewWarning(scanErr))
+	}
+	if err != nil {
+		// The scan errors are likely to be the cause.
+		errs := []error{err}
+		for _, warning := range warnings {
+			errs = append(errs, warning)
+		}
+		return nil, errors.Join(errs...)
+	}
+	proto.Warnings = warnings
+	return proto, nil
+}
+
+func (p *Parser) parseProto() (*Proto, error) {
 	syntaxComments := p.ParseComments()
 	syntax, err := p.ParseSyntax()
 	if err != nil {
@@ -136,7 +164,7 @@ func (p *Parser) parseProtoBody() ([]Visitee, error) {
 			if err != nil {
 				return nil, err
 			}
-			protoBody = append(protoBody, &EmptyStatement{})
+			stmt = &EmptyStatement{}
 		}
 
 		p.MaybeScanInlineComment(stmt)
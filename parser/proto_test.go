@@ -2,7 +2,6 @@ package parser_test
 
 import (
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -1198,7 +1197,7 @@ extend Foo {
 		t.Run(test.name, func(t *testing.T) {
 			p := parser.NewParser(
 				lexer.NewLexer(
-					strings.NewReader(test.input),
+					[]byte(test.input),
 					lexer.WithFilename(test.filename),
 				),
 				parser.WithBodyIncludingComments(test.inputBodyIncludingComments),
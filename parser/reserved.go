@@ -16,6 +16,11 @@ func (e *parseReservedErr) Error() string {
 	return fmt.Sprintf("%v:%v", e.parseRangesErr, e.parseFieldNamesErr)
 }
 
+// Unwrap returns the errors of the alternatives.
+func (e *parseReservedErr) Unwrap() []error {
+	return []error{e.parseRangesErr, e.parseFieldNamesErr}
+}
+
 // Range is a range of field numbers. End is an optional value.
 type Range struct {
 	Begin string
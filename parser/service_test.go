@@ -2,7 +2,6 @@ package parser_test
 
 import (
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
@@ -612,7 +611,7 @@ service SearchService {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			p := parser.NewParser(
-				lexer.NewLexer(strings.NewReader(test.input)),
+				lexer.NewLexer([]byte(test.input)),
 				parser.WithBodyIncludingComments(test.inputBodyIncludingComments),
 			)
 			got, err := p.ParseService()
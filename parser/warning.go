@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/thought-machine/go-protoparser/internal/lexer"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// Warning represents a problem which did not stop parsing, such as a scan error.
+type Warning struct {
+	// Err is the cause of the warning.
+	Err error
+
+	// Meta is the meta information.
+	Meta meta.Meta
+}
+
+// NewWarning creates a new Warning from lexer.ScanError.
+func NewWarning(from *lexer.ScanError) *Warning {
+	return &Warning{
+		Err:  from.Err,
+		Meta: meta.NewMeta(from.Pos),
+	}
+}
+
+func (w *Warning) Error() string {
+	return fmt.Sprintf("%s: %v", w.Meta.Pos, w.Err)
+}
+
+// Unwrap returns the cause of the warning.
+func (w *Warning) Unwrap() error {
+	return w.Err
+}
@@ -0,0 +1,237 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+func (s *state) printNode(node interface{}) error {
+	switch n := node.(type) {
+	case *parser.Proto:
+		return s.printProto(n)
+	case *parser.Syntax:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf(`syntax = "%s";`, n.ProtobufVersion)
+		s.end(n.InlineComment)
+	case *parser.Import:
+		s.printComments(n.Comments)
+		s.begin()
+		s.buf.WriteString("import ")
+		switch n.Modifier {
+		case parser.ImportModifierPublic:
+			s.buf.WriteString("public ")
+		case parser.ImportModifierWeak:
+			s.buf.WriteString("weak ")
+		}
+		s.printf("%s;", n.Location)
+		s.end(n.InlineComment)
+	case *parser.Package:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("package %s;", n.Name)
+		s.end(n.InlineComment)
+	case *parser.Option:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printOption(n)
+		s.buf.WriteString(";")
+		s.end(n.InlineComment)
+	case *parser.Message:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("message %s", n.MessageName)
+		return s.printBlock(n.InlineCommentBehindLeftCurly, n.MessageBody, n.InlineComment)
+	case *parser.Field:
+		s.printComments(n.Comments)
+		s.begin()
+		if n.IsRepeated {
+			s.buf.WriteString("repeated ")
+		}
+		s.printf("%s %s = %s", n.Type, n.FieldName, n.FieldNumber)
+		s.printFieldOptions(n.FieldOptions)
+		s.buf.WriteString(";")
+		s.end(n.InlineComment)
+	case *parser.MapField:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("map<%s, %s> %s = %s", n.KeyType, n.Type, n.MapName, n.FieldNumber)
+		s.printFieldOptions(n.FieldOptions)
+		s.buf.WriteString(";")
+		s.end(n.InlineComment)
+	case *parser.Oneof:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("oneof %s", n.OneofName)
+		var body []parser.Visitee
+		for _, field := range n.OneofFields {
+			body = append(body, field)
+		}
+		return s.printBlock(n.InlineCommentBehindLeftCurly, body, n.InlineComment)
+	case *parser.OneofField:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("%s %s = %s", n.Type, n.FieldName, n.FieldNumber)
+		s.printFieldOptions(n.FieldOptions)
+		s.buf.WriteString(";")
+		s.end(n.InlineComment)
+	case *parser.Enum:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("enum %s", n.EnumName)
+		return s.printBlock(n.InlineCommentBehindLeftCurly, n.EnumBody, n.InlineComment)
+	case *parser.EnumField:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("%s = %s", n.Ident, n.Number)
+		if 0 < len(n.EnumValueOptions) {
+			var opts []string
+			for _, opt := range n.EnumValueOptions {
+				opts = append(opts, opt.OptionName+" = "+opt.Constant)
+			}
+			s.printf(" [%s]", strings.Join(opts, ", "))
+		}
+		s.buf.WriteString(";")
+		s.end(n.InlineComment)
+	case *parser.Reserved:
+		s.printComments(n.Comments)
+		s.begin()
+		s.buf.WriteString("reserved ")
+		if 0 < len(n.Ranges) {
+			var ranges []string
+			for _, r := range n.Ranges {
+				if r.End == "" {
+					ranges = append(ranges, r.Begin)
+				} else {
+					ranges = append(ranges, r.Begin+" to "+r.End)
+				}
+			}
+			s.buf.WriteString(strings.Join(ranges, ", "))
+		} else {
+			s.buf.WriteString(strings.Join(n.FieldNames, ", "))
+		}
+		s.buf.WriteString(";")
+		s.end(n.InlineComment)
+	case *parser.Extend:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("extend %s", n.MessageType)
+		return s.printBlock(n.InlineCommentBehindLeftCurly, n.ExtendBody, n.InlineComment)
+	case *parser.Service:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf("service %s", n.ServiceName)
+		return s.printBlock(n.InlineCommentBehindLeftCurly, n.ServiceBody, n.InlineComment)
+	case *parser.RPC:
+		s.printComments(n.Comments)
+		s.begin()
+		s.printf(
+			"rpc %s(%s) returns (%s)",
+			n.RPCName,
+			messageType(n.RPCRequest.IsStream, n.RPCRequest.MessageType),
+			messageType(n.RPCResponse.IsStream, n.RPCResponse.MessageType),
+		)
+		if len(n.Options) == 0 {
+			s.buf.WriteString(";")
+			s.end(n.InlineComment)
+			return nil
+		}
+		var body []parser.Visitee
+		for _, opt := range n.Options {
+			body = append(body, opt)
+		}
+		return s.printBlock(nil, body, n.InlineComment)
+	case *parser.EmptyStatement:
+		s.begin()
+		s.buf.WriteString(";")
+		s.end(n.InlineComment)
+	case *parser.Comment:
+		s.printComments([]*parser.Comment{n})
+	default:
+		return fmt.Errorf("unsupported node type %T", node)
+	}
+	return nil
+}
+
+func (s *state) printProto(proto *parser.Proto) error {
+	if proto.Syntax != nil {
+		if err := s.printNode(proto.Syntax); err != nil {
+			return err
+		}
+	}
+
+	var prev parser.Visitee
+	if proto.Syntax != nil {
+		prev = proto.Syntax
+	}
+	for _, body := range proto.ProtoBody {
+		if prev != nil && needsBlankLine(prev, body) {
+			s.buf.WriteString("\n")
+		}
+		if err := s.printNode(body); err != nil {
+			return err
+		}
+		prev = body
+	}
+	return nil
+}
+
+// needsBlankLine reports whether a blank line separates the top-level elements prev and next.
+// Definitions are always separated, and so are statements of different kinds.
+func needsBlankLine(prev, next parser.Visitee) bool {
+	switch next.(type) {
+	case *parser.Message, *parser.Enum, *parser.Service, *parser.Extend:
+		return true
+	}
+	return fmt.Sprintf("%T", prev) != fmt.Sprintf("%T", next)
+}
+
+func (s *state) printOption(opt *parser.Option) {
+	s.printf("option %s = ", opt.OptionName)
+	if opt.Endpoint == nil {
+		s.buf.WriteString(opt.Constant)
+		return
+	}
+
+	s.buf.WriteString("{\n")
+	s.depth++
+	for _, field := range opt.Endpoint.Fields {
+		s.begin()
+		s.printf("%s: %s\n", field.OptionName, field.Constant)
+	}
+	for _, binding := range opt.Endpoint.AdditionalBinding {
+		s.begin()
+		s.buf.WriteString("additional_bindings {\n")
+		s.depth++
+		for _, field := range binding.Fields {
+			s.begin()
+			s.printf("%s: %s\n", field.Name, strings.Join(field.Values, " "))
+		}
+		s.depth--
+		s.begin()
+		s.buf.WriteString("}\n")
+	}
+	s.depth--
+	s.begin()
+	s.buf.WriteString("}")
+}
+
+func (s *state) printFieldOptions(opts []*parser.FieldOption) {
+	if len(opts) == 0 {
+		return
+	}
+	var strs []string
+	for _, opt := range opts {
+		strs = append(strs, opt.OptionName+" = "+opt.Constant)
+	}
+	s.printf(" [%s]", strings.Join(strs, ", "))
+}
+
+func messageType(isStream bool, messageType string) string {
+	if isStream {
+		return "stream " + messageType
+	}
+	return messageType
+}
@@ -0,0 +1,129 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+// Printer prints a parsed Protocol Buffer back to its source form.
+type Printer struct {
+	indent string
+}
+
+// Option is an option for printer.NewPrinter.
+type Option func(*Printer)
+
+// WithIndent is an option to set the string used for one level of indentation.
+func WithIndent(indent string) Option {
+	return func(p *Printer) {
+		p.indent = indent
+	}
+}
+
+// NewPrinter creates a new Printer.
+func NewPrinter(opts ...Option) *Printer {
+	p := &Printer{
+		indent: "  ",
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Fprint writes the source form of node to w.
+// node is either a *parser.Proto or one of the elements which can appear in its body.
+func (p *Printer) Fprint(w io.Writer, node interface{}) error {
+	s := &state{
+		indent: p.indent,
+	}
+	if err := s.printNode(node); err != nil {
+		return err
+	}
+	_, err := w.Write(s.buf.Bytes())
+	return err
+}
+
+// Sprint returns the source form of node.
+func (p *Printer) Sprint(node interface{}) (string, error) {
+	var b strings.Builder
+	if err := p.Fprint(&b, node); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Fprint writes the source form of node to w using the default Printer.
+func Fprint(w io.Writer, node interface{}) error {
+	return NewPrinter().Fprint(w, node)
+}
+
+// Sprint returns the source form of node using the default Printer.
+func Sprint(node interface{}) (string, error) {
+	return NewPrinter().Sprint(node)
+}
+
+type state struct {
+	buf    bytes.Buffer
+	indent string
+	depth  int
+}
+
+// begin starts a new line at the current depth.
+func (s *state) begin() {
+	for i := 0; i < s.depth; i++ {
+		s.buf.WriteString(s.indent)
+	}
+}
+
+// end terminates the current line, appending the inline comment if any.
+func (s *state) end(inlineComment *parser.Comment) {
+	if inlineComment != nil {
+		s.buf.WriteString(" ")
+		s.buf.WriteString(inlineComment.Raw)
+	}
+	s.buf.WriteString("\n")
+}
+
+func (s *state) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&s.buf, format, args...)
+}
+
+func (s *state) printComments(comments []*parser.Comment) {
+	for _, comment := range comments {
+		s.begin()
+		s.buf.WriteString(comment.Raw)
+		s.buf.WriteString("\n")
+	}
+}
+
+// printBlock prints "{", the body and "}" in this order.
+func (s *state) printBlock(
+	inlineCommentBehindLeftCurly *parser.Comment,
+	body []parser.Visitee,
+	inlineComment *parser.Comment,
+) error {
+	if len(body) == 0 && inlineCommentBehindLeftCurly == nil {
+		s.buf.WriteString(" {}")
+		s.end(inlineComment)
+		return nil
+	}
+
+	s.buf.WriteString(" {")
+	s.end(inlineCommentBehindLeftCurly)
+	s.depth++
+	for _, elem := range body {
+		if err := s.printNode(elem); err != nil {
+			return err
+		}
+	}
+	s.depth--
+	s.begin()
+	s.buf.WriteString("}")
+	s.end(inlineComment)
+	return nil
+}
@@ -0,0 +1,173 @@
+package printer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/internal/lexer"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/printer"
+)
+
+func TestPrinter_Fprint(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		inputIndent string
+		wantOutput  string
+	}{
+		{
+			name: "printing an excerpt from the official reference",
+			input: `syntax = "proto3";
+// An example of the official reference
+package examplepb;
+import public "other.proto";
+option java_package = "com.example.foo";
+enum EnumAllowingAlias {
+    option allow_alias = true;
+    UNKNOWN = 0;
+    RUNNING = 2 [(custom_option) = "hello world"];
+}
+message outer {
+    option (my_option).a = true;
+    message inner {   // Level 2
+        int64 ival = 1;
+    }
+    repeated inner inner_message = 2;
+    map<int32, string> my_map = 4;
+    oneof foo {
+        string name = 5;
+    }
+    reserved 8, 9 to 11;
+    reserved "bar";
+}
+`,
+			wantOutput: `syntax = "proto3";
+
+// An example of the official reference
+package examplepb;
+
+import public "other.proto";
+
+option java_package = "com.example.foo";
+
+enum EnumAllowingAlias {
+  option allow_alias = true;
+  UNKNOWN = 0;
+  RUNNING = 2 [(custom_option) = "hello world"];
+}
+
+message outer {
+  option (my_option).a = true;
+  message inner { // Level 2
+    int64 ival = 1;
+  }
+  repeated inner inner_message = 2;
+  map<int32, string> my_map = 4;
+  oneof foo {
+    string name = 5;
+  }
+  reserved 8, 9 to 11;
+  reserved "bar";
+}
+`,
+		},
+		{
+			name: "printing a service with an indent option",
+			input: `syntax = "proto3";
+service SearchService {
+  rpc Search (SearchRequest) returns (stream SearchResponse); // inline
+  rpc Get (GetRequest) returns (GetResponse) {
+    option (google.api.http) = {
+      get: "/v1/{name=messages/*}"
+    };
+  }
+}
+extend Foo {
+  int32 bar = 126;
+}
+`,
+			inputIndent: "    ",
+			wantOutput: `syntax = "proto3";
+
+service SearchService {
+    rpc Search(SearchRequest) returns (stream SearchResponse); // inline
+    rpc Get(GetRequest) returns (GetResponse) {
+        option (google.api.http) = {
+            get: "/v1/{name=messages/*}"
+        };
+    }
+}
+
+extend Foo {
+    int32 bar = 126;
+}
+`,
+		},
+		{
+			name: "printing the additional bindings of a google.api.http option",
+			input: `syntax = "proto3";
+service Library {
+  rpc Update(Book) returns (Book) {
+    option (google.api.http) = {
+      patch: "/v1/books"
+      body: "*"
+      additional_bindings { put: "/v1/books" body: "*" }
+      additional_bindings { body: "book" post: "/v1/" "books" }
+    };
+  }
+}
+`,
+			wantOutput: `syntax = "proto3";
+
+service Library {
+  rpc Update(Book) returns (Book) {
+    option (google.api.http) = {
+      patch: "/v1/books"
+      body: "*"
+      additional_bindings {
+        put: "/v1/books"
+        body: "*"
+      }
+      additional_bindings {
+        body: "book"
+        post: "/v1/books"
+      }
+    };
+  }
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p := parser.NewParser(
+				lexer.NewLexer([]byte(test.input)),
+				parser.WithPermissive(true),
+			)
+			proto, err := p.ParseProto()
+			if err != nil {
+				t.Errorf("got err %v, but want nil", err)
+				return
+			}
+
+			var opts []printer.Option
+			if test.inputIndent != "" {
+				opts = append(opts, printer.WithIndent(test.inputIndent))
+			}
+			var b strings.Builder
+			err = printer.NewPrinter(opts...).Fprint(&b, proto)
+			if err != nil {
+				t.Errorf("got err %v, but want nil", err)
+				return
+			}
+
+			got := b.String()
+			if got != test.wantOutput {
+				t.Errorf("got %s, but want %s", got, test.wantOutput)
+			}
+		})
+	}
+}
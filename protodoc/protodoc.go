@@ -0,0 +1,235 @@
+// Package protodoc renders the documentation of linked files as Markdown or HTML pages, one per package.
+package protodoc
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/thought-machine/go-protoparser/doc"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// Format is the output format.
+type Format int
+
+// The output formats.
+const (
+	FormatMarkdown Format = iota
+	FormatHTML
+)
+
+// Ext returns the file extension of the format, like ".md".
+func (f Format) Ext() string {
+	if f == FormatHTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// Package is the data passed to the "page" template.
+type Package struct {
+	// Name is the package name. It is empty for the files without a package statement.
+	Name string
+	// Files are the files of the package.
+	Files []*schema.File
+	// Services are the services of the files.
+	Services []*schema.Service
+	// Messages are the messages of the files including the nested ones, in the order of the declaration.
+	Messages []*schema.Message
+	// Enums are the enums of the files including the nested ones, in the order of the declaration.
+	Enums []*schema.Enum
+}
+
+// Page is a rendered page.
+type Page struct {
+	// Package is the package name.
+	Package string
+	// Path is the file name of the page, like "foo.bar.md".
+	Path    string
+	Content []byte
+}
+
+// executor is implemented by both *text/template.Template and *html/template.Template.
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// Generator renders the pages.
+type Generator struct {
+	format    Format
+	overrides []string
+	tmpl      executor
+}
+
+// Option is an option for NewGenerator.
+type Option func(*Generator)
+
+// WithFormat is an option to set the output format. The default is FormatMarkdown.
+func WithFormat(format Format) Option {
+	return func(g *Generator) {
+		g.format = format
+	}
+}
+
+// WithTemplates is an option to override the default templates.
+// Each text defines the templates to replace with {{define "name"}}...{{end}}: "page", "header", "footer",
+// "service", "rpc", "message", "enum", "type" and "ref", and "style" and "comment" for FormatHTML.
+// The templates for FormatHTML are html/template ones, so that the texts are escaped.
+func WithTemplates(texts ...string) Option {
+	return func(g *Generator) {
+		g.overrides = append(g.overrides, texts...)
+	}
+}
+
+// NewGenerator creates a new Generator. It fails if an overriding template cannot be parsed.
+func NewGenerator(options ...Option) (*Generator, error) {
+	g := &Generator{}
+	for _, opt := range options {
+		opt(g)
+	}
+
+	funcs := map[string]interface{}{
+		"comment":   comment,
+		"cell":      cell,
+		"href":      g.href,
+		"label":     label,
+		"localName": localName,
+		"pagePath":  g.pagePath,
+	}
+	switch g.format {
+	case FormatHTML:
+		tmpl, err := htmltemplate.New("").Funcs(funcs).Parse(htmlTemplate)
+		if err != nil {
+			return nil, err
+		}
+		for _, text := range g.overrides {
+			if tmpl, err = tmpl.Parse(text); err != nil {
+				return nil, err
+			}
+		}
+		g.tmpl = tmpl
+	default:
+		tmpl, err := texttemplate.New("").Funcs(funcs).Parse(markdownTemplate)
+		if err != nil {
+			return nil, err
+		}
+		for _, text := range g.overrides {
+			if tmpl, err = tmpl.Parse(text); err != nil {
+				return nil, err
+			}
+		}
+		g.tmpl = tmpl
+	}
+	return g, nil
+}
+
+// Generate renders a page for each package in the schema, in the order of schema.Packages.
+func (g *Generator) Generate(s *schema.Schema) ([]*Page, error) {
+	var pages []*Page
+	for _, pkg := range Packages(s) {
+		var buf bytes.Buffer
+		if err := g.Render(&buf, pkg); err != nil {
+			return nil, err
+		}
+		pages = append(pages, &Page{
+			Package: pkg.Name,
+			Path:    g.pagePath(pkg.Name),
+			Content: buf.Bytes(),
+		})
+	}
+	return pages, nil
+}
+
+// Render renders the page of the package.
+func (g *Generator) Render(w io.Writer, pkg *Package) error {
+	return g.tmpl.ExecuteTemplate(w, "page", pkg)
+}
+
+// Packages groups the definitions of the schema by package.
+func Packages(s *schema.Schema) []*Package {
+	var packages []*Package
+	byName := make(map[string]*Package)
+	for _, name := range s.Packages() {
+		pkg := &Package{Name: name}
+		byName[name] = pkg
+		packages = append(packages, pkg)
+	}
+	for _, f := range s.Files {
+		pkg := byName[f.Package]
+		pkg.Files = append(pkg.Files, f)
+		pkg.Services = append(pkg.Services, f.Services...)
+		for _, m := range f.Messages {
+			pkg.addMessage(m)
+		}
+		pkg.Enums = append(pkg.Enums, f.Enums...)
+	}
+	return packages
+}
+
+func (p *Package) addMessage(m *schema.Message) {
+	p.Messages = append(p.Messages, m)
+	p.Enums = append(p.Enums, m.Enums...)
+	for _, nested := range m.Messages {
+		p.addMessage(nested)
+	}
+}
+
+// pagePath returns the file name of the page of the package.
+func (g *Generator) pagePath(pkg string) string {
+	if pkg == "" {
+		pkg = "default"
+	}
+	return pkg + g.format.Ext()
+}
+
+// href returns the link to the definition referenced by ref, or an empty string if it is not resolved.
+// The link is relative to the page of the package of the referencing file.
+func (g *Generator) href(ref *schema.Ref) string {
+	var pkg string
+	switch {
+	case ref.Message != nil:
+		pkg = ref.Message.File.Package
+	case ref.Enum != nil:
+		pkg = ref.Enum.File.Package
+	default:
+		return ""
+	}
+	if pkg == ref.File.Package {
+		return "#" + ref.FullName
+	}
+	return g.pagePath(pkg) + "#" + ref.FullName
+}
+
+// comment returns the leading comment of the node.
+func comment(node parser.Visitee) string {
+	return doc.Of(node).Text()
+}
+
+// cell makes the text fit in a Markdown table cell.
+func cell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// label returns "repeated" for a repeated field, "oneof name" for a oneof field, or an empty string.
+func label(field *schema.Field) string {
+	switch {
+	case field.Repeated:
+		return "repeated"
+	case field.Oneof != nil:
+		return "oneof " + field.Oneof.Name
+	}
+	return ""
+}
+
+// localName returns the full name without the package, like "Outer.Inner".
+func localName(fullName string, pkg string) string {
+	if pkg == "" {
+		return fullName
+	}
+	return strings.TrimPrefix(fullName, pkg+".")
+}
@@ -0,0 +1,159 @@
+package protodoc_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/protodoc"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var testFS = fstest.MapFS{
+	"a.proto": {Data: []byte(`syntax = "proto3";
+package a;
+import "b.proto";
+
+// Library manages books.
+service Library {
+  // GetBook returns a book.
+  rpc GetBook(GetBookRequest) returns (stream b.Book) {
+    option (google.api.http) = {
+      get: "/v1/{name=books/*}"
+    };
+  }
+}
+
+// GetBookRequest is a request.
+message GetBookRequest {
+  // The name | the id.
+  string name = 1;
+  map<string, b.Book> books = 2;
+  repeated Kind kinds = 3;
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+  }
+}
+`)},
+	"b.proto": {Data: []byte(`syntax = "proto3";
+package b;
+
+// A <Book>.
+message Book {
+  oneof id {
+    string isbn = 1;
+  }
+}
+`)},
+}
+
+func load(t *testing.T) *schema.Schema {
+	s, err := schema.Load(context.Background(), testFS, []string{"a.proto"}, protoparser.WithImportPaths("."))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	return s
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	g, err := protodoc.NewGenerator()
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	pages, err := g.Generate(load(t))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if len(pages) != 2 || pages[0].Path != "a.md" || pages[1].Path != "b.md" {
+		t.Fatalf("got %v, but want a.md and b.md", pages)
+	}
+
+	want := "# Package a\n" +
+		"\n" +
+		"- `a.proto`\n" +
+		"\n" +
+		"## Services\n" +
+		"\n" +
+		"<a id=\"a.Library\"></a>\n" +
+		"### Library\n" +
+		"\n" +
+		"Library manages books.\n" +
+		"\n" +
+		"<a id=\"a.Library.GetBook\"></a>\n" +
+		"#### Library.GetBook\n" +
+		"\n" +
+		"GetBook returns a book.\n" +
+		"\n" +
+		"- Request: [a.GetBookRequest](#a.GetBookRequest)\n" +
+		"- Response: stream [b.Book](b.md#b.Book)\n" +
+		"- HTTP: `GET /v1/{name=books/*}`\n" +
+		"\n" +
+		"## Messages\n" +
+		"\n" +
+		"<a id=\"a.GetBookRequest\"></a>\n" +
+		"### GetBookRequest\n" +
+		"\n" +
+		"GetBookRequest is a request.\n" +
+		"\n" +
+		"| Field | Type | Label | Number | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| name | `string` |  | 1 | The name \\| the id. |\n" +
+		"| books | map&lt;string, [b.Book](b.md#b.Book)&gt; |  | 2 |  |\n" +
+		"| kinds | [a.GetBookRequest.Kind](#a.GetBookRequest.Kind) | repeated | 3 |  |\n" +
+		"\n" +
+		"## Enums\n" +
+		"\n" +
+		"<a id=\"a.GetBookRequest.Kind\"></a>\n" +
+		"### GetBookRequest.Kind\n" +
+		"\n" +
+		"| Name | Number | Description |\n" +
+		"| --- | --- | --- |\n" +
+		"| KIND_UNSPECIFIED | 0 |  |\n"
+	if got := string(pages[0].Content); got != want {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if got := string(pages[1].Content); !strings.Contains(got, "| isbn | `string` | oneof id | 1 |  |\n") {
+		t.Errorf("got %v, but want the oneof field", got)
+	}
+}
+
+func TestGenerator_GenerateHTML(t *testing.T) {
+	g, err := protodoc.NewGenerator(
+		protodoc.WithFormat(protodoc.FormatHTML),
+		protodoc.WithTemplates(`{{define "header"}}<header>{{.Name}} API</header>{{end}}`),
+	)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	pages, err := g.Generate(load(t))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if len(pages) != 2 || pages[1].Path != "b.html" {
+		t.Fatalf("got %v, but want b.html", pages)
+	}
+
+	got := string(pages[1].Content)
+	for _, want := range []string{
+		"<header>b API</header>",
+		`<section class="message" id="b.Book">`,
+		`<p class="comment">A &lt;Book&gt;.</p>`,
+		"<tr><td>isbn</td><td><code>string</code></td><td>oneof id</td><td>1</td><td></td></tr>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %v, but want it to contain %v", got, want)
+		}
+	}
+	if got := string(pages[0].Content); !strings.Contains(got, `<a href="b.html#b.Book">b.Book</a>`) {
+		t.Errorf("got %v, but want the link to b.html", got)
+	}
+}
+
+func TestNewGenerator_invalidTemplate(t *testing.T) {
+	_, err := protodoc.NewGenerator(protodoc.WithTemplates(`{{define "header"}}{{end`))
+	if err == nil {
+		t.Errorf("got nil, but want an error")
+	}
+}
@@ -0,0 +1,164 @@
+package protodoc
+
+// markdownTemplate is the default template set of FormatMarkdown.
+const markdownTemplate = `
+{{- define "page" -}}
+{{ template "header" . -}}
+# {{ if .Name }}Package {{ .Name }}{{ else }}Default package{{ end }}
+
+{{ range .Files }}- ` + "`{{ .Path }}`" + `
+{{ end -}}
+{{ if .Services }}
+## Services
+{{ range .Services }}{{ template "service" . }}{{ end }}{{ end -}}
+{{ if .Messages }}
+## Messages
+{{ range .Messages }}{{ template "message" . }}{{ end }}{{ end -}}
+{{ if .Enums }}
+## Enums
+{{ range .Enums }}{{ template "enum" . }}{{ end }}{{ end -}}
+{{ template "footer" . -}}
+{{ end -}}
+
+{{- define "header" }}{{ end -}}
+
+{{- define "footer" }}{{ end -}}
+
+{{- define "service" }}
+<a id="{{ .FullName }}"></a>
+### {{ .Name }}
+{{ with comment .Node }}
+{{ . }}
+{{ end }}{{ range .RPCs }}{{ template "rpc" . }}{{ end }}{{ end -}}
+
+{{- define "rpc" }}
+<a id="{{ .Parent.FullName }}.{{ .Name }}"></a>
+#### {{ .Parent.Name }}.{{ .Name }}
+{{ with comment .Node }}
+{{ . }}
+{{ end }}
+- Request: {{ if .RequestStream }}stream {{ end }}{{ template "ref" .Request }}
+- Response: {{ if .ResponseStream }}stream {{ end }}{{ template "ref" .Response }}
+{{ range .HTTPRules }}- HTTP: ` + "`{{ .Method }} {{ .Path }}`" + `{{ with .Body }} with the body ` + "`{{ . }}`" + `{{ end }}
+{{ end }}{{ end -}}
+
+{{- define "message" }}
+<a id="{{ .FullName }}"></a>
+### {{ localName .FullName .File.Package }}
+{{ with comment .Node }}
+{{ . }}
+{{ end }}{{ if .Fields }}
+| Field | Type | Label | Number | Description |
+| --- | --- | --- | --- | --- |
+{{ range .Fields }}| {{ .Name }} | {{ template "type" . }} | {{ label . }} | {{ .Number }} | {{ cell (comment .Node) }} |
+{{ end }}{{ end }}{{ end -}}
+
+{{- define "enum" }}
+<a id="{{ .FullName }}"></a>
+### {{ localName .FullName .File.Package }}
+{{ with comment .Node }}
+{{ . }}
+{{ end }}
+| Name | Number | Description |
+| --- | --- | --- |
+{{ range .Values }}| {{ .Name }} | {{ .Number }} | {{ cell (comment .Node) }} |
+{{ end }}{{ end -}}
+
+{{- define "type" }}{{ if .IsMap }}map&lt;{{ .KeyType }}, {{ template "ref" .Type }}&gt;{{ else }}{{ template "ref" .Type }}{{ end }}{{ end -}}
+
+{{- define "ref" }}{{ with href . }}[{{ $.FullName }}]({{ . }}){{ else }}` + "`{{ .FullName }}`" + `{{ end }}{{ end -}}
+`
+
+// htmlTemplate is the default template set of FormatHTML.
+const htmlTemplate = `
+{{- define "page" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ if .Name }}{{ .Name }}{{ else }}Default package{{ end }}</title>
+{{ template "style" . }}
+</head>
+<body>
+{{ template "header" . -}}
+<h1>{{ if .Name }}Package {{ .Name }}{{ else }}Default package{{ end }}</h1>
+<ul class="files">
+{{ range .Files }}<li><code>{{ .Path }}</code></li>
+{{ end -}}
+</ul>
+{{ if .Services }}<h2>Services</h2>
+{{ range .Services }}{{ template "service" . }}{{ end }}{{ end -}}
+{{ if .Messages }}<h2>Messages</h2>
+{{ range .Messages }}{{ template "message" . }}{{ end }}{{ end -}}
+{{ if .Enums }}<h2>Enums</h2>
+{{ range .Enums }}{{ template "enum" . }}{{ end }}{{ end -}}
+{{ template "footer" . -}}
+</body>
+</html>
+{{ end -}}
+
+{{- define "style" -}}
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+</style>
+{{- end -}}
+
+{{- define "header" }}{{ end -}}
+
+{{- define "footer" }}{{ end -}}
+
+{{- define "comment" }}{{ with comment . }}<p class="comment">{{ . }}</p>
+{{ end }}{{ end -}}
+
+{{- define "service" -}}
+<section class="service" id="{{ .FullName }}">
+<h3>{{ .Name }}</h3>
+{{ template "comment" .Node -}}
+{{ range .RPCs }}{{ template "rpc" . }}{{ end -}}
+</section>
+{{ end -}}
+
+{{- define "rpc" -}}
+<section class="rpc" id="{{ .Parent.FullName }}.{{ .Name }}">
+<h4>{{ .Parent.Name }}.{{ .Name }}</h4>
+{{ template "comment" .Node -}}
+<ul>
+<li>Request: {{ if .RequestStream }}stream {{ end }}{{ template "ref" .Request }}</li>
+<li>Response: {{ if .ResponseStream }}stream {{ end }}{{ template "ref" .Response }}</li>
+{{ range .HTTPRules }}<li>HTTP: <code>{{ .Method }} {{ .Path }}</code>{{ with .Body }} with the body <code>{{ . }}</code>{{ end }}</li>
+{{ end -}}
+</ul>
+</section>
+{{ end -}}
+
+{{- define "message" -}}
+<section class="message" id="{{ .FullName }}">
+<h3>{{ localName .FullName .File.Package }}</h3>
+{{ template "comment" .Node -}}
+{{ if .Fields }}<table>
+<tr><th>Field</th><th>Type</th><th>Label</th><th>Number</th><th>Description</th></tr>
+{{ range .Fields }}<tr><td>{{ .Name }}</td><td>{{ template "type" . }}</td><td>{{ label . }}</td><td>{{ .Number }}</td><td>{{ comment .Node }}</td></tr>
+{{ end -}}
+</table>
+{{ end -}}
+</section>
+{{ end -}}
+
+{{- define "enum" -}}
+<section class="enum" id="{{ .FullName }}">
+<h3>{{ localName .FullName .File.Package }}</h3>
+{{ template "comment" .Node -}}
+<table>
+<tr><th>Name</th><th>Number</th><th>Description</th></tr>
+{{ range .Values }}<tr><td>{{ .Name }}</td><td>{{ .Number }}</td><td>{{ comment .Node }}</td></tr>
+{{ end -}}
+</table>
+</section>
+{{ end -}}
+
+{{- define "type" }}{{ if .IsMap }}map&lt;{{ .KeyType }}, {{ template "ref" .Type }}&gt;{{ else }}{{ template "ref" .Type }}{{ end }}{{ end -}}
+
+{{- define "ref" }}{{ with href . }}<a href="{{ . }}">{{ $.FullName }}</a>{{ else }}<code>{{ .FullName }}</code>{{ end }}{{ end -}}
+`
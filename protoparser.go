@@ -1,11 +1,16 @@
 package protoparser
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 
 	"github.com/thought-machine/go-protoparser/internal/lexer"
 	"github.com/thought-machine/go-protoparser/interpret/unordered"
 	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
 )
 
 // ParseConfig is a config for parser.
@@ -14,6 +19,15 @@ type ParseConfig struct {
 	permissive            bool
 	bodyIncludingComments bool
 	filename              string
+	maxInputSize          int
+	maxDepth              int
+	maxTokens             int
+	errorHandler          func(err error)
+	logger                *slog.Logger
+
+	// parallelism and importPaths are only used by ParseFS and ParseFiles.
+	parallelism int
+	importPaths []string
 }
 
 // Option is an option for ParseConfig.
@@ -48,8 +62,79 @@ func WithFilename(filename string) Option {
 	}
 }
 
+// WithErrorHandler is an option to set the handler called for each scan error.
+// The error is a *parser.Warning which is also added to the Warnings of the result.
+// ParseFS and ParseFiles call it concurrently.
+func WithErrorHandler(errorHandler func(err error)) Option {
+	return func(c *ParseConfig) {
+		c.errorHandler = errorHandler
+	}
+}
+
+// WithLogger is an option to log scan errors at the warn level and, in the debug mode, tokens at the debug level.
+// Nothing is logged by default except in the debug mode, which logs to os.Stderr.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *ParseConfig) {
+		c.logger = logger
+	}
+}
+
+// WithMaxInputSize is an option to limit the size of the input in bytes.
+// Zero means no limit.
+func WithMaxInputSize(maxInputSize int) Option {
+	return func(c *ParseConfig) {
+		c.maxInputSize = maxInputSize
+	}
+}
+
+// WithMaxDepth is an option to limit the nesting depth of messages and option literals.
+// Zero means no limit.
+func WithMaxDepth(maxDepth int) Option {
+	return func(c *ParseConfig) {
+		c.maxDepth = maxDepth
+	}
+}
+
+// WithMaxTokens is an option to limit the number of tokens in the input.
+// Zero means no limit.
+func WithMaxTokens(maxTokens int) Option {
+	return func(c *ParseConfig) {
+		c.maxTokens = maxTokens
+	}
+}
+
+// The names of limits for LimitError.
+const (
+	LimitInputSize    = "input size"
+	LimitNestingDepth = lexer.LimitNestingDepth
+	LimitTokenCount   = lexer.LimitTokenCount
+)
+
+// LimitError is returned when the input exceeds a limit set by WithMaxInputSize, WithMaxDepth or WithMaxTokens.
+type LimitError struct {
+	// Limit is the name of the exceeded limit.
+	Limit string
+	// Max is the maximum set by the option.
+	Max int
+	// Pos is the position where the limit was exceeded. It is not set for LimitInputSize.
+	Pos meta.Position
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == LimitInputSize {
+		return fmt.Sprintf("%s exceeds the maximum of %d", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("%s exceeds the maximum of %d at %s", e.Limit, e.Max, e.Pos)
+}
+
 // Parse parses a Protocol Buffer file.
 func Parse(input io.Reader, options ...Option) (*parser.Proto, error) {
+	return ParseContext(context.Background(), input, options...)
+}
+
+// ParseContext parses a Protocol Buffer file and stops with ctx.Err() when ctx is done, also while reading input.
+// It returns the error of reading input, and a *LimitError when the input exceeds a limit set by the options.
+func ParseContext(ctx context.Context, input io.Reader, options ...Option) (*parser.Proto, error) {
 	config := &ParseConfig{
 		permissive: true,
 	}
@@ -57,16 +142,66 @@ func Parse(input io.Reader, options ...Option) (*parser.Proto, error) {
 		opt(config)
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var r io.Reader = &contextReader{ctx: ctx, r: input}
+	if 0 < config.maxInputSize {
+		r = io.LimitReader(r, int64(config.maxInputSize)+1)
+	}
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if 0 < config.maxInputSize && config.maxInputSize < len(src) {
+		return nil, &LimitError{
+			Limit: LimitInputSize,
+			Max:   config.maxInputSize,
+		}
+	}
+
+	lexOpts := []lexer.Option{
+		lexer.WithDebug(config.debug),
+		lexer.WithFilename(config.filename),
+		lexer.WithContext(ctx),
+		lexer.WithMaxTokens(config.maxTokens),
+		lexer.WithLogger(config.logger),
+	}
+	if config.errorHandler != nil {
+		lexOpts = append(lexOpts, lexer.WithErrorHandler(func(err *lexer.ScanError) {
+			config.errorHandler(parser.NewWarning(err))
+		}))
+	}
+
 	p := parser.NewParser(
-		lexer.NewLexer(
-			input,
-			lexer.WithDebug(config.debug),
-			lexer.WithFilename(config.filename),
-		),
+		lexer.NewLexer(src, lexOpts...),
 		parser.WithPermissive(config.permissive),
 		parser.WithBodyIncludingComments(config.bodyIncludingComments),
+		parser.WithMaxDepth(config.maxDepth),
 	)
-	return p.ParseProto()
+	proto, err := p.ParseProto()
+	var limitErr *lexer.LimitError
+	if errors.As(err, &limitErr) {
+		return nil, &LimitError{
+			Limit: limitErr.Limit,
+			Max:   limitErr.Max,
+			Pos:   meta.NewPosition(limitErr.Pos),
+		}
+	}
+	return proto, err
+}
+
+// contextReader is a reader which fails with ctx.Err() when ctx is done, so that reading a slow input stops.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
 }
 
 // UnorderedInterpret interprets a Proto to an unordered one without interface{}.
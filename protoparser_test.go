@@ -1,8 +1,19 @@
 package protoparser
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
+
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
 )
 
 func TestParseSimpleFile(t *testing.T) {
@@ -52,3 +63,191 @@ func TestParseReleaseVersionFile(t *testing.T) {
 		t.Errorf("Failed to parse proto, %v", err)
 	}
 }
+
+func TestParseContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	input := `syntax = "proto3";
+message A {
+  message B {
+    string c = 1 [(validator.field) = {msg: {d: 1}}];
+  }
+}
+`
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		options []Option
+		wantErr error
+	}{
+		{
+			name: "parsing within the limits",
+			ctx:  context.Background(),
+			options: []Option{
+				WithMaxInputSize(len(input)),
+				WithMaxDepth(4),
+				WithMaxTokens(36),
+			},
+		},
+		{
+			name:    "parsing with a canceled context",
+			ctx:     canceled,
+			wantErr: context.Canceled,
+		},
+		{
+			name: "exceeding the input size",
+			ctx:  context.Background(),
+			options: []Option{
+				WithMaxInputSize(len(input) - 1),
+			},
+			wantErr: &LimitError{
+				Limit: LimitInputSize,
+				Max:   len(input) - 1,
+			},
+		},
+		{
+			name: "exceeding the nesting depth",
+			ctx:  context.Background(),
+			options: []Option{
+				WithMaxDepth(3),
+			},
+			wantErr: &LimitError{
+				Limit: LimitNestingDepth,
+				Max:   3,
+				Pos: meta.Position{
+					Offset: 89,
+					Line:   4,
+					Column: 45,
+				},
+			},
+		},
+		{
+			name: "exceeding the token count",
+			ctx:  context.Background(),
+			options: []Option{
+				WithMaxTokens(8),
+			},
+			wantErr: &LimitError{
+				Limit: LimitTokenCount,
+				Max:   8,
+				Pos: meta.Position{
+					Offset: 29,
+					Line:   2,
+					Column: 11,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseContext(test.ctx, strings.NewReader(input), test.options...)
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatalf("got err %v, but want nil", err)
+				}
+				if got == nil {
+					t.Errorf("got nil, but want the proto")
+				}
+				return
+			}
+
+			if got != nil {
+				t.Errorf("got %v, but want nil", got)
+			}
+			var limitErr *LimitError
+			if errors.As(err, &limitErr) {
+				if !reflect.DeepEqual(limitErr, test.wantErr) {
+					t.Errorf("got %v, but want %v", limitErr, test.wantErr)
+				}
+				return
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got %v, but want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// cancelingReader cancels the context when it is read.
+type cancelingReader struct {
+	r      io.Reader
+	cancel func()
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	r.cancel()
+	return r.r.Read(p)
+}
+
+func TestParseContext_reading(t *testing.T) {
+	errBroken := errors.New("broken")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		input   io.Reader
+		wantErr error
+	}{
+		{
+			name:    "failing to read the input",
+			ctx:     context.Background(),
+			input:   io.MultiReader(strings.NewReader(`syntax = "proto3";`), iotest.ErrReader(errBroken)),
+			wantErr: errBroken,
+		},
+		{
+			name:    "canceling the context while reading the input",
+			ctx:     ctx,
+			input:   &cancelingReader{r: iotest.OneByteReader(strings.NewReader(`syntax = "proto3";`)), cancel: cancel},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseContext(test.ctx, test.input)
+			if got != nil {
+				t.Errorf("got %v, but want nil", got)
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got %v, but want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_scanError(t *testing.T) {
+	var handled []error
+	var logs bytes.Buffer
+	_, err := Parse(
+		strings.NewReader("syntax = \"proto3\";\n/* unterminated"),
+		WithErrorHandler(func(err error) {
+			handled = append(handled, err)
+		}),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	var warning *parser.Warning
+	if !errors.As(err, &warning) {
+		t.Fatalf("got %v, but want a *parser.Warning", err)
+	}
+	wantPos := meta.Position{
+		Offset: 19,
+		Line:   2,
+		Column: 1,
+	}
+	if !reflect.DeepEqual(warning.Meta.Pos, wantPos) {
+		t.Errorf("got %v, but want %v", warning.Meta.Pos, wantPos)
+	}
+	if len(handled) != 1 || handled[0].Error() != warning.Error() {
+		t.Errorf("got %v, but want [%v]", handled, warning)
+	}
+	if !strings.Contains(logs.String(), `level=WARN msg="scan error" pos=<input>:2:1`) {
+		t.Errorf("got %q, but want a warn log", logs.String())
+	}
+}
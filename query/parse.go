@@ -0,0 +1,284 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// combinator relates a compound selector to the preceding one.
+type combinator int
+
+const (
+	descendant combinator = iota
+	child
+)
+
+// selector is a chain of compound selectors, like "message > field".
+type selector struct {
+	compounds []*compound
+	// combinators[i] relates compounds[i+1] to compounds[i].
+	combinators []combinator
+}
+
+// compound is a kind with predicates, like "field[type=string]:not([repeated])".
+type compound struct {
+	// kind is the kind of the node, or empty for any kind.
+	kind       string
+	predicates []*predicate
+	not        []*compound
+	has        []*selector
+	// scope matches only the node of :has which is evaluated.
+	scope bool
+}
+
+// predicate is an attribute predicate, like "[name^=Get]".
+type predicate struct {
+	attribute string
+	// operator is empty if the predicate checks only the existence of the attribute.
+	operator string
+	value    string
+	re       *regexp.Regexp
+}
+
+// queryParser parses a query.
+type queryParser struct {
+	text string
+	pos  int
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d of %q", fmt.Sprintf(format, args...), p.pos, p.text)
+}
+
+func (p *queryParser) skipSpaces() bool {
+	start := p.pos
+	for p.pos < len(p.text) && strings.ContainsRune(" \t\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+	return start < p.pos
+}
+
+// found describes the next byte for an error, or the end of the query.
+func (p *queryParser) found() string {
+	if len(p.text) <= p.pos {
+		return "the end of the query"
+	}
+	return strconv.Quote(string(p.text[p.pos]))
+}
+
+func (p *queryParser) peek() byte {
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+func (p *queryParser) consume(s string) bool {
+	if strings.HasPrefix(p.text[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// parseSelectors parses the selectors separated by commas until the end or ")".
+func (p *queryParser) parseSelectors() ([]*selector, error) {
+	var selectors []*selector
+	for {
+		s, err := p.parseSelector(false)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, s)
+		p.skipSpaces()
+		if !p.consume(",") {
+			return selectors, nil
+		}
+	}
+}
+
+// parseSelector parses a chain of compound selectors. A relative selector of :has starts with the scope,
+// and may start with ">".
+func (p *queryParser) parseSelector(relative bool) (*selector, error) {
+	s := &selector{}
+	p.skipSpaces()
+	if relative {
+		s.compounds = append(s.compounds, &compound{scope: true})
+		if p.consume(">") {
+			s.combinators = append(s.combinators, child)
+		} else {
+			s.combinators = append(s.combinators, descendant)
+		}
+		p.skipSpaces()
+	}
+	for {
+		c, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		s.compounds = append(s.compounds, c)
+
+		spaced := p.skipSpaces()
+		switch {
+		case p.consume(">"):
+			p.skipSpaces()
+			s.combinators = append(s.combinators, child)
+		case spaced && p.pos < len(p.text) && p.peek() != ',' && p.peek() != ')':
+			s.combinators = append(s.combinators, descendant)
+		default:
+			return s, nil
+		}
+	}
+}
+
+func (p *queryParser) parseCompound() (*compound, error) {
+	c := &compound{}
+	if p.consume("*") {
+		c.kind = ""
+	} else if kind := p.readWhile(isNameByte); kind != "" {
+		if !kinds[kind] {
+			return nil, p.errorf("found %q but expected a kind", kind)
+		}
+		c.kind = kind
+	} else if p.peek() != '[' && p.peek() != ':' {
+		return nil, p.errorf("found %s but expected a kind, \"*\", \"[\" or \":\"", p.found())
+	}
+
+	for {
+		switch {
+		case p.consume("["):
+			pred, err := p.parsePredicate()
+			if err != nil {
+				return nil, err
+			}
+			c.predicates = append(c.predicates, pred)
+		case p.consume(":not("):
+			p.skipSpaces()
+			not, err := p.parseCompound()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpaces()
+			if !p.consume(")") {
+				return nil, p.errorf("found %s but expected \")\"", p.found())
+			}
+			c.not = append(c.not, not)
+		case p.consume(":has("):
+			has, err := p.parseSelector(true)
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpaces()
+			if !p.consume(")") {
+				return nil, p.errorf("found %s but expected \")\"", p.found())
+			}
+			c.has = append(c.has, has)
+		case p.peek() == ':':
+			return nil, p.errorf("found %q but expected :not( or :has(", p.text[p.pos:])
+		default:
+			return c, nil
+		}
+	}
+}
+
+// parsePredicate parses a predicate after "[".
+func (p *queryParser) parsePredicate() (*predicate, error) {
+	p.skipSpaces()
+	attribute, err := p.readAttribute()
+	if err != nil {
+		return nil, err
+	}
+	pred := &predicate{attribute: attribute}
+	if pred.attribute == "" {
+		return nil, p.errorf("found %s but expected an attribute", p.found())
+	}
+	p.skipSpaces()
+	for _, operator := range []string{"!=", "^=", "$=", "*=", "~=", "="} {
+		if p.consume(operator) {
+			pred.operator = operator
+			break
+		}
+	}
+	if pred.operator != "" {
+		p.skipSpaces()
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		pred.value = value
+		if pred.operator == "~=" {
+			pred.re, err = regexp.Compile(value)
+			if err != nil {
+				return nil, p.errorf("found the invalid regular expression %q", value)
+			}
+		}
+		p.skipSpaces()
+	}
+	if !p.consume("]") {
+		return nil, p.errorf("found %s but expected \"]\"", p.found())
+	}
+	return pred, nil
+}
+
+// readAttribute reads an attribute name, which may have an option name in parentheses like "option.(foo.bar)".
+// It fails if a parenthesis is not closed.
+func (p *queryParser) readAttribute() (string, error) {
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.text); p.pos++ {
+		b := p.text[p.pos]
+		if b == '(' {
+			depth++
+		} else if b == ')' && 0 < depth {
+			depth--
+		} else if !isNameByte(b) && b != '.' {
+			break
+		}
+	}
+	if 0 < depth {
+		return "", p.errorf("found %s but expected \")\"", p.found())
+	}
+	return p.text[start:p.pos], nil
+}
+
+// readValue reads a quoted string, or a word up to "]" or a space.
+func (p *queryParser) readValue() (string, error) {
+	if b := p.peek(); b == '"' || b == '\'' {
+		end := p.pos + 1
+		for ; end < len(p.text) && p.text[end] != b; end++ {
+			if p.text[end] == '\\' {
+				end++
+			}
+		}
+		if len(p.text) <= end {
+			return "", p.errorf("found an unterminated string")
+		}
+		quoted := p.text[p.pos : end+1]
+		p.pos = end + 1
+		if b == '\'' {
+			quoted = `"` + strings.ReplaceAll(quoted[1:len(quoted)-1], `"`, `\"`) + `"`
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return "", p.errorf("found the invalid string %s", quoted)
+		}
+		return value, nil
+	}
+	return p.readWhile(func(b byte) bool {
+		return b != ']' && !strings.ContainsRune(" \t\r\n", rune(b))
+	}), nil
+}
+
+func (p *queryParser) readWhile(match func(byte) bool) string {
+	start := p.pos
+	for p.pos < len(p.text) && match(p.text[p.pos]) {
+		p.pos++
+	}
+	return p.text[start:p.pos]
+}
+
+func isNameByte(b byte) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
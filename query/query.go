@@ -0,0 +1,538 @@
+// Package query selects the nodes of parsed files by selectors like CSS ones.
+//
+// A query is selectors separated by commas. A selector is compound selectors joined by spaces, which select
+// the descendants, or by ">", which selects the children:
+//
+//	message > field[type=google.protobuf.Timestamp]:not([option.deprecated=true])
+//	service rpc[response.stream=true], rpc[name^=List]
+//
+// A compound selector is a kind or "*" followed by predicates, ":not(compound)" and ":has(selector)".
+// The selector of :has is relative to the node, like ":has(> field)" for a node which has a field.
+//
+// The kinds are file, syntax, package, import, option, message, field, oneof, enum, value, reserved, extend,
+// service and rpc. A field is a field, a map field or a oneof field, and a value is an enum value.
+//
+// A predicate is [attribute] or [attribute operator value]. [attribute] holds if the attribute is neither
+// empty nor "false". The operators are = (equal), != (not equal), ^= (prefix), $= (suffix), *= (substring)
+// and ~= (regular expression). A value is a word or a quoted string.
+//
+// The attributes are listed in Attributes. The option.NAME attribute is the constant of the option NAME,
+// like option.deprecated or option.(foo.bar), of a field, an enum value, or a definition which has
+// option statements. The constants of the string options are compared without the quotes.
+package query
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/doc"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// Attributes are the attributes of the nodes of each kind, other than option.NAME. Every node has "comment",
+// which is the text of its leading comments.
+var Attributes = map[string][]string{
+	"file":     {"name", "package", "syntax"},
+	"syntax":   {"version"},
+	"package":  {"name"},
+	"import":   {"path", "modifier"},
+	"option":   {"name", "value"},
+	"message":  {"name", "fullname"},
+	"field":    {"name", "fullname", "type", "number", "label", "repeated", "key", "oneof"},
+	"oneof":    {"name"},
+	"enum":     {"name", "fullname"},
+	"value":    {"name", "number"},
+	"reserved": {"names", "numbers"},
+	"extend":   {"type"},
+	"service":  {"name", "fullname"},
+	"rpc":      {"name", "request", "response", "request.stream", "response.stream"},
+}
+
+var kinds = func() map[string]bool {
+	m := make(map[string]bool)
+	for kind := range Attributes {
+		m[kind] = true
+	}
+	return m
+}()
+
+// Query is a compiled query.
+type Query struct {
+	text      string
+	selectors []*selector
+}
+
+// Compile parses the query.
+func Compile(text string) (*Query, error) {
+	p := &queryParser{text: text}
+	selectors, err := p.parseSelectors()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(text) {
+		return nil, p.errorf("found %q but expected the end", text[p.pos:])
+	}
+	return &Query{text: text, selectors: selectors}, nil
+}
+
+// MustCompile is like Compile but panics if the query cannot be parsed.
+func MustCompile(text string) *Query {
+	q, err := Compile(text)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the text of the query.
+func (q *Query) String() string {
+	return q.text
+}
+
+// Match is a selected node.
+type Match struct {
+	// Node is the selected node.
+	Node parser.Visitee
+	// Parents are the enclosing nodes, from the *parser.Proto to the parent of Node.
+	Parents []parser.Visitee
+	// Kind is the kind of Node, like "message".
+	Kind string
+	// Name is the name of Node, like the name of a message or the path of an import. It is empty if Node has no name.
+	Name string
+	Pos  meta.Position
+}
+
+// Select returns the nodes of the files which match the query in the order of the files and the positions.
+func (q *Query) Select(protos ...*parser.Proto) []*Match {
+	var matches []*Match
+	for _, p := range protos {
+		walk(p, nil, func(n *node) {
+			for _, s := range q.selectors {
+				if s.match(len(s.compounds)-1, n, nil) {
+					matches = append(matches, &Match{
+						Node:    n.node,
+						Parents: parentNodes(n),
+						Kind:    n.kind,
+						Name:    n.attribute("name"),
+						Pos:     n.pos,
+					})
+					return
+				}
+			}
+		})
+	}
+	return matches
+}
+
+// node is a node with its kind and its parent.
+type node struct {
+	node   parser.Visitee
+	kind   string
+	pos    meta.Position
+	parent *node
+	// scope is the full name of the enclosing definitions and the package.
+	scope string
+}
+
+func parentNodes(n *node) []parser.Visitee {
+	var parents []parser.Visitee
+	for p := n.parent; p != nil; p = p.parent {
+		parents = append([]parser.Visitee{p.node}, parents...)
+	}
+	return parents
+}
+
+// walk calls fn for the node of v and its descendants in the order of the declaration.
+func walk(v parser.Visitee, parent *node, fn func(n *node)) {
+	n := newNode(v, parent)
+	if n == nil {
+		return
+	}
+	fn(n)
+	for _, child := range n.children() {
+		walk(child, n, fn)
+	}
+}
+
+func newNode(v parser.Visitee, parent *node) *node {
+	n := &node{node: v, parent: parent}
+	if parent != nil {
+		n.scope = parent.scope
+	}
+	switch v := v.(type) {
+	case *parser.Proto:
+		n.kind = "file"
+		if v.Meta != nil {
+			n.pos = meta.Position{Filename: v.Meta.Filename, Line: 1, Column: 1}
+		}
+		for _, body := range v.ProtoBody {
+			if p, ok := body.(*parser.Package); ok {
+				n.scope = p.Name
+			}
+		}
+	case *parser.Syntax:
+		n.kind, n.pos = "syntax", v.Meta.Pos
+	case *parser.Package:
+		n.kind, n.pos = "package", v.Meta.Pos
+	case *parser.Import:
+		n.kind, n.pos = "import", v.Meta.Pos
+	case *parser.Option:
+		n.kind, n.pos = "option", v.Meta.Pos
+	case *parser.Message:
+		n.kind, n.pos = "message", v.Meta.Pos
+		n.scope = qualify(n.scope, v.MessageName)
+	case *parser.Field:
+		n.kind, n.pos = "field", v.Meta.Pos
+	case *parser.MapField:
+		n.kind, n.pos = "field", v.Meta.Pos
+	case *parser.OneofField:
+		n.kind, n.pos = "field", v.Meta.Pos
+	case *parser.Oneof:
+		n.kind, n.pos = "oneof", v.Meta.Pos
+	case *parser.Enum:
+		n.kind, n.pos = "enum", v.Meta.Pos
+		n.scope = qualify(n.scope, v.EnumName)
+	case *parser.EnumField:
+		n.kind, n.pos = "value", v.Meta.Pos
+	case *parser.Reserved:
+		n.kind, n.pos = "reserved", v.Meta.Pos
+	case *parser.Extend:
+		n.kind, n.pos = "extend", v.Meta.Pos
+	case *parser.Service:
+		n.kind, n.pos = "service", v.Meta.Pos
+		n.scope = qualify(n.scope, v.ServiceName)
+	case *parser.RPC:
+		n.kind, n.pos = "rpc", v.Meta.Pos
+	default:
+		return nil
+	}
+	return n
+}
+
+func (n *node) children() []parser.Visitee {
+	var children []parser.Visitee
+	switch v := n.node.(type) {
+	case *parser.Proto:
+		if v.Syntax != nil {
+			children = append(children, v.Syntax)
+		}
+		children = append(children, v.ProtoBody...)
+	case *parser.Message:
+		children = v.MessageBody
+	case *parser.Enum:
+		children = v.EnumBody
+	case *parser.Extend:
+		children = v.ExtendBody
+	case *parser.Service:
+		children = v.ServiceBody
+	case *parser.Oneof:
+		for _, field := range v.OneofFields {
+			children = append(children, field)
+		}
+	case *parser.RPC:
+		for _, option := range v.Options {
+			children = append(children, option)
+		}
+	}
+	return children
+}
+
+// attribute returns the attribute of the node, or "" if it has none.
+func (n *node) attribute(name string) string {
+	if name == "comment" {
+		return doc.Of(n.node).Text()
+	}
+	if strings.HasPrefix(name, "option.") {
+		return n.option(strings.TrimPrefix(name, "option."))
+	}
+
+	switch v := n.node.(type) {
+	case *parser.Proto:
+		switch name {
+		case "name":
+			return n.pos.Filename
+		case "package":
+			return n.scope
+		case "syntax":
+			if v.Syntax != nil {
+				return v.Syntax.ProtobufVersion
+			}
+		}
+	case *parser.Syntax:
+		if name == "version" {
+			return v.ProtobufVersion
+		}
+	case *parser.Package:
+		if name == "name" {
+			return v.Name
+		}
+	case *parser.Import:
+		switch name {
+		case "name", "path":
+			return strings.Trim(v.Location, `"'`)
+		case "modifier":
+			switch v.Modifier {
+			case parser.ImportModifierPublic:
+				return "public"
+			case parser.ImportModifierWeak:
+				return "weak"
+			}
+		}
+	case *parser.Option:
+		switch name {
+		case "name":
+			return v.OptionName
+		case "value":
+			return constant(v.Constant)
+		}
+	case *parser.Message:
+		return definitionAttribute(name, v.MessageName, n.scope)
+	case *parser.Field:
+		label := ""
+		if v.IsRepeated {
+			label = "repeated"
+		}
+		return n.fieldAttribute(name, v.FieldName, v.Type, v.FieldNumber, label, "")
+	case *parser.MapField:
+		return n.fieldAttribute(name, v.MapName, v.Type, v.FieldNumber, "map", v.KeyType)
+	case *parser.OneofField:
+		return n.fieldAttribute(name, v.FieldName, v.Type, v.FieldNumber, "", "")
+	case *parser.Oneof:
+		if name == "name" {
+			return v.OneofName
+		}
+	case *parser.Enum:
+		return definitionAttribute(name, v.EnumName, n.scope)
+	case *parser.EnumField:
+		switch name {
+		case "name":
+			return v.Ident
+		case "number":
+			return normalizeNumber(v.Number)
+		}
+	case *parser.Reserved:
+		switch name {
+		case "names":
+			var names []string
+			for _, fieldName := range v.FieldNames {
+				names = append(names, strings.Trim(fieldName, `"'`))
+			}
+			return strings.Join(names, ",")
+		case "numbers":
+			var numbers []string
+			for _, r := range v.Ranges {
+				if r.End == "" {
+					numbers = append(numbers, normalizeNumber(r.Begin))
+				} else {
+					numbers = append(numbers, normalizeNumber(r.Begin)+"-"+normalizeNumber(r.End))
+				}
+			}
+			return strings.Join(numbers, ",")
+		}
+	case *parser.Extend:
+		if name == "type" {
+			return strings.TrimPrefix(v.MessageType, ".")
+		}
+	case *parser.Service:
+		return definitionAttribute(name, v.ServiceName, n.scope)
+	case *parser.RPC:
+		switch name {
+		case "name":
+			return v.RPCName
+		case "request":
+			return strings.TrimPrefix(v.RPCRequest.MessageType, ".")
+		case "response":
+			return strings.TrimPrefix(v.RPCResponse.MessageType, ".")
+		case "request.stream":
+			return strconv.FormatBool(v.RPCRequest.IsStream)
+		case "response.stream":
+			return strconv.FormatBool(v.RPCResponse.IsStream)
+		}
+	}
+	return ""
+}
+
+// definitionAttribute returns the attribute of a message, an enum or a service. scope includes the name.
+func definitionAttribute(name, definitionName, scope string) string {
+	switch name {
+	case "name":
+		return definitionName
+	case "fullname":
+		return scope
+	}
+	return ""
+}
+
+func (n *node) fieldAttribute(name, fieldName, typeName, number, label, keyType string) string {
+	switch name {
+	case "name":
+		return fieldName
+	case "fullname":
+		return qualify(n.scope, fieldName)
+	case "type":
+		return strings.TrimPrefix(typeName, ".")
+	case "number":
+		return normalizeNumber(number)
+	case "label":
+		return label
+	case "repeated":
+		return strconv.FormatBool(label == "repeated")
+	case "key":
+		return keyType
+	case "oneof":
+		if oneof, ok := n.parent.node.(*parser.Oneof); ok {
+			return oneof.OneofName
+		}
+	}
+	return ""
+}
+
+// option returns the constant of the option of the node.
+func (n *node) option(name string) string {
+	var body []parser.Visitee
+	switch v := n.node.(type) {
+	case *parser.Proto:
+		body = v.ProtoBody
+	case *parser.Message:
+		body = v.MessageBody
+	case *parser.Enum:
+		body = v.EnumBody
+	case *parser.Service:
+		body = v.ServiceBody
+	case *parser.RPC:
+		for _, opt := range v.Options {
+			body = append(body, opt)
+		}
+	case *parser.Field:
+		return fieldOption(v.FieldOptions, name)
+	case *parser.MapField:
+		return fieldOption(v.FieldOptions, name)
+	case *parser.OneofField:
+		return fieldOption(v.FieldOptions, name)
+	case *parser.EnumField:
+		for _, opt := range v.EnumValueOptions {
+			if opt.OptionName == name {
+				return constant(opt.Constant)
+			}
+		}
+	}
+	for _, v := range body {
+		if opt, ok := v.(*parser.Option); ok && opt.OptionName == name {
+			return constant(opt.Constant)
+		}
+	}
+	return ""
+}
+
+func fieldOption(options []*parser.FieldOption, name string) string {
+	for _, opt := range options {
+		if opt.OptionName == name {
+			return constant(opt.Constant)
+		}
+	}
+	return ""
+}
+
+// constant returns the constant of an option without the quotes of a string.
+func constant(c string) string {
+	if u, err := strconv.Unquote(c); err == nil {
+		return u
+	}
+	if 2 <= len(c) && c[0] == '\'' && c[len(c)-1] == '\'' {
+		return c[1 : len(c)-1]
+	}
+	return c
+}
+
+// match reports whether the compounds up to i match the node. scope is the node of :has which is evaluated.
+func (s *selector) match(i int, n *node, scope *node) bool {
+	if !s.compounds[i].match(n, scope) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if s.combinators[i-1] == child {
+		return n.parent != nil && s.match(i-1, n.parent, scope)
+	}
+	for p := n.parent; p != nil; p = p.parent {
+		if s.match(i-1, p, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compound) match(n *node, scope *node) bool {
+	if c.scope {
+		return n == scope
+	}
+	if c.kind != "" && c.kind != n.kind {
+		return false
+	}
+	for _, pred := range c.predicates {
+		if !pred.match(n.attribute(pred.attribute)) {
+			return false
+		}
+	}
+	for _, not := range c.not {
+		if not.match(n, scope) {
+			return false
+		}
+	}
+	for _, has := range c.has {
+		if !n.has(has) {
+			return false
+		}
+	}
+	return true
+}
+
+// has reports whether a descendant of the node matches the relative selector.
+func (n *node) has(s *selector) bool {
+	found := false
+	for _, child := range n.children() {
+		walk(child, n, func(d *node) {
+			if !found && s.match(len(s.compounds)-1, d, n) {
+				found = true
+			}
+		})
+	}
+	return found
+}
+
+func (pred *predicate) match(value string) bool {
+	switch pred.operator {
+	case "":
+		return value != "" && value != "false"
+	case "=":
+		return value == pred.value
+	case "!=":
+		return value != pred.value
+	case "^=":
+		return strings.HasPrefix(value, pred.value)
+	case "$=":
+		return strings.HasSuffix(value, pred.value)
+	case "*=":
+		return strings.Contains(value, pred.value)
+	case "~=":
+		return pred.re.MatchString(value)
+	}
+	return false
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func normalizeNumber(number string) string {
+	if n, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return strconv.FormatInt(n, 10)
+	}
+	return number
+}
@@ -0,0 +1,193 @@
+package query_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/query"
+)
+
+const itemProto = `syntax = "proto3";
+package item.v1;
+import "google/protobuf/timestamp.proto";
+option go_package = "example.com/item/v1";
+
+// Item is an item.
+message Item {
+  string name = 1;
+  google.protobuf.Timestamp created_at = 2;
+  .google.protobuf.Timestamp updated_at = 3 [deprecated = true];
+  map<string, int32> counts = 4;
+  oneof condition {
+    double score = 5;
+    google.protobuf.Timestamp sold_at = 6;
+  }
+  message Part {
+    repeated string tags = 1;
+  }
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_OK = 1 [deprecated = true];
+  }
+}
+`
+
+const serviceProto = `syntax = "proto3";
+package item.v1;
+
+service Items {
+  rpc GetItem(GetItemRequest) returns (Item);
+  rpc ListItems(ListItemsRequest) returns (stream Item) {
+    option (google.api.http).get = "/v1/items";
+  }
+  rpc Watch(stream WatchRequest) returns (stream Item);
+}
+`
+
+func parse(t *testing.T, filename, src string) *parser.Proto {
+	p, err := protoparser.Parse(strings.NewReader(src), protoparser.WithFilename(filename))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	return p
+}
+
+func TestQuery_Select(t *testing.T) {
+	protos := []*parser.Proto{
+		parse(t, "item.proto", itemProto),
+		parse(t, "service.proto", serviceProto),
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "kind",
+			query: "message",
+			want:  []string{"item.proto:7:1 message Item", "item.proto:16:3 message Part"},
+		},
+		{
+			name:  "child",
+			query: "file > message",
+			want:  []string{"item.proto:7:1 message Item"},
+		},
+		{
+			name:  "descendant",
+			query: "message[name=Item] field[repeated]",
+			want:  []string{"item.proto:17:5 field tags"},
+		},
+		{
+			name:  "type without option",
+			query: "field[type=google.protobuf.Timestamp]:not([option.deprecated=true])",
+			want:  []string{"item.proto:9:3 field created_at", "item.proto:14:5 field sold_at"},
+		},
+		{
+			name:  "oneof field",
+			query: "oneof > field[type$=Timestamp]",
+			want:  []string{"item.proto:14:5 field sold_at"},
+		},
+		{
+			name:  "map field",
+			query: "field[label=map][key=string]",
+			want:  []string{"item.proto:11:3 field counts"},
+		},
+		{
+			name:  "streaming responses",
+			query: "service rpc[response.stream=true]",
+			want:  []string{"service.proto:6:3 rpc ListItems", "service.proto:9:3 rpc Watch"},
+		},
+		{
+			name:  "selector list",
+			query: "rpc[name^=Get], rpc[request.stream]",
+			want:  []string{"service.proto:5:3 rpc GetItem", "service.proto:9:3 rpc Watch"},
+		},
+		{
+			name:  "has",
+			query: "rpc:has(> option[name=\"(google.api.http).get\"])",
+			want:  []string{"service.proto:6:3 rpc ListItems"},
+		},
+		{
+			name:  "option attribute",
+			query: `rpc[option.(google.api.http).get="/v1/items"]`,
+			want:  []string{"service.proto:6:3 rpc ListItems"},
+		},
+		{
+			name:  "regular expression",
+			query: "value[name~=^STATUS_(OK|FAILED)$]",
+			want:  []string{"item.proto:21:5 value STATUS_OK"},
+		},
+		{
+			name:  "full name and comment",
+			query: "*[fullname=item.v1.Item.Status], message[comment*=item]",
+			want:  []string{"item.proto:7:1 message Item", "item.proto:19:3 enum Status"},
+		},
+		{
+			name:  "file option",
+			query: "file[option.go_package^=example.com] > import[path=google/protobuf/timestamp.proto]",
+			want:  []string{"item.proto:3:1 import google/protobuf/timestamp.proto"},
+		},
+		{
+			name:  "message without a field of a type",
+			query: "message:not(:has(field[type=string]))",
+			want:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			q, err := query.Compile(test.query)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			var got []string
+			for _, m := range q.Select(protos...) {
+				got = append(got, m.Pos.String()+" "+m.Kind+" "+m.Name)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompile_errors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"messages",
+		"message[",
+		"message[name=Item",
+		"message[=Item]",
+		"message:first",
+		"message:not([name]",
+		"message[name~=(]",
+		"message >",
+		"message, ",
+		`message[name="Item]`,
+		"field[option.(foo]",
+		"field[option.(foo.bar]",
+	} {
+		if _, err := query.Compile(text); err == nil {
+			t.Errorf("got nil, but want an error for %q", text)
+		}
+	}
+}
+
+func TestCompile_errorsAtTheEnd(t *testing.T) {
+	for _, text := range []string{
+		"message[",
+		"message[name=Item",
+		"message:not([name]",
+		"field[option.(foo",
+	} {
+		_, err := query.Compile(text)
+		if err == nil || !strings.Contains(err.Error(), "found the end of the query") {
+			t.Errorf("got %v, but want the end of the query to be found in %q", err, text)
+		}
+	}
+}
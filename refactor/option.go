@@ -0,0 +1,85 @@
+package refactor
+
+import (
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/schema"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// optionRef is an option name which refers to an extension, like "(foo.bar).baz.qux".
+type optionRef struct {
+	file *schema.File
+	// pos is the position of the statement which has the option.
+	pos meta.Position
+	// extensionName are the components of the name in the parentheses without a leading dot.
+	extensionName []string
+	// extension is the resolved extension field, or nil if it is not in the schema.
+	extension         *schema.Field
+	extensionFullName string
+	// fieldNames are the names following the parentheses, and fields are the fields resolved from the type
+	// of the extension. fields is shorter than fieldNames if a name is not resolved.
+	fieldNames []string
+	fields     []*schema.Field
+	source     func(path string) (*source, error)
+}
+
+// find returns the tokens of the extension name and the field names.
+func (ref *optionRef) find() ([]*tokenizer.Item, []*tokenizer.Item, error) {
+	s, err := ref.source(ref.file.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.optionName(ref.pos, ref.extensionName, ref.fieldNames)
+}
+
+// eachOption calls fn for every option name of the schema which refers to an extension.
+func (r *Renamer) eachOption(fn func(ref *optionRef) error) error {
+	for _, extensionRef := range r.schema.ExtensionRefs {
+		ref := r.optionRef(extensionRef)
+		if ref == nil {
+			continue
+		}
+		if err := fn(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// optionRef returns the reference of the option name resolved by the schema, or nil if the name has a nested
+// extension.
+func (r *Renamer) optionRef(extensionRef *schema.ExtensionRef) *optionRef {
+	ref := &optionRef{
+		file:              extensionRef.File,
+		pos:               extensionRef.Pos,
+		extensionName:     strings.Split(strings.TrimPrefix(extensionRef.Name, "."), "."),
+		extension:         extensionRef.Extension,
+		extensionFullName: extensionRef.FullName,
+		source:            r.source,
+	}
+	rest := extensionRef.Option[strings.Index(extensionRef.Option, ")")+1:]
+	if rest = strings.TrimPrefix(rest, "."); rest != "" {
+		if strings.Contains(rest, "(") {
+			// The nested extensions are not supported.
+			return nil
+		}
+		ref.fieldNames = strings.Split(rest, ".")
+	}
+	if ref.extension == nil {
+		return ref
+	}
+	field := ref.extension
+	for _, fieldName := range ref.fieldNames {
+		if field.Type.Message == nil {
+			break
+		}
+		field = findField(field.Type.Message, fieldName)
+		if field == nil {
+			break
+		}
+		ref.fields = append(ref.fields, field)
+	}
+	return ref
+}
@@ -0,0 +1,346 @@
+// Package refactor computes the text edits of refactorings over a linked schema, like renaming a type or a field.
+//
+// The edits replace only the names and insert only the new statements, so that the formatting and the comments
+// of the files are kept. The references written in the constants of the options, like the fields of the
+// google.api.http rules, are not updated.
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/schema"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Renamer renames the definitions of a schema.
+type Renamer struct {
+	schema *schema.Schema
+	// sources are the sources of the files keyed by their paths.
+	sources   map[string][]byte
+	tokenized map[string]*source
+}
+
+// NewRenamer returns a Renamer of the schema. The sources are keyed by the paths of the files of the schema,
+// and are needed for the files which refer to the renamed definitions.
+func NewRenamer(s *schema.Schema, sources map[string][]byte) *Renamer {
+	return &Renamer{
+		schema:    s,
+		sources:   sources,
+		tokenized: make(map[string]*source),
+	}
+}
+
+// edits are the edits of the files keyed by their paths.
+type edits map[string][]*edit.Edit
+
+func (e edits) replace(path string, item *tokenizer.Item, text string) {
+	e.insert(path, &edit.Edit{Start: item.Pos.Offset, End: item.End.Offset, NewText: text})
+}
+
+func (e edits) insert(path string, change *edit.Edit) {
+	for _, other := range e[path] {
+		if other.Start == change.Start {
+			return
+		}
+	}
+	e[path] = append(e[path], change)
+}
+
+// RenameType returns the edits to rename the message or the enum of the full name, like "foo.bar.Outer.Inner",
+// to the new name in the same scope. It updates the references to the type and to the types and the extensions
+// nested in it, whether they are qualified or not. The edited files are linked again to verify that every
+// reference still refers to the same definition.
+func (r *Renamer) RenameType(fullName, newName string) (map[string][]*edit.Edit, error) {
+	fullName = strings.TrimPrefix(fullName, ".")
+	var f *schema.File
+	var pos meta.Position
+	if m := r.schema.Message(fullName); m != nil {
+		f, pos = m.File, m.Node.Meta.Pos
+	} else if e := r.schema.Enum(fullName); e != nil {
+		f, pos = e.File, e.Node.Meta.Pos
+	} else {
+		return nil, fmt.Errorf("found %q but expected a message or an enum", fullName)
+	}
+	if !identifier.MatchString(newName) {
+		return nil, fmt.Errorf("found %q but expected an identifier", newName)
+	}
+	newFullName := schema.Qualify(schema.ParentScope(fullName), newName)
+	if newFullName == fullName {
+		return edits{}, nil
+	}
+	if r.schema.Message(newFullName) != nil || r.schema.Enum(newFullName) != nil || r.schema.Service(newFullName) != nil {
+		return nil, fmt.Errorf("found %q already defined", newFullName)
+	}
+
+	e := make(edits)
+	s, err := r.source(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.definitionName(pos)
+	if err != nil {
+		return nil, err
+	}
+	e.replace(f.Path, item, newName)
+
+	depth := len(strings.Split(fullName, "."))
+	for _, ref := range r.schema.Refs {
+		if (ref.Message == nil && ref.Enum == nil) || !schema.IsWithin(ref.FullName, fullName) {
+			continue
+		}
+		written := strings.Split(strings.TrimPrefix(ref.Name, "."), ".")
+		// The written name is the last components of the full name.
+		i := depth - 1 - (len(strings.Split(ref.FullName, ".")) - len(written))
+		if i < 0 {
+			continue
+		}
+		s, err := r.source(ref.File.Path)
+		if err != nil {
+			return nil, err
+		}
+		items, err := s.typeName(ref.Pos, written)
+		if err != nil {
+			return nil, err
+		}
+		e.replace(ref.File.Path, items[i], newName)
+	}
+
+	err = r.eachOption(func(ref *optionRef) error {
+		if ref.extension == nil || !schema.IsWithin(ref.extensionFullName, fullName) {
+			return nil
+		}
+		i := depth - 1 - (len(strings.Split(ref.extensionFullName, ".")) - len(ref.extensionName))
+		if i < 0 {
+			return nil
+		}
+		items, _, err := ref.find()
+		if err != nil {
+			return err
+		}
+		e.replace(ref.file.Path, items[i], newName)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rename := func(name string) string {
+		if schema.IsWithin(name, fullName) {
+			return newFullName + strings.TrimPrefix(name, fullName)
+		}
+		return name
+	}
+	if err := r.verify(e, rename); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// RenameField returns the edits to rename the field of the full name, like "foo.bar.Outer.field", or the
+// extension field of the full name, like "foo.bar.my_option". The old name of a field of a message is reserved
+// by a statement inserted before the field, or before its oneof. It updates the option names which refer to
+// the field, like "(foo.bar.my_option).field".
+func (r *Renamer) RenameField(fullName, newName string) (map[string][]*edit.Edit, error) {
+	fullName = strings.TrimPrefix(fullName, ".")
+	target := r.schema.Extension(fullName)
+	var m *schema.Message
+	if target == nil {
+		m = r.schema.Message(schema.ParentScope(fullName))
+		if m != nil {
+			target = findField(m, fullName[strings.LastIndex(fullName, ".")+1:])
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("found %q but expected a field", fullName)
+	}
+	if !identifier.MatchString(newName) {
+		return nil, fmt.Errorf("found %q but expected an identifier", newName)
+	}
+	if newName == target.Name {
+		return edits{}, nil
+	}
+	if m != nil {
+		if err := checkFieldName(m, newName); err != nil {
+			return nil, err
+		}
+	} else if r.schema.Extension(schema.Qualify(schema.ParentScope(fullName), newName)) != nil {
+		return nil, fmt.Errorf("found %q already defined", schema.Qualify(schema.ParentScope(fullName), newName))
+	}
+
+	f := fieldFile(target)
+	s, err := r.source(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	pos, comments := fieldPos(target)
+	item, err := s.fieldName(pos)
+	if err != nil {
+		return nil, err
+	}
+	e := make(edits)
+	e.replace(f.Path, item, newName)
+
+	if m != nil {
+		if target.Oneof != nil {
+			pos, comments = target.Oneof.Node.Meta.Pos, target.Oneof.Node.Comments
+		}
+		if 0 < len(comments) {
+			pos = comments[0].Meta.Pos
+		}
+		e.insert(f.Path, reserve(s.Src, pos.Offset, target.Name))
+	}
+
+	err = r.eachOption(func(ref *optionRef) error {
+		extension := ref.extension == target
+		var fields []int
+		for i, field := range ref.fields {
+			if field == target {
+				fields = append(fields, i)
+			}
+		}
+		if !extension && len(fields) == 0 {
+			return nil
+		}
+		extensionItems, fieldItems, err := ref.find()
+		if err != nil {
+			return err
+		}
+		if extension {
+			e.replace(ref.file.Path, extensionItems[len(extensionItems)-1], newName)
+		}
+		for _, i := range fields {
+			e.replace(ref.file.Path, fieldItems[i], newName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.verify(e, func(name string) string { return name }); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// reserve returns the edit to insert a reserved statement of the name at the offset. The statement is placed
+// on its own line with the same indentation if the offset starts a line.
+func reserve(src []byte, offset int, name string) *edit.Edit {
+	statement := "reserved " + strconv.Quote(name) + ";"
+	lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+	indent := string(src[lineStart:offset])
+	if strings.TrimSpace(indent) == "" {
+		return &edit.Edit{Start: offset, End: offset, NewText: statement + "\n" + indent}
+	}
+	return &edit.Edit{Start: offset, End: offset, NewText: statement + " "}
+}
+
+func findField(m *schema.Message, name string) *schema.Field {
+	for _, field := range m.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func checkFieldName(m *schema.Message, name string) error {
+	if findField(m, name) != nil {
+		return fmt.Errorf("found the field %q already in %s", name, m.FullName)
+	}
+	for _, oneof := range m.Oneofs {
+		if oneof.Name == name {
+			return fmt.Errorf("found the oneof %q already in %s", name, m.FullName)
+		}
+	}
+	if m.IsReservedName(name) {
+		return fmt.Errorf("found %q reserved in %s", name, m.FullName)
+	}
+	return nil
+}
+
+func fieldFile(field *schema.Field) *schema.File {
+	if field.Extend != nil {
+		return field.Extend.File
+	}
+	return field.Parent.File
+}
+
+// fieldPos returns the position and the comments of the node of the field.
+func fieldPos(field *schema.Field) (meta.Position, []*parser.Comment) {
+	switch n := field.Node.(type) {
+	case *parser.Field:
+		return n.Meta.Pos, n.Comments
+	case *parser.MapField:
+		return n.Meta.Pos, n.Comments
+	case *parser.OneofField:
+		return n.Meta.Pos, n.Comments
+	}
+	return meta.Position{}, nil
+}
+
+// verify links the edited files again, and returns an error if they cannot be parsed or a resolved reference
+// does not refer to the renamed full name of its definition.
+func (r *Renamer) verify(e edits, rename func(string) string) error {
+	edited, err := edit.ApplyFiles(r.sources, e)
+	if err != nil {
+		return err
+	}
+	var results []*protoparser.FileResult
+	for _, f := range r.schema.Files {
+		result := &protoparser.FileResult{
+			Path:     f.Path,
+			Proto:    f.Proto,
+			Imported: f.Imported,
+		}
+		for _, imp := range f.Imports {
+			result.Imports = append(result.Imports, imp.Path)
+		}
+		if src, ok := edited[f.Path]; ok {
+			result.Proto, err = protoparser.Parse(bytes.NewReader(src), protoparser.WithFilename(f.Path))
+			if err != nil {
+				return fmt.Errorf("failed to parse the renamed %s, err %w", f.Path, err)
+			}
+		}
+		results = append(results, result)
+	}
+
+	// The errors are ignored since the same errors are in the original schema.
+	s, _ := schema.Link(results)
+	if len(s.Refs) != len(r.schema.Refs) {
+		return fmt.Errorf("found %d references after renaming but expected %d", len(s.Refs), len(r.schema.Refs))
+	}
+	for i, ref := range r.schema.Refs {
+		if ref.Message == nil && ref.Enum == nil {
+			continue
+		}
+		got, want := s.Refs[i], rename(ref.FullName)
+		if !got.Resolved() || got.FullName != want {
+			return fmt.Errorf("%s: found %q referring to %q after renaming but expected %q", got.Pos, got.Name, got.FullName, want)
+		}
+	}
+	return nil
+}
+
+func (r *Renamer) source(path string) (*source, error) {
+	if s, ok := r.tokenized[path]; ok {
+		return s, nil
+	}
+	src, ok := r.sources[path]
+	if !ok {
+		return nil, fmt.Errorf("found no source of %s", path)
+	}
+	s := newSource(src)
+	r.tokenized[path] = s
+	return s, nil
+}
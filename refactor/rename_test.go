@@ -0,0 +1,245 @@
+package refactor_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/refactor"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var testFS = fstest.MapFS{
+	"foo/bar/a.proto": {Data: []byte(`syntax = "proto3";
+package foo.bar;
+import "foo/baz/b.proto";
+
+option (foo.baz.file_label) = "a";
+
+message Outer {
+  message Inner {
+    baz.Shared.Kind kind = 1;
+  }
+  // The shared one.
+  baz.Shared shared = 1; // inline
+  .foo.baz.Shared qualified = 2;
+  map<string, foo.baz.Shared> shared_map = 3 [(foo.baz.Shared.label) = "m"];
+  oneof choice {
+    Inner inner = 4;
+    string text = 5;
+  }
+}
+
+service Service {
+  rpc Get(stream baz.Shared) returns (Outer) {
+    option (baz.rule).path = "/v1/get";
+  }
+}
+`)},
+	"foo/baz/b.proto": {Data: []byte(`syntax = "proto3";
+package foo.baz;
+import "google/protobuf/descriptor.proto";
+
+message Shared {
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+  }
+  extend google.protobuf.FieldOptions {
+    string label = 50000;
+  }
+  Kind kind = 1;
+}
+
+message Rule {
+  string path = 1;
+}
+
+extend google.protobuf.FileOptions {
+  string file_label = 50001;
+}
+
+extend google.protobuf.MethodOptions {
+  Rule rule = 50002;
+}
+`)},
+	"google/protobuf/descriptor.proto": {Data: []byte(`syntax = "proto3";
+package google.protobuf;
+message FieldOptions {}
+message FileOptions {}
+message MethodOptions {}
+`)},
+}
+
+func load(t *testing.T) *refactor.Renamer {
+	s, err := schema.Load(context.Background(), testFS, []string{"foo/bar/a.proto"}, protoparser.WithImportPaths("."))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	sources := make(map[string][]byte)
+	for path, file := range testFS {
+		sources[path] = file.Data
+	}
+	return refactor.NewRenamer(s, sources)
+}
+
+func TestRenamer_RenameType(t *testing.T) {
+	edits, err := load(t).RenameType("foo.baz.Shared", "Common")
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	sources := map[string][]byte{
+		"foo/bar/a.proto": testFS["foo/bar/a.proto"].Data,
+		"foo/baz/b.proto": testFS["foo/baz/b.proto"].Data,
+	}
+	got, err := edit.ApplyFiles(sources, edits)
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	wantA := `syntax = "proto3";
+package foo.bar;
+import "foo/baz/b.proto";
+
+option (foo.baz.file_label) = "a";
+
+message Outer {
+  message Inner {
+    baz.Common.Kind kind = 1;
+  }
+  // The shared one.
+  baz.Common shared = 1; // inline
+  .foo.baz.Common qualified = 2;
+  map<string, foo.baz.Common> shared_map = 3 [(foo.baz.Common.label) = "m"];
+  oneof choice {
+    Inner inner = 4;
+    string text = 5;
+  }
+}
+
+service Service {
+  rpc Get(stream baz.Common) returns (Outer) {
+    option (baz.rule).path = "/v1/get";
+  }
+}
+`
+	if string(got["foo/bar/a.proto"]) != wantA {
+		t.Errorf("got %s, but want %s", got["foo/bar/a.proto"], wantA)
+	}
+	// The references to Kind inside Shared are not qualified by Shared.
+	wantB := `syntax = "proto3";
+package foo.baz;
+import "google/protobuf/descriptor.proto";
+
+message Common {
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+  }
+  extend google.protobuf.FieldOptions {
+    string label = 50000;
+  }
+  Kind kind = 1;
+}
+`
+	if b := string(got["foo/baz/b.proto"]); b[:len(wantB)] != wantB {
+		t.Errorf("got %s, but want %s", b, wantB)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d files, but want 2", len(got))
+	}
+}
+
+func TestRenamer_RenameField(t *testing.T) {
+	tests := []struct {
+		name     string
+		fullName string
+		newName  string
+		path     string
+		want     string
+	}{
+		{
+			name:     "field",
+			fullName: "foo.bar.Outer.shared",
+			newName:  "common",
+			path:     "foo/bar/a.proto",
+			want: `  reserved "shared";
+  // The shared one.
+  baz.Shared common = 1; // inline`,
+		},
+		{
+			name:     "oneof field",
+			fullName: "foo.bar.Outer.text",
+			newName:  "body",
+			path:     "foo/bar/a.proto",
+			want: `  reserved "text";
+  oneof choice {
+    Inner inner = 4;
+    string body = 5;`,
+		},
+		{
+			name:     "extension",
+			fullName: "foo.baz.rule",
+			newName:  "http",
+			path:     "foo/bar/a.proto",
+			want:     `option (baz.http).path = "/v1/get";`,
+		},
+		{
+			name:     "field of an extension",
+			fullName: "foo.baz.Rule.path",
+			newName:  "pattern",
+			path:     "foo/bar/a.proto",
+			want:     `option (baz.rule).pattern = "/v1/get";`,
+		},
+		{
+			name:     "field of the extension type",
+			fullName: "foo.baz.Rule.path",
+			newName:  "pattern",
+			path:     "foo/baz/b.proto",
+			want: `message Rule {
+  reserved "path";
+  string pattern = 1;
+}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := load(t)
+			edits, err := r.RenameField(test.fullName, test.newName)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			got, err := edit.ApplyFiles(map[string][]byte{
+				"foo/bar/a.proto": testFS["foo/bar/a.proto"].Data,
+				"foo/baz/b.proto": testFS["foo/baz/b.proto"].Data,
+			}, edits)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if !strings.Contains(string(got[test.path]), test.want) {
+				t.Errorf("got %s, but want it to contain %s", got[test.path], test.want)
+			}
+		})
+	}
+}
+
+func TestRenamer_errors(t *testing.T) {
+	r := load(t)
+	for name, f := range map[string]func() error{
+		"unknown type":        func() error { _, err := r.RenameType("foo.bar.Missing", "Other"); return err },
+		"invalid name":        func() error { _, err := r.RenameType("foo.bar.Outer", "1Outer"); return err },
+		"defined type":        func() error { _, err := r.RenameType("foo.baz.Shared", "Rule"); return err },
+		"shadowing type":      func() error { _, err := r.RenameType("foo.bar.Outer.Inner", "baz"); return err },
+		"unknown field":       func() error { _, err := r.RenameField("foo.bar.Outer.missing", "other"); return err },
+		"duplicate field":     func() error { _, err := r.RenameField("foo.bar.Outer.shared", "qualified"); return err },
+		"name of a oneof":     func() error { _, err := r.RenameField("foo.bar.Outer.shared", "choice"); return err },
+		"duplicate extension": func() error { _, err := r.RenameField("foo.baz.rule", "file_label"); return err },
+	} {
+		if err := f(); err == nil {
+			t.Errorf("got nil, but want an error for %s", name)
+		}
+	}
+}
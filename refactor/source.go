@@ -0,0 +1,132 @@
+package refactor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/edit"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// source is the tokenized source of a file, which finds the tokens of the names in its statements.
+type source struct {
+	*edit.Source
+}
+
+func newSource(src []byte) *source {
+	return &source{Source: edit.NewSource(src)}
+}
+
+// start returns the index of the token at pos.
+func (s *source) start(pos meta.Position) (int, error) {
+	i, ok := s.Index(pos.Offset)
+	if !ok {
+		return 0, fmt.Errorf("found no token at %s", pos)
+	}
+	return i, nil
+}
+
+// definitionName returns the token of the name of the message or the enum at pos, which follows the keyword.
+func (s *source) definitionName(pos meta.Position) (*tokenizer.Item, error) {
+	i, err := s.start(pos)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Items) <= i+1 || s.Items[i+1].Token != tokenizer.TIDENT {
+		return nil, fmt.Errorf("found no name at %s", pos)
+	}
+	return s.Items[i+1], nil
+}
+
+// fieldName returns the token of the name of the field at pos, which precedes "=".
+func (s *source) fieldName(pos meta.Position) (*tokenizer.Item, error) {
+	i, err := s.start(pos)
+	if err != nil {
+		return nil, err
+	}
+	for ; i+1 < len(s.Items) && !s.isEnd(i); i++ {
+		if s.Items[i+1].Token == tokenizer.TEQUALS {
+			return s.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("found no field name at %s", pos)
+}
+
+// typeName returns the tokens of the components of the type name written in the statement at pos.
+func (s *source) typeName(pos meta.Position, components []string) ([]*tokenizer.Item, error) {
+	i, err := s.start(pos)
+	if err != nil {
+		return nil, err
+	}
+	for ; i < len(s.Items) && !s.isEnd(i); i++ {
+		if 1 < i && s.Items[i-1].Token == tokenizer.TDOT && s.Items[i-2].Token == tokenizer.TIDENT {
+			// It is in the middle of a name.
+			continue
+		}
+		if items, ok := s.match(i, components); ok {
+			return items, nil
+		}
+	}
+	return nil, fmt.Errorf("found no type %q at %s", strings.Join(components, "."), pos)
+}
+
+// optionName returns the tokens of the components of the extension in parentheses and the following field
+// names, which are written in the statement at pos, like "(foo.bar).baz".
+func (s *source) optionName(pos meta.Position, extension, fields []string) ([]*tokenizer.Item, []*tokenizer.Item, error) {
+	i, err := s.start(pos)
+	if err != nil {
+		return nil, nil, err
+	}
+	for ; i+1 < len(s.Items) && !s.isEnd(i); i++ {
+		if s.Items[i].Token != tokenizer.TLEFTPAREN {
+			continue
+		}
+		j := i + 1
+		if s.Items[j].Token == tokenizer.TDOT {
+			j++
+		}
+		extensionItems, ok := s.match(j, extension)
+		j += 2 * len(extension)
+		if !ok || len(s.Items) <= j || s.Items[j-1].Token != tokenizer.TRIGHTPAREN {
+			continue
+		}
+		var fieldItems []*tokenizer.Item
+		for _, field := range fields {
+			if len(s.Items) <= j+1 || s.Items[j].Token != tokenizer.TDOT || s.Items[j+1].Text != field {
+				break
+			}
+			fieldItems = append(fieldItems, s.Items[j+1])
+			j += 2
+		}
+		if len(fieldItems) == len(fields) {
+			return extensionItems, fieldItems, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("found no option (%s) at %s", strings.Join(extension, "."), pos)
+}
+
+// match returns the tokens of the components if the tokens from i are the components joined by dots.
+func (s *source) match(i int, components []string) ([]*tokenizer.Item, bool) {
+	var items []*tokenizer.Item
+	for k, component := range components {
+		j := i + 2*k
+		if len(s.Items) <= j || s.Items[j].Token != tokenizer.TIDENT || s.Items[j].Text != component {
+			return nil, false
+		}
+		if 0 < k && s.Items[j-1].Token != tokenizer.TDOT {
+			return nil, false
+		}
+		items = append(items, s.Items[j])
+	}
+	return items, true
+}
+
+// isEnd reports whether the token at i ends a statement or starts a body.
+func (s *source) isEnd(i int) bool {
+	switch s.Items[i].Token {
+	case tokenizer.TSEMICOLON, tokenizer.TLEFTCURLY, tokenizer.TEOF:
+		return true
+	}
+	return false
+}
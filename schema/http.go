@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// HTTPOptionName is the name of the option which binds an RPC to an HTTP method and path.
+// See https://github.com/googleapis/googleapis/blob/master/google/api/http.proto
+const HTTPOptionName = "(google.api.http)"
+
+// HTTPRule is an HTTP binding of an RPC.
+type HTTPRule struct {
+	// Method is the upper-cased HTTP method, like "GET", or the kind of a custom pattern.
+	Method string
+	// Path is the path template, like "/v1/{name=shelves/*}".
+	Path string
+	// Body is the request field mapped to the HTTP request body, "*" for the whole request, or empty for no body.
+	Body string
+	// ResponseBody is the response field mapped to the HTTP response body, or empty for the whole response.
+	ResponseBody string
+}
+
+var customPattern = regexp.MustCompile(`(kind|path):\s*("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`)
+
+// HTTPRules returns the HTTP bindings of the google.api.http options of the RPC, including the additional bindings.
+func (r *RPC) HTTPRules() []*HTTPRule {
+	var rules []*HTTPRule
+	for _, option := range r.Options {
+		if option.OptionName != HTTPOptionName || option.Endpoint == nil {
+			continue
+		}
+		rule := &HTTPRule{}
+		for _, field := range option.Endpoint.Fields {
+			rule.set(field.OptionName, field.Constant)
+		}
+		if rule.Method != "" {
+			rules = append(rules, rule)
+		}
+
+		for _, binding := range option.Endpoint.AdditionalBinding {
+			additional := &HTTPRule{}
+			for _, field := range binding.Fields {
+				if 0 < len(field.Values) {
+					additional.set(field.Name, field.Values[0])
+				}
+			}
+			if additional.Method != "" {
+				rules = append(rules, additional)
+			}
+		}
+	}
+	return rules
+}
+
+// set sets the field of the HttpRule message.
+func (rule *HTTPRule) set(name string, constant string) {
+	switch name {
+	case "get", "put", "post", "delete", "patch":
+		rule.Method = strings.ToUpper(name)
+		rule.Path = unquote(constant)
+	case "custom":
+		for _, m := range customPattern.FindAllStringSubmatch(constant, -1) {
+			if m[1] == "kind" {
+				rule.Method = unquote(m[2])
+			} else {
+				rule.Path = unquote(m[2])
+			}
+		}
+	case "body":
+		rule.Body = unquote(constant)
+	case "response_body":
+		rule.ResponseBody = unquote(constant)
+	}
+}
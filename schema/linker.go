@@ -0,0 +1,408 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+type linker struct {
+	schema *Schema
+	errs   []error
+	// pending are the references to resolve with their scopes, in the order of the declaration.
+	pending []pendingRef
+	// pendingExtensions are the references to extensions to resolve with their scopes.
+	pendingExtensions []pendingExtensionRef
+}
+
+type pendingRef struct {
+	ref   *Ref
+	scope string
+}
+
+type pendingExtensionRef struct {
+	ref   *ExtensionRef
+	scope string
+}
+
+// define adds the definitions of the file to the schema.
+func (l *linker) define(result *protoparser.FileResult) {
+	f := &File{
+		Path:     result.Path,
+		Proto:    result.Proto,
+		Imported: result.Imported,
+	}
+	l.schema.Files = append(l.schema.Files, f)
+	l.schema.files[f.Path] = f
+
+	for _, body := range f.Proto.ProtoBody {
+		if p, ok := body.(*parser.Package); ok {
+			f.Package = p.Name
+		}
+	}
+	for name := f.Package; name != ""; name = ParentScope(name) {
+		l.schema.packages[name] = true
+	}
+
+	for _, body := range f.Proto.ProtoBody {
+		switch b := body.(type) {
+		case *parser.Import:
+			f.Imports = append(f.Imports, &Import{
+				Path: strings.Trim(b.Location, `"'`),
+				Node: b,
+			})
+		case *parser.Option:
+			f.Options = append(f.Options, b)
+			l.option(f, b.OptionName, b.Meta.Pos, f.Package)
+		case *parser.Message:
+			f.Messages = append(f.Messages, l.defineMessage(f, nil, f.Package, b))
+		case *parser.Enum:
+			f.Enums = append(f.Enums, l.defineEnum(f, nil, f.Package, b))
+		case *parser.Service:
+			f.Services = append(f.Services, l.defineService(f, b))
+		case *parser.Extend:
+			f.Extends = append(f.Extends, l.defineExtend(f, nil, f.Package, b))
+		}
+	}
+}
+
+func (l *linker) defineMessage(f *File, parent *Message, scope string, node *parser.Message) *Message {
+	m := &Message{
+		Name:     node.MessageName,
+		FullName: Qualify(scope, node.MessageName),
+		Parent:   parent,
+		File:     f,
+		Node:     node,
+	}
+	if l.isDefined(m.FullName) {
+		l.duplicate(m.FullName, node.Meta.Pos)
+	} else {
+		l.schema.messages[m.FullName] = m
+	}
+
+	for _, body := range node.MessageBody {
+		switch b := body.(type) {
+		case *parser.Field:
+			m.Fields = append(m.Fields, l.defineField(f, m, b, b.FieldName, b.FieldNumber, b.Type, b.FieldOptions, b.Meta.Pos))
+			m.Fields[len(m.Fields)-1].Repeated = b.IsRepeated
+		case *parser.MapField:
+			field := l.defineField(f, m, b, b.MapName, b.FieldNumber, b.Type, b.FieldOptions, b.Meta.Pos)
+			field.KeyType = b.KeyType
+			m.Fields = append(m.Fields, field)
+		case *parser.Oneof:
+			oneof := &Oneof{
+				Name:   b.OneofName,
+				Parent: m,
+				Node:   b,
+			}
+			for _, of := range b.OneofFields {
+				field := l.defineField(f, m, of, of.FieldName, of.FieldNumber, of.Type, of.FieldOptions, of.Meta.Pos)
+				field.Oneof = oneof
+				oneof.Fields = append(oneof.Fields, field)
+				m.Fields = append(m.Fields, field)
+			}
+			m.Oneofs = append(m.Oneofs, oneof)
+		case *parser.Message:
+			m.Messages = append(m.Messages, l.defineMessage(f, m, m.FullName, b))
+		case *parser.Enum:
+			m.Enums = append(m.Enums, l.defineEnum(f, m, m.FullName, b))
+		case *parser.Extend:
+			m.Extends = append(m.Extends, l.defineExtend(f, m, m.FullName, b))
+		case *parser.Option:
+			m.Options = append(m.Options, b)
+			l.option(f, b.OptionName, b.Meta.Pos, m.FullName)
+		case *parser.Reserved:
+			m.Reserved = append(m.Reserved, b)
+		}
+	}
+	return m
+}
+
+func (l *linker) defineField(
+	f *File,
+	parent *Message,
+	node parser.Visitee,
+	name string,
+	number string,
+	typeName string,
+	options []*parser.FieldOption,
+	pos meta.Position,
+) *Field {
+	field := &Field{
+		Name:     name,
+		Number:   l.number(number, pos),
+		JSONName: JSONName(name),
+		Options:  options,
+		Parent:   parent,
+		Node:     node,
+	}
+	scope := f.Package
+	if parent != nil {
+		scope = parent.FullName
+	}
+	for _, option := range options {
+		if option.OptionName == "json_name" {
+			field.JSONName = unquote(option.Constant)
+		}
+		l.option(f, option.OptionName, pos, scope)
+	}
+	field.Type = l.ref(f, node, typeName, pos, scope)
+	return field
+}
+
+func (l *linker) defineEnum(f *File, parent *Message, scope string, node *parser.Enum) *Enum {
+	e := &Enum{
+		Name:     node.EnumName,
+		FullName: Qualify(scope, node.EnumName),
+		Parent:   parent,
+		File:     f,
+		Node:     node,
+	}
+	if l.isDefined(e.FullName) {
+		l.duplicate(e.FullName, node.Meta.Pos)
+	} else {
+		l.schema.enums[e.FullName] = e
+	}
+
+	for _, body := range node.EnumBody {
+		switch b := body.(type) {
+		case *parser.EnumField:
+			e.Values = append(e.Values, &EnumValue{
+				Name:   b.Ident,
+				Number: l.number(b.Number, b.Meta.Pos),
+				Parent: e,
+				Node:   b,
+			})
+			for _, option := range b.EnumValueOptions {
+				l.option(f, option.OptionName, b.Meta.Pos, e.FullName)
+			}
+		case *parser.Option:
+			e.Options = append(e.Options, b)
+			l.option(f, b.OptionName, b.Meta.Pos, e.FullName)
+		case *parser.Reserved:
+			e.Reserved = append(e.Reserved, b)
+		}
+	}
+	return e
+}
+
+func (l *linker) defineService(f *File, node *parser.Service) *Service {
+	s := &Service{
+		Name:     node.ServiceName,
+		FullName: Qualify(f.Package, node.ServiceName),
+		File:     f,
+		Node:     node,
+	}
+	if l.isDefined(s.FullName) {
+		l.duplicate(s.FullName, node.Meta.Pos)
+	} else {
+		l.schema.services[s.FullName] = s
+	}
+
+	for _, body := range node.ServiceBody {
+		switch b := body.(type) {
+		case *parser.RPC:
+			s.RPCs = append(s.RPCs, &RPC{
+				Name:           b.RPCName,
+				Request:        l.ref(f, b, b.RPCRequest.MessageType, b.RPCRequest.Meta.Pos, f.Package),
+				Response:       l.ref(f, b, b.RPCResponse.MessageType, b.RPCResponse.Meta.Pos, f.Package),
+				RequestStream:  b.RPCRequest.IsStream,
+				ResponseStream: b.RPCResponse.IsStream,
+				Options:        b.Options,
+				Parent:         s,
+				Node:           b,
+			})
+			for _, option := range b.Options {
+				l.option(f, option.OptionName, option.Meta.Pos, s.FullName)
+			}
+		case *parser.Option:
+			s.Options = append(s.Options, b)
+			l.option(f, b.OptionName, b.Meta.Pos, s.FullName)
+		}
+	}
+	return s
+}
+
+func (l *linker) defineExtend(f *File, parent *Message, scope string, node *parser.Extend) *Extend {
+	e := &Extend{
+		Type:   l.ref(f, node, node.MessageType, node.Meta.Pos, scope),
+		Parent: parent,
+		File:   f,
+		Node:   node,
+	}
+	for _, body := range node.ExtendBody {
+		if b, ok := body.(*parser.Field); ok {
+			field := l.defineField(f, parent, b, b.FieldName, b.FieldNumber, b.Type, b.FieldOptions, b.Meta.Pos)
+			field.Repeated = b.IsRepeated
+			field.Parent = nil
+			field.Extend = e
+			e.Fields = append(e.Fields, field)
+			l.schema.extensions[Qualify(scope, field.Name)] = field
+		}
+	}
+	return e
+}
+
+// ref creates a reference resolved later in the scope.
+func (l *linker) ref(f *File, node parser.Visitee, name string, pos meta.Position, scope string) *Ref {
+	ref := &Ref{
+		Name:     name,
+		FullName: strings.TrimPrefix(name, "."),
+		File:     f,
+		Node:     node,
+		Pos:      pos,
+	}
+	l.schema.Refs = append(l.schema.Refs, ref)
+	if !IsScalar(name) {
+		l.pending = append(l.pending, pendingRef{ref: ref, scope: scope})
+	}
+	return ref
+}
+
+// option creates a reference to the extension in the option name, if it has one, resolved later in the scope.
+func (l *linker) option(f *File, optionName string, pos meta.Position, scope string) {
+	end := strings.Index(optionName, ")")
+	if !strings.HasPrefix(optionName, "(") || end < 0 {
+		return
+	}
+	name := optionName[1:end]
+	ref := &ExtensionRef{
+		Name:     name,
+		FullName: strings.TrimPrefix(name, "."),
+		Option:   optionName,
+		File:     f,
+		Pos:      pos,
+	}
+	l.schema.ExtensionRefs = append(l.schema.ExtensionRefs, ref)
+	l.pendingExtensions = append(l.pendingExtensions, pendingExtensionRef{ref: ref, scope: scope})
+}
+
+// linkImports links the imports of f to the files at paths, which are the resolved paths of the imports.
+// The location of each import is used as its path if the paths are not known.
+func (l *linker) linkImports(f *File, paths []string) {
+	for i, imp := range f.Imports {
+		if len(paths) == len(f.Imports) {
+			imp.Path = paths[i]
+		}
+		imp.File = l.schema.files[imp.Path]
+	}
+}
+
+// resolveAll resolves the pending references.
+func (l *linker) resolveAll() {
+	for _, p := range l.pending {
+		fullName, ok := l.resolve(p.ref.Name, p.scope)
+		if !ok {
+			l.errs = append(l.errs, &Error{
+				Err: fmt.Errorf("%w %q", ErrUndefined, p.ref.Name),
+				Pos: p.ref.Pos,
+			})
+			continue
+		}
+		p.ref.FullName = fullName
+		p.ref.Message = l.schema.messages[fullName]
+		p.ref.Enum = l.schema.enums[fullName]
+	}
+	l.pending = nil
+
+	for _, p := range l.pendingExtensions {
+		if fullName := l.lookup(p.ref.Name, p.scope, true); l.schema.extensions[fullName] != nil {
+			p.ref.FullName = fullName
+			p.ref.Extension = l.schema.extensions[fullName]
+		}
+	}
+	l.pendingExtensions = nil
+}
+
+// resolve finds the full name of the type name in the scope in the same way as protoc.
+// The first component of a relative name is looked up from the innermost scope to the outermost one,
+// then the rest of the name is looked up in the found scope.
+func (l *linker) resolve(name string, scope string) (string, bool) {
+	fullName := l.lookup(name, scope, false)
+	return fullName, l.isType(fullName)
+}
+
+// lookup returns the full name of the name in the scope, or "" if its first component is not found.
+// Like protoc, the first component is found as a message or an enum, or as a package if the name has more
+// components. The extensions are found too if extensions is true, for the names of the options.
+func (l *linker) lookup(name string, scope string, extensions bool) string {
+	if strings.HasPrefix(name, ".") {
+		return name[1:]
+	}
+
+	first := name
+	compound := false
+	if i := strings.Index(name, "."); 0 <= i {
+		first = name[:i]
+		compound = true
+	}
+	for {
+		candidate := Qualify(scope, first)
+		if l.isType(candidate) || (compound && l.schema.packages[candidate]) ||
+			(extensions && l.schema.extensions[candidate] != nil) {
+			return Qualify(scope, name)
+		}
+		if scope == "" {
+			return ""
+		}
+		scope = ParentScope(scope)
+	}
+}
+
+func (l *linker) isType(fullName string) bool {
+	return l.schema.messages[fullName] != nil || l.schema.enums[fullName] != nil
+}
+
+func (l *linker) isDefined(fullName string) bool {
+	return l.isType(fullName) || l.schema.services[fullName] != nil
+}
+
+func (l *linker) duplicate(fullName string, pos meta.Position) {
+	l.errs = append(l.errs, &Error{
+		Err: fmt.Errorf("%w %q", ErrDuplicate, fullName),
+		Pos: pos,
+	})
+}
+
+func (l *linker) number(number string, pos meta.Position) int {
+	n, err := strconv.ParseInt(number, 0, 32)
+	if err != nil {
+		l.errs = append(l.errs, &Error{
+			Err: fmt.Errorf("%w %q", ErrInvalidNumber, number),
+			Pos: pos,
+		})
+	}
+	return int(n)
+}
+
+// Qualify returns the name qualified by the scope, like "foo.bar.Name" for the scope "foo.bar".
+func Qualify(scope string, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// ParentScope returns the scope enclosing the scope, like "foo" for "foo.bar", or "" for a scope of one component.
+func ParentScope(scope string) string {
+	if i := strings.LastIndex(scope, "."); 0 <= i {
+		return scope[:i]
+	}
+	return ""
+}
+
+// IsWithin reports whether the full name is the scope or is nested in it.
+func IsWithin(fullName, scope string) bool {
+	return fullName == scope || strings.HasPrefix(fullName, scope+".")
+}
+
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return strings.Trim(s, `"'`)
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/thought-machine/go-protoparser/parser"
+)
+
+// The maximum numbers, which "max" means in a reserved range.
+const (
+	MaxFieldNumber     = 1<<29 - 1
+	MaxEnumValueNumber = 1<<31 - 1
+)
+
+// ReservedRange is an inclusive range of reserved numbers.
+type ReservedRange struct {
+	Start int
+	End   int
+}
+
+// ReservedRanges returns the reserved field numbers sorted by Start.
+func (m *Message) ReservedRanges() []ReservedRange {
+	return ParseReservedRanges(m.Reserved, MaxFieldNumber)
+}
+
+// ReservedNames returns the reserved field names without the quotes.
+func (m *Message) ReservedNames() []string {
+	return ParseReservedNames(m.Reserved)
+}
+
+// IsReservedNumber reports whether the field number is reserved.
+func (m *Message) IsReservedNumber(number int) bool {
+	return inRanges(m.ReservedRanges(), number)
+}
+
+// IsReservedName reports whether the field name is reserved.
+func (m *Message) IsReservedName(name string) bool {
+	return contains(m.ReservedNames(), name)
+}
+
+// ReservedRanges returns the reserved value numbers sorted by Start.
+func (e *Enum) ReservedRanges() []ReservedRange {
+	return ParseReservedRanges(e.Reserved, MaxEnumValueNumber)
+}
+
+// ReservedNames returns the reserved value names without the quotes.
+func (e *Enum) ReservedNames() []string {
+	return ParseReservedNames(e.Reserved)
+}
+
+// IsReservedNumber reports whether the value number is reserved.
+func (e *Enum) IsReservedNumber(number int) bool {
+	return inRanges(e.ReservedRanges(), number)
+}
+
+// IsReservedName reports whether the value name is reserved.
+func (e *Enum) IsReservedName(name string) bool {
+	return contains(e.ReservedNames(), name)
+}
+
+// ParseReservedRanges returns the numbers reserved by the statements sorted by Start. "max" is max.
+func ParseReservedRanges(reserved []*parser.Reserved, max int) []ReservedRange {
+	var ranges []ReservedRange
+	for _, r := range reserved {
+		for _, rng := range r.Ranges {
+			start, err := strconv.ParseInt(rng.Begin, 0, 64)
+			if err != nil {
+				continue
+			}
+			end := start
+			switch rng.End {
+			case "":
+			case "max":
+				end = int64(max)
+			default:
+				if end, err = strconv.ParseInt(rng.End, 0, 64); err != nil {
+					continue
+				}
+			}
+			ranges = append(ranges, ReservedRange{Start: int(start), End: int(end)})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	return ranges
+}
+
+// ParseReservedNames returns the names reserved by the statements without the quotes.
+func ParseReservedNames(reserved []*parser.Reserved) []string {
+	var names []string
+	for _, r := range reserved {
+		for _, name := range r.FieldNames {
+			names = append(names, unquote(name))
+		}
+	}
+	return names
+}
+
+func inRanges(ranges []ReservedRange, number int) bool {
+	for _, r := range ranges {
+		if r.Start <= number && number <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package schema
+
+import "strings"
+
+// The scalar value types.
+// See https://protobuf.dev/programming-guides/proto3/#scalar
+var scalars = map[string]bool{
+	"double":   true,
+	"float":    true,
+	"int32":    true,
+	"int64":    true,
+	"uint32":   true,
+	"uint64":   true,
+	"sint32":   true,
+	"sint64":   true,
+	"fixed32":  true,
+	"fixed64":  true,
+	"sfixed32": true,
+	"sfixed64": true,
+	"bool":     true,
+	"string":   true,
+	"bytes":    true,
+}
+
+// IsScalar reports whether the type name is a scalar value type, like "int32" or "string".
+func IsScalar(name string) bool {
+	return scalars[name]
+}
+
+// JSONName converts the field name to lowerCamelCase in the same way as protoc does for json_name:
+// each underscore is removed and the next letter is upper-cased.
+func JSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper && 'a' <= r && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(r)
+			upper = false
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,345 @@
+// Package schema links parsed files into a model where every type reference is resolved to its definition.
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// Schema is a set of linked files.
+type Schema struct {
+	// Files are the parsed files in the order of the results passed to Link.
+	Files []*File
+	// Refs are all type references in the order of Files and the positions.
+	Refs []*Ref
+	// ExtensionRefs are all references to extensions in the option names in the order of Files and the positions.
+	ExtensionRefs []*ExtensionRef
+
+	files    map[string]*File
+	messages map[string]*Message
+	enums    map[string]*Enum
+	services map[string]*Service
+	// extensions are the extension fields keyed by their full names, like "foo.bar.my_option".
+	extensions map[string]*Field
+	// packages has the package names and all their prefixes, like "foo" and "foo.bar" for "foo.bar".
+	packages map[string]bool
+}
+
+// File is a linked file.
+type File struct {
+	// Path is the path of the file given by the FileResult.
+	Path string
+	// Proto is the parsed file.
+	Proto *parser.Proto
+	// Package is the package name. It is empty if the file has no package statement.
+	Package  string
+	Imports  []*Import
+	Options  []*parser.Option
+	Messages []*Message
+	Enums    []*Enum
+	Services []*Service
+	Extends  []*Extend
+	// Imported is true if the file was not requested but parsed because another file imports it.
+	Imported bool
+}
+
+// Import is an import statement.
+type Import struct {
+	// Path is the path of the imported file, or its location if the file is not parsed.
+	Path string
+	// File is the imported file. It is nil if the file is not parsed.
+	File *File
+	Node *parser.Import
+}
+
+// Message is a message definition.
+type Message struct {
+	Name string
+	// FullName is the name qualified by the package and the enclosing messages, like "foo.bar.Outer.Inner".
+	FullName string
+	// Fields are the fields, the map fields and the oneof fields in the order of the declaration.
+	Fields   []*Field
+	Oneofs   []*Oneof
+	Messages []*Message
+	Enums    []*Enum
+	Extends  []*Extend
+	Options  []*parser.Option
+	Reserved []*parser.Reserved
+	// Parent is the enclosing message. It is nil for a top-level message.
+	Parent *Message
+	File   *File
+	Node   *parser.Message
+}
+
+// Field is a field, a map field, a oneof field or a field of an extend.
+type Field struct {
+	Name   string
+	Number int
+	// JSONName is the name in the proto3 JSON mapping, which is the json_name option or the lowerCamelCase name.
+	JSONName string
+	Repeated bool
+	// KeyType is the scalar type of the keys of a map field. It is empty for the other fields.
+	KeyType string
+	// Type is the type of the field, or the type of the values of a map field.
+	Type    *Ref
+	Options []*parser.FieldOption
+	// Oneof is the oneof which has the field, if any.
+	Oneof *Oneof
+	// Parent is the message which has the field. It is nil for a field of an extend.
+	Parent *Message
+	// Extend is the extend which has the field, if any.
+	Extend *Extend
+	// Node is *parser.Field, *parser.MapField or *parser.OneofField.
+	Node parser.Visitee
+}
+
+// IsMap reports whether the field is a map field.
+func (f *Field) IsMap() bool {
+	return f.KeyType != ""
+}
+
+// Oneof is a oneof.
+type Oneof struct {
+	Name   string
+	Fields []*Field
+	Parent *Message
+	Node   *parser.Oneof
+}
+
+// Enum is an enum definition.
+type Enum struct {
+	Name string
+	// FullName is the name qualified by the package and the enclosing messages.
+	FullName string
+	Values   []*EnumValue
+	Options  []*parser.Option
+	Reserved []*parser.Reserved
+	// Parent is the enclosing message. It is nil for a top-level enum.
+	Parent *Message
+	File   *File
+	Node   *parser.Enum
+}
+
+// EnumValue is a value of an enum.
+type EnumValue struct {
+	Name   string
+	Number int
+	Parent *Enum
+	Node   *parser.EnumField
+}
+
+// Service is a service definition.
+type Service struct {
+	Name string
+	// FullName is the name qualified by the package.
+	FullName string
+	RPCs     []*RPC
+	Options  []*parser.Option
+	File     *File
+	Node     *parser.Service
+}
+
+// RPC is a method of a service.
+type RPC struct {
+	Name           string
+	Request        *Ref
+	Response       *Ref
+	RequestStream  bool
+	ResponseStream bool
+	Options        []*parser.Option
+	Parent         *Service
+	Node           *parser.RPC
+}
+
+// Extend is an extend statement.
+type Extend struct {
+	// Type is the extended message.
+	Type   *Ref
+	Fields []*Field
+	// Parent is the enclosing message. It is nil for a top-level extend.
+	Parent *Message
+	File   *File
+	Node   *parser.Extend
+}
+
+// Ref is a reference to a type.
+type Ref struct {
+	// Name is the type name as written, like "int32", "Inner", "foo.Bar" or ".foo.Bar".
+	Name string
+	// FullName is the full name of the referenced message or enum, or the scalar type.
+	// It is Name without the leading dot if the reference is not resolved.
+	FullName string
+	// Message is the referenced message, if any.
+	Message *Message
+	// Enum is the referenced enum, if any.
+	Enum *Enum
+	// File is the file which has the reference.
+	File *File
+	// Node is *parser.Field, *parser.MapField, *parser.OneofField, *parser.RPC or *parser.Extend.
+	Node parser.Visitee
+	// Pos is the position of Node, or of the RPCRequest or the RPCResponse.
+	Pos meta.Position
+}
+
+// IsScalar reports whether the reference is to a scalar type.
+func (r *Ref) IsScalar() bool {
+	return IsScalar(r.Name)
+}
+
+// Resolved reports whether the reference is to a scalar type or a definition in the schema.
+func (r *Ref) Resolved() bool {
+	return r.IsScalar() || r.Message != nil || r.Enum != nil
+}
+
+// ExtensionRef is a reference to an extension in an option name, like "foo.bar" in "(foo.bar).baz".
+// A reference to an extension which is not in the schema is not an error, since the options are often
+// defined in files which are not parsed, like google/protobuf/descriptor.proto.
+type ExtensionRef struct {
+	// Name is the extension name as written in the parentheses, like "bar" or ".foo.bar".
+	Name string
+	// FullName is the full name of the referenced extension. It is Name without the leading dot if
+	// the reference is not resolved.
+	FullName string
+	// Extension is the referenced extension field. It is nil if it is not in the schema.
+	Extension *Field
+	// Option is the whole option name, like "(foo.bar).baz".
+	Option string
+	// File is the file which has the reference.
+	File *File
+	// Pos is the position of the option, or of the field or the enum value which has the option.
+	Pos meta.Position
+}
+
+// Errors.
+var (
+	// ErrUndefined is the error of a reference to an unknown type.
+	ErrUndefined = errors.New("undefined type")
+	// ErrDuplicate is the error of a definition whose full name is already defined.
+	ErrDuplicate = errors.New("duplicate definition")
+	// ErrInvalidNumber is the error of a field or enum value whose number is not an integer.
+	ErrInvalidNumber = errors.New("invalid number")
+)
+
+// Error is an error found while linking.
+type Error struct {
+	Err error
+	Pos meta.Position
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Pos, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Load parses the files at paths in fsys with ParseFS and links them.
+// Use protoparser.WithImportPaths to link the imported files too.
+func Load(ctx context.Context, fsys fs.FS, paths []string, options ...protoparser.Option) (*Schema, error) {
+	results, err := protoparser.ParseFS(ctx, fsys, paths, options...)
+	if err != nil {
+		return nil, err
+	}
+	return Link(results)
+}
+
+// LoadFiles parses the files at the paths on the local file system with ParseFiles and links them.
+func LoadFiles(ctx context.Context, paths []string, options ...protoparser.Option) (*Schema, error) {
+	results, err := protoparser.ParseFiles(ctx, paths, options...)
+	if err != nil {
+		return nil, err
+	}
+	return Link(results)
+}
+
+// Link links the parsed files.
+//
+// The schema is returned even if there is an error, so that a caller can use what was linked.
+// The error joins the errors of the results and the *Error values of undefined types, duplicate definitions
+// and invalid numbers.
+func Link(results []*protoparser.FileResult) (*Schema, error) {
+	l := &linker{
+		schema: &Schema{
+			files:      make(map[string]*File),
+			messages:   make(map[string]*Message),
+			enums:      make(map[string]*Enum),
+			services:   make(map[string]*Service),
+			extensions: make(map[string]*Field),
+			packages:   make(map[string]bool),
+		},
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			l.errs = append(l.errs, result.Err)
+			continue
+		}
+		l.define(result)
+	}
+	for i, result := range results {
+		if result.Err == nil {
+			l.linkImports(l.schema.files[result.Path], results[i].Imports)
+		}
+	}
+	l.resolveAll()
+	return l.schema, errors.Join(l.errs...)
+}
+
+// File returns the file at the path, or nil if there is none.
+func (s *Schema) File(path string) *File {
+	return s.files[path]
+}
+
+// Message returns the message of the full name, or nil if there is none. A leading dot is allowed.
+func (s *Schema) Message(fullName string) *Message {
+	return s.messages[strings.TrimPrefix(fullName, ".")]
+}
+
+// Enum returns the enum of the full name, or nil if there is none. A leading dot is allowed.
+func (s *Schema) Enum(fullName string) *Enum {
+	return s.enums[strings.TrimPrefix(fullName, ".")]
+}
+
+// Service returns the service of the full name, or nil if there is none. A leading dot is allowed.
+func (s *Schema) Service(fullName string) *Service {
+	return s.services[strings.TrimPrefix(fullName, ".")]
+}
+
+// Extension returns the extension field of the full name, or nil if there is none. A leading dot is allowed.
+func (s *Schema) Extension(fullName string) *Field {
+	return s.extensions[strings.TrimPrefix(fullName, ".")]
+}
+
+// Packages returns the package names of the files in the order of Files without duplicates.
+func (s *Schema) Packages() []string {
+	var packages []string
+	seen := make(map[string]bool)
+	for _, f := range s.Files {
+		if !seen[f.Package] {
+			seen[f.Package] = true
+			packages = append(packages, f.Package)
+		}
+	}
+	return packages
+}
+
+// RefsTo returns the references to the message or the enum of the full name.
+func (s *Schema) RefsTo(fullName string) []*Ref {
+	fullName = strings.TrimPrefix(fullName, ".")
+	var refs []*Ref
+	for _, ref := range s.Refs {
+		if (ref.Message != nil || ref.Enum != nil) && ref.FullName == fullName {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
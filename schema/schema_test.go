@@ -0,0 +1,356 @@
+package schema_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+var testFS = fstest.MapFS{
+	"foo/bar/a.proto": {Data: []byte(`syntax = "proto3";
+package foo.bar;
+import "foo/baz/b.proto";
+
+message Outer {
+  message Inner {
+    Kind kind = 1;
+  }
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+  }
+  Inner inner = 1;
+  baz.Shared shared = 2;
+  .foo.baz.Shared qualified = 3;
+  map<string, Outer.Inner> inners = 4;
+  oneof choice {
+    string text_value = 5;
+    foo.baz.Status status = 6 [json_name = "state"];
+  }
+  repeated Kind kinds = 0x07;
+}
+
+service Service {
+  rpc Get(Outer) returns (stream baz.Shared) {
+    option (google.api.http) = {
+      get: "/v1/{inner.kind}"
+      response_body: "shared"
+      additional_bindings {
+        post: "/v1/outer"
+        body: "inner"
+      }
+    };
+  }
+  rpc Custom(stream Outer) returns (Outer) {
+    option (google.api.http) = {
+      custom: {
+        kind: "HEAD"
+        path: "/v1/outer"
+      }
+      body: "*"
+    };
+  }
+}
+`)},
+	"foo/baz/b.proto": {Data: []byte(`syntax = "proto3";
+package foo.baz;
+
+message Shared {}
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 1;
+}
+`)},
+	"invalid.proto": {Data: []byte(`syntax = "proto3";
+package foo.baz;
+
+message Shared {
+  Missing missing = 1;
+  Shared.Missing nested = 2;
+}
+`)},
+}
+
+func TestLink(t *testing.T) {
+	s, err := schema.Load(context.Background(), testFS, []string{"foo/bar/a.proto"}, protoparser.WithImportPaths("."))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	if got, want := s.Packages(), []string{"foo.bar", "foo.baz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	a := s.File("foo/bar/a.proto")
+	if a == nil || len(a.Imports) != 1 || a.Imports[0].File != s.File("foo/baz/b.proto") {
+		t.Fatalf("got %v, but want foo/bar/a.proto importing foo/baz/b.proto", a)
+	}
+
+	outer := s.Message("foo.bar.Outer")
+	type field struct {
+		name     string
+		number   int
+		jsonName string
+		repeated bool
+		keyType  string
+		typeName string
+		oneof    string
+	}
+	var got []field
+	for _, f := range outer.Fields {
+		got = append(got, field{
+			name:     f.Name,
+			number:   f.Number,
+			jsonName: f.JSONName,
+			repeated: f.Repeated,
+			keyType:  f.KeyType,
+			typeName: f.Type.FullName,
+		})
+		if f.Oneof != nil {
+			got[len(got)-1].oneof = f.Oneof.Name
+		}
+		if !f.Type.Resolved() {
+			t.Errorf("got the unresolved %v", f.Type)
+		}
+	}
+	want := []field{
+		{name: "inner", number: 1, jsonName: "inner", typeName: "foo.bar.Outer.Inner"},
+		{name: "shared", number: 2, jsonName: "shared", typeName: "foo.baz.Shared"},
+		{name: "qualified", number: 3, jsonName: "qualified", typeName: "foo.baz.Shared"},
+		{name: "inners", number: 4, jsonName: "inners", keyType: "string", typeName: "foo.bar.Outer.Inner"},
+		{name: "text_value", number: 5, jsonName: "textValue", typeName: "string", oneof: "choice"},
+		{name: "status", number: 6, jsonName: "state", typeName: "foo.baz.Status", oneof: "choice"},
+		{name: "kinds", number: 7, jsonName: "kinds", repeated: true, typeName: "foo.bar.Outer.Kind"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if got := outer.Messages[0].Fields[0].Type.Enum; got != s.Enum(".foo.bar.Outer.Kind") {
+		t.Errorf("got %v, but want foo.bar.Outer.Kind", got)
+	}
+	if got := len(s.RefsTo("foo.baz.Shared")); got != 3 {
+		t.Errorf("got %d references, but want 3", got)
+	}
+
+	service := s.Service("foo.bar.Service")
+	get, custom := service.RPCs[0], service.RPCs[1]
+	if get.Request.Message != outer || !get.ResponseStream || get.RequestStream || !custom.RequestStream {
+		t.Errorf("got %v and %v, but want the linked rpcs", get, custom)
+	}
+	wantRules := []*schema.HTTPRule{
+		{Method: "GET", Path: "/v1/{inner.kind}", ResponseBody: "shared"},
+		{Method: "POST", Path: "/v1/outer", Body: "inner"},
+	}
+	if got := get.HTTPRules(); !reflect.DeepEqual(got, wantRules) {
+		t.Errorf("got %v, but want %v", got, wantRules)
+	}
+	wantRules = []*schema.HTTPRule{{Method: "HEAD", Path: "/v1/outer", Body: "*"}}
+	if got := custom.HTTPRules(); !reflect.DeepEqual(got, wantRules) {
+		t.Errorf("got %v, but want %v", got, wantRules)
+	}
+}
+
+func TestRPC_HTTPRules(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";
+service Service {
+  rpc Update(Request) returns (Request) {
+    option (google.api.http) = {
+      patch: "/v1/a"
+      additional_bindings { post: "/v1/b" body: "*" }
+      additional_bindings { body: "x" put: "/v1/c" }
+      additional_bindings {}
+    };
+  }
+}
+message Request {}
+`)},
+	}
+	s, err := schema.Load(context.Background(), fsys, []string{"a.proto"}, protoparser.WithPermissive(true))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	// Each additional_bindings block is a rule whatever the order of its fields.
+	want := []*schema.HTTPRule{
+		{Method: "PATCH", Path: "/v1/a"},
+		{Method: "POST", Path: "/v1/b", Body: "*"},
+		{Method: "PUT", Path: "/v1/c", Body: "x"},
+	}
+	if got := s.Service("Service").RPCs[0].HTTPRules(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+}
+
+func TestLink_errors(t *testing.T) {
+	s, err := schema.Load(context.Background(), testFS, []string{"foo/baz/b.proto", "invalid.proto", "missing.proto"})
+	if s == nil {
+		t.Fatalf("got nil, but want the schema")
+	}
+	if len(s.Files) != 2 {
+		t.Errorf("got %d files, but want 2", len(s.Files))
+	}
+
+	var linkErrs []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var linkErr *schema.Error
+		switch {
+		case errors.As(e, &linkErr) && errors.Is(e, schema.ErrDuplicate):
+			linkErrs = append(linkErrs, "duplicate "+linkErr.Pos.String())
+		case errors.As(e, &linkErr) && errors.Is(e, schema.ErrUndefined):
+			linkErrs = append(linkErrs, "undefined "+linkErr.Pos.String())
+		default:
+			linkErrs = append(linkErrs, "other")
+		}
+	}
+	want := []string{
+		"duplicate invalid.proto:4:1",
+		"other",
+		"undefined invalid.proto:5:3",
+		"undefined invalid.proto:6:3",
+	}
+	if !reflect.DeepEqual(linkErrs, want) {
+		t.Errorf("got %v, but want %v", linkErrs, want)
+	}
+}
+
+func TestLink_extensionsDoNotShadowTypes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";
+package a;
+message Opts {}
+message b {}
+message M {
+  extend Opts {
+    string b = 50000;
+    string c = 50001;
+  }
+  b x = 1;
+  c.C y = 2;
+}
+`)},
+		"c.proto": {Data: []byte(`syntax = "proto3";
+package c;
+message C {}
+`)},
+	}
+	s, err := schema.Load(context.Background(), fsys, []string{"a.proto", "c.proto"})
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	// Like protoc, the lookup of a type skips the extensions a.M.b and a.M.c.
+	var got []string
+	for _, ref := range s.Refs {
+		if ref.Name == "b" || ref.Name == "c.C" {
+			got = append(got, ref.Pos.String()+" "+ref.FullName)
+		}
+	}
+	want := []string{"a.proto:10:3 a.b", "a.proto:11:3 c.C"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+}
+
+func TestLink_extensionRefs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"opts.proto": {Data: []byte(`syntax = "proto3";
+package foo.opts;
+extend google.protobuf.FieldOptions {
+  string label = 50000;
+}
+message Rules {
+  extend google.protobuf.MessageOptions {
+    Rules rules = 50001;
+  }
+  string pattern = 1;
+}
+`)},
+		"a.proto": {Data: []byte(`syntax = "proto3";
+package foo.bar;
+import "opts.proto";
+option (file_opt) = true;
+message M {
+  option (opts.Rules.rules).pattern = "a";
+  string name = 1 [(label) = "n", deprecated = true];
+}
+enum E {
+  E_UNSPECIFIED = 0 [(foo.opts.label) = "e"];
+}
+`)},
+	}
+	// The errors are the undefined descriptor options.
+	s, _ := schema.Load(context.Background(), fsys, []string{"a.proto", "opts.proto"})
+
+	var got []string
+	for _, ref := range s.ExtensionRefs {
+		resolved := ""
+		if ref.Extension != nil {
+			resolved = ref.Extension.Name
+		}
+		got = append(got, ref.Pos.String()+" "+ref.Option+" "+ref.FullName+" "+resolved)
+	}
+	want := []string{
+		"a.proto:4:1 (file_opt) file_opt ",
+		"a.proto:6:3 (opts.Rules.rules).pattern foo.opts.Rules.rules rules",
+		"a.proto:7:3 (label) label ",
+		"a.proto:10:3 (foo.opts.label) foo.opts.label label",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+	if got := s.Extension(".foo.opts.label"); got == nil || got.Extend.Type.Name != "google.protobuf.FieldOptions" {
+		t.Errorf("got %v, but want the label extension", got)
+	}
+}
+
+func TestJSONName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "foo_bar", want: "fooBar"},
+		{name: "foo__bar_baz", want: "fooBarBaz"},
+		{name: "FooBar", want: "FooBar"},
+		{name: "foo_1bar", want: "foo1bar"},
+		{name: "_foo", want: "Foo"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if got := schema.JSONName(test.name); got != test.want {
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMessage_ReservedRanges(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";
+message M {
+  reserved 9 to 11, 2, 100 to max;
+  reserved "foo", "bar";
+}
+`)},
+	}
+	s, err := schema.Load(context.Background(), fsys, []string{"a.proto"})
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	m := s.Message("M")
+
+	wantRanges := []schema.ReservedRange{{Start: 2, End: 2}, {Start: 9, End: 11}, {Start: 100, End: schema.MaxFieldNumber}}
+	if got := m.ReservedRanges(); !reflect.DeepEqual(got, wantRanges) {
+		t.Errorf("got %v, but want %v", got, wantRanges)
+	}
+	wantNames := []string{"foo", "bar"}
+	if got := m.ReservedNames(); !reflect.DeepEqual(got, wantNames) {
+		t.Errorf("got %v, but want %v", got, wantNames)
+	}
+	if !m.IsReservedNumber(10) || m.IsReservedNumber(12) || !m.IsReservedName("bar") || m.IsReservedName("baz") {
+		t.Errorf("got the wrong reservations %v %v", m.ReservedRanges(), m.ReservedNames())
+	}
+}
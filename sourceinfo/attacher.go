@@ -0,0 +1,150 @@
+package sourceinfo
+
+import (
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// attacher visits the elements and attaches the comments around their first and last tokens.
+type attacher struct {
+	src   []byte
+	items []*tokenizer.Item
+	// index maps an offset to the index of the token there.
+	index map[int]int
+	info  *SourceInfo
+}
+
+// attach attaches the comments to the element which starts at pos.
+// A block element ends with "{", or with ";" like a statement if it has no body.
+func (a *attacher) attach(element parser.Visitee, pos meta.Position, block bool) {
+	first, ok := a.index[pos.Offset]
+	if !ok {
+		return
+	}
+	comments := &Comments{}
+	_, comments.LeadingDetached, comments.Leading = a.split(first)
+	if last := a.end(first, block); last < len(a.items)-1 {
+		comments.Trailing, _, _ = a.split(last + 1)
+	}
+	a.info.comments[element] = comments
+}
+
+// split splits the comments before the token at i.
+func (a *attacher) split(i int) (trailing string, detached []string, leading string) {
+	start := 0
+	if 0 < i {
+		start = a.items[i-1].End.Offset
+	}
+	next := a.items[i]
+	return split(string(a.src[start:next.Pos.Offset]), i == 0, next.Text)
+}
+
+// end returns the index of the last token of the element which starts at first.
+func (a *attacher) end(first int, block bool) int {
+	depth := 0
+	for i := first; i < len(a.items); i++ {
+		switch a.items[i].Token {
+		case tokenizer.TSEMICOLON:
+			if depth == 0 {
+				return i
+			}
+		case tokenizer.TLEFTCURLY:
+			if depth == 0 && block {
+				return i
+			}
+			depth++
+		case tokenizer.TLEFTPAREN, tokenizer.TLEFTSQUARE:
+			depth++
+		case tokenizer.TRIGHTCURLY, tokenizer.TRIGHTPAREN, tokenizer.TRIGHTSQUARE:
+			depth--
+		case tokenizer.TEOF:
+			return i
+		}
+	}
+	return len(a.items) - 1
+}
+
+func (a *attacher) VisitComment(*parser.Comment) {}
+
+func (a *attacher) VisitEmptyStatement(*parser.EmptyStatement) bool {
+	return true
+}
+
+func (a *attacher) VisitEnum(e *parser.Enum) bool {
+	a.attach(e, e.Meta.Pos, true)
+	return true
+}
+
+func (a *attacher) VisitEnumField(f *parser.EnumField) bool {
+	a.attach(f, f.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitExtend(e *parser.Extend) bool {
+	a.attach(e, e.Meta.Pos, true)
+	return true
+}
+
+func (a *attacher) VisitField(f *parser.Field) bool {
+	a.attach(f, f.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitImport(i *parser.Import) bool {
+	a.attach(i, i.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitMapField(m *parser.MapField) bool {
+	a.attach(m, m.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitMessage(m *parser.Message) bool {
+	a.attach(m, m.Meta.Pos, true)
+	return true
+}
+
+func (a *attacher) VisitOneof(o *parser.Oneof) bool {
+	a.attach(o, o.Meta.Pos, true)
+	return true
+}
+
+func (a *attacher) VisitOneofField(f *parser.OneofField) bool {
+	a.attach(f, f.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitOption(o *parser.Option) bool {
+	a.attach(o, o.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitPackage(p *parser.Package) bool {
+	a.attach(p, p.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitReserved(r *parser.Reserved) bool {
+	a.attach(r, r.Meta.Pos, false)
+	return true
+}
+
+func (a *attacher) VisitRPC(r *parser.RPC) bool {
+	a.attach(r, r.Meta.Pos, true)
+	for _, option := range r.Options {
+		a.VisitOption(option)
+	}
+	return true
+}
+
+func (a *attacher) VisitService(s *parser.Service) bool {
+	a.attach(s, s.Meta.Pos, true)
+	return true
+}
+
+func (a *attacher) VisitSyntax(s *parser.Syntax) bool {
+	a.attach(s, s.Meta.Pos, false)
+	return true
+}
@@ -0,0 +1,78 @@
+// Package sourceinfo attaches comments to the parsed elements in the same way as SourceCodeInfo of protoc.
+package sourceinfo
+
+import (
+	"bytes"
+	"io"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+// Comments are the comments attached to an element.
+// Each text excludes the comment markers, like "//", "/*" and "*/", and each line of a line comment ends with a newline.
+type Comments struct {
+	// LeadingDetached are the comment blocks before the element separated from it by blank lines.
+	LeadingDetached []string
+	// Leading is the comment block just before the element.
+	Leading string
+	// Trailing is the comment behind the element on the same line, or on the next line not followed by another element.
+	// The end of a message, enum, service, oneof, extend or rpc with options is its "{".
+	Trailing string
+}
+
+// SourceInfo holds the comments of the elements of a file.
+type SourceInfo struct {
+	comments map[parser.Visitee]*Comments
+	header   []string
+}
+
+// Comments returns the comments attached to the element. It is never nil.
+func (s *SourceInfo) Comments(element parser.Visitee) *Comments {
+	if comments, ok := s.comments[element]; ok {
+		return comments
+	}
+	return &Comments{}
+}
+
+// Header returns the comment blocks at the beginning of the file separated from the first element by blank lines,
+// such as a license header.
+func (s *SourceInfo) Header() []string {
+	return s.header
+}
+
+// Parse parses the input and attaches the comments.
+func Parse(input io.Reader, options ...protoparser.Option) (*parser.Proto, *SourceInfo, error) {
+	src, err := io.ReadAll(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	proto, err := protoparser.Parse(bytes.NewReader(src), options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proto, New(src, proto), nil
+}
+
+// New attaches the comments in src to the elements of proto parsed from src.
+func New(src []byte, proto *parser.Proto) *SourceInfo {
+	items, _ := tokenizer.Tokenize(bytes.NewReader(src), tokenizer.WithTolerant(true))
+	a := &attacher{
+		src:   src,
+		items: items,
+		index: make(map[int]int, len(items)),
+		info: &SourceInfo{
+			comments: make(map[parser.Visitee]*Comments),
+		},
+	}
+	for i, item := range items {
+		a.index[item.Pos.Offset] = i
+	}
+
+	proto.Accept(a)
+	if proto.Syntax != nil {
+		a.info.header = a.info.Comments(proto.Syntax).LeadingDetached
+	}
+	return a.info
+}
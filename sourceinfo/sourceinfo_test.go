@@ -0,0 +1,186 @@
+package sourceinfo_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/internal/util_test"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/sourceinfo"
+)
+
+func TestParse(t *testing.T) {
+	input := `// Copyright 2024 Example.
+// Licensed under the MIT License.
+
+// Comment attached to syntax.
+syntax = "proto3";
+
+message Foo { // Comment attached to Foo.
+  int32 foo = 1;  // Comment attached to foo.
+  // Comment attached to bar.
+  int32 bar = 2;
+
+  string baz = 3;
+  // Comment attached to baz.
+  // Another line attached to baz.
+
+  // Comment attached to moo.
+  //
+  // Another line attached to moo.
+  double moo = 4;
+
+  // Detached comment for corge. This is not leading or trailing comments
+  // to moo or corge because there are blank lines separating it from
+  // both.
+
+  // Detached comment for corge paragraph 2.
+
+  string corge = 5;
+  /* Block comment attached
+   * to corge.  Leading asterisks
+   * will be removed. */
+  /* Block comment attached to
+   * grault. */
+  int32 grault = 6;
+
+  // ignored detached comments.
+}
+`
+	proto, info, err := sourceinfo.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	message := proto.ProtoBody[0].(*parser.Message)
+	field := func(i int) parser.Visitee {
+		return message.MessageBody[i]
+	}
+
+	wantHeader := []string{" Copyright 2024 Example.\n Licensed under the MIT License.\n"}
+	if !reflect.DeepEqual(info.Header(), wantHeader) {
+		t.Errorf("got %q, but want %q", info.Header(), wantHeader)
+	}
+
+	tests := []struct {
+		name         string
+		element      parser.Visitee
+		wantComments *sourceinfo.Comments
+	}{
+		{
+			name:    "syntax",
+			element: proto.Syntax,
+			wantComments: &sourceinfo.Comments{
+				LeadingDetached: wantHeader,
+				Leading:         " Comment attached to syntax.\n",
+			},
+		},
+		{
+			name:    "message",
+			element: message,
+			wantComments: &sourceinfo.Comments{
+				Trailing: " Comment attached to Foo.\n",
+			},
+		},
+		{
+			name:    "trailing on the same line",
+			element: field(0),
+			wantComments: &sourceinfo.Comments{
+				Trailing: " Comment attached to foo.\n",
+			},
+		},
+		{
+			name:    "leading",
+			element: field(1),
+			wantComments: &sourceinfo.Comments{
+				Leading: " Comment attached to bar.\n",
+			},
+		},
+		{
+			name:    "trailing on the next lines",
+			element: field(2),
+			wantComments: &sourceinfo.Comments{
+				Trailing: " Comment attached to baz.\n Another line attached to baz.\n",
+			},
+		},
+		{
+			name:    "leading with an empty line",
+			element: field(3),
+			wantComments: &sourceinfo.Comments{
+				Leading: " Comment attached to moo.\n\n Another line attached to moo.\n",
+			},
+		},
+		{
+			name:    "detached and trailing block",
+			element: field(4),
+			wantComments: &sourceinfo.Comments{
+				LeadingDetached: []string{
+					" Detached comment for corge. This is not leading or trailing comments\n" +
+						" to moo or corge because there are blank lines separating it from\n both.\n",
+					" Detached comment for corge paragraph 2.\n",
+				},
+				Trailing: " Block comment attached\n to corge.  Leading asterisks\n will be removed. ",
+			},
+		},
+		{
+			name:    "leading block",
+			element: field(5),
+			wantComments: &sourceinfo.Comments{
+				Leading: " Block comment attached to\n grault. ",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got := info.Comments(test.element)
+			if !reflect.DeepEqual(got, test.wantComments) {
+				t.Errorf("got %v, but want %v", util_test.PrettyFormat(got), util_test.PrettyFormat(test.wantComments))
+			}
+		})
+	}
+}
+
+func TestNew_ambiguous(t *testing.T) {
+	input := "syntax = \"proto3\";\nenum E {\n  A = 0; /* between */ B = 1;\n  C = 2; // same\n  D = 3; }\n"
+	proto, info, err := sourceinfo.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	enum := proto.ProtoBody[0].(*parser.Enum)
+
+	tests := []struct {
+		name         string
+		element      parser.Visitee
+		wantComments *sourceinfo.Comments
+	}{
+		{
+			name:         "a block comment followed by a token on the same line is dropped",
+			element:      enum.EnumBody[0],
+			wantComments: &sourceinfo.Comments{},
+		},
+		{
+			name:         "the next element is not affected",
+			element:      enum.EnumBody[1],
+			wantComments: &sourceinfo.Comments{},
+		},
+		{
+			name:    "a line comment is trailing",
+			element: enum.EnumBody[2],
+			wantComments: &sourceinfo.Comments{
+				Trailing: " same\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got := info.Comments(test.element)
+			if !reflect.DeepEqual(got, test.wantComments) {
+				t.Errorf("got %v, but want %v", util_test.PrettyFormat(got), util_test.PrettyFormat(test.wantComments))
+			}
+		})
+	}
+}
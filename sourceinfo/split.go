@@ -0,0 +1,199 @@
+package sourceinfo
+
+import "strings"
+
+// split splits the comments in gap, which is the whitespace and comments between two tokens, into the trailing
+// comment of the previous token, the detached comments and the leading comment of the next token.
+//
+// It follows the Tokenizer::NextWithComments of protoc. start tells whether there is no previous token, and next is
+// the text of the next token, which is empty at the end of the input.
+func split(gap string, start bool, next string) (trailing string, detached []string, leading string) {
+	s := &gapScanner{text: gap}
+	c := &collector{canAttachToPrev: true}
+	trailingEndLine := -1
+
+	if start {
+		c.detachFromPrev()
+	} else {
+		// A comment on the same line is attached to the previous token.
+		s.skipSpaces()
+		switch {
+		case s.consume("//"):
+			trailingEndLine = s.line
+			s.lineComment(c.bufferForLine())
+			c.flush()
+		case s.consume("/*"):
+			s.blockComment(c.bufferForBlock())
+			trailingEndLine = s.line
+			s.skipSpaces()
+			if !s.consume("\n") {
+				// The next token is on the same line, so it is unclear which one the comment belongs to.
+				return "", nil, ""
+			}
+			c.flush()
+		default:
+			if !s.consume("\n") {
+				return "", nil, ""
+			}
+		}
+	}
+
+	for {
+		s.skipSpaces()
+		switch {
+		case s.consume("//"):
+			s.lineComment(c.bufferForLine())
+		case s.consume("/*"):
+			s.blockComment(c.bufferForBlock())
+			s.skipSpaces()
+			s.consume("\n")
+		case s.consume("\n"):
+			// A blank line.
+			c.flush()
+			c.detachFromPrev()
+		default:
+			if next == "" || next == "}" || next == "]" || next == ")" {
+				// A comment at the end of a scope is not attached to the next token.
+				c.flush()
+			}
+			if next != "" && (s.line == 0 || s.line == trailingEndLine) {
+				// A comment is ambiguous if the previous token or the trailing comment is on the same line.
+				c.maybeDetach()
+			}
+			if c.has {
+				leading = c.buf.String()
+			}
+			return c.trailing, c.detached, leading
+		}
+	}
+}
+
+// collector is the CommentCollector of protoc.
+type collector struct {
+	trailing    string
+	hasTrailing bool
+	detached    []string
+
+	buf    strings.Builder
+	has    bool
+	isLine bool
+
+	canAttachToPrev bool
+	num             int
+}
+
+func (c *collector) bufferForLine() *strings.Builder {
+	// Consecutive line comments are combined, but not with a block comment.
+	if c.has && !c.isLine {
+		c.flush()
+	}
+	c.has = true
+	c.isLine = true
+	return &c.buf
+}
+
+func (c *collector) bufferForBlock() *strings.Builder {
+	if c.has {
+		c.flush()
+	}
+	c.has = true
+	c.isLine = false
+	return &c.buf
+}
+
+// flush is called once the buffer is complete and not connected to the next token.
+func (c *collector) flush() {
+	if !c.has {
+		return
+	}
+	if c.canAttachToPrev {
+		c.trailing += c.buf.String()
+		c.hasTrailing = true
+		c.canAttachToPrev = false
+	} else {
+		c.detached = append(c.detached, c.buf.String())
+	}
+	c.buf.Reset()
+	c.has = false
+	c.num++
+}
+
+func (c *collector) detachFromPrev() {
+	c.canAttachToPrev = false
+}
+
+// maybeDetach detaches the comment if there is only one.
+func (c *collector) maybeDetach() {
+	count := c.num
+	if c.has {
+		count++
+	}
+	if count != 1 {
+		return
+	}
+	if c.hasTrailing {
+		c.detached = append([]string{c.trailing}, c.detached...)
+		c.trailing = ""
+		c.hasTrailing = false
+	}
+	c.canAttachToPrev = false
+	c.flush()
+}
+
+// gapScanner reads the whitespace and comments between two tokens.
+type gapScanner struct {
+	text string
+	pos  int
+	// line is the number of newlines read.
+	line int
+}
+
+func (s *gapScanner) consume(prefix string) bool {
+	if !strings.HasPrefix(s.text[s.pos:], prefix) {
+		return false
+	}
+	s.pos += len(prefix)
+	s.line += strings.Count(prefix, "\n")
+	return true
+}
+
+// skipSpaces skips whitespace except newlines.
+func (s *gapScanner) skipSpaces() {
+	for s.pos < len(s.text) && strings.IndexByte(" \t\r\v\f", s.text[s.pos]) != -1 {
+		s.pos++
+	}
+}
+
+// lineComment reads a line comment after "//" into content, including the newline.
+func (s *gapScanner) lineComment(content *strings.Builder) {
+	end := strings.IndexByte(s.text[s.pos:], '\n')
+	if end == -1 {
+		content.WriteString(s.text[s.pos:])
+		s.pos = len(s.text)
+		return
+	}
+	content.WriteString(s.text[s.pos : s.pos+end+1])
+	s.pos += end + 1
+	s.line++
+}
+
+// blockComment reads a block comment after "/*" into content, stripping "*/" and
+// the whitespace and "*" at the beginning of each line.
+func (s *gapScanner) blockComment(content *strings.Builder) {
+	for s.pos < len(s.text) {
+		switch {
+		case s.consume("*/"):
+			return
+		case s.consume("\n"):
+			content.WriteByte('\n')
+			s.skipSpaces()
+			if s.consume("*/") {
+				return
+			}
+			s.consume("*")
+		default:
+			content.WriteByte(s.text[s.pos])
+			s.pos++
+		}
+	}
+}
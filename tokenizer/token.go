@@ -0,0 +1,69 @@
+package tokenizer
+
+import "github.com/thought-machine/go-protoparser/internal/lexer/scanner"
+
+// Token represents a lexical token.
+type Token = scanner.Token
+
+// The Token of an Item is one of these tokens.
+const (
+	// Special tokens
+	TILLEGAL = scanner.TILLEGAL
+	TEOF     = scanner.TEOF
+
+	// Identifiers
+	TIDENT = scanner.TIDENT
+
+	// Literals
+	TINTLIT   = scanner.TINTLIT
+	TFLOATLIT = scanner.TFLOATLIT
+	TBOOLLIT  = scanner.TBOOLLIT
+	TSTRLIT   = scanner.TSTRLIT
+
+	// Misc characters
+	TSEMICOLON   = scanner.TSEMICOLON   // ;
+	TCOLON       = scanner.TCOLON       // :
+	TEQUALS      = scanner.TEQUALS      // =
+	TLEFTPAREN   = scanner.TLEFTPAREN   // (
+	TRIGHTPAREN  = scanner.TRIGHTPAREN  // )
+	TLEFTCURLY   = scanner.TLEFTCURLY   // {
+	TRIGHTCURLY  = scanner.TRIGHTCURLY  // }
+	TLEFTSQUARE  = scanner.TLEFTSQUARE  // [
+	TRIGHTSQUARE = scanner.TRIGHTSQUARE // ]
+	TLESS        = scanner.TLESS        // <
+	TGREATER     = scanner.TGREATER     // >
+	TCOMMA       = scanner.TCOMMA       // ,
+	TDOT         = scanner.TDOT         // .
+	TMINUS       = scanner.TMINUS       // -
+	TPLUS        = scanner.TPLUS        // +
+
+	// Keywords
+	TSYNTAX     = scanner.TSYNTAX
+	TSERVICE    = scanner.TSERVICE
+	TRPC        = scanner.TRPC
+	TRETURNS    = scanner.TRETURNS
+	TMESSAGE    = scanner.TMESSAGE
+	TEXTEND     = scanner.TEXTEND
+	TIMPORT     = scanner.TIMPORT
+	TPACKAGE    = scanner.TPACKAGE
+	TOPTION     = scanner.TOPTION
+	TREPEATED   = scanner.TREPEATED
+	TWEAK       = scanner.TWEAK
+	TPUBLIC     = scanner.TPUBLIC
+	TONEOF      = scanner.TONEOF
+	TMAP        = scanner.TMAP
+	TRESERVED   = scanner.TRESERVED
+	TENUM       = scanner.TENUM
+	TSTREAM     = scanner.TSTREAM
+	TADDITIONAL = scanner.TADDITIONAL
+)
+
+// IsKeyword reports whether tok is a keyword.
+func IsKeyword(tok Token) bool {
+	return TSYNTAX <= tok && tok <= TADDITIONAL
+}
+
+// IsLiteral reports whether tok is a literal.
+func IsLiteral(tok Token) bool {
+	return TINTLIT <= tok && tok <= TSTRLIT
+}
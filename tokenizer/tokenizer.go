@@ -0,0 +1,183 @@
+// Package tokenizer splits a Protocol Buffer file into tokens with their positions and the trivia between them.
+package tokenizer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thought-machine/go-protoparser/internal/lexer/scanner"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+)
+
+// TriviaKind is the kind of Trivia.
+type TriviaKind int
+
+// The Kind of Trivia is one of these.
+const (
+	TriviaWhitespace TriviaKind = iota
+	TriviaComment
+)
+
+// Trivia represents whitespace or a comment, which has no meaning to the parser.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+	// Pos is the position of the first character.
+	Pos meta.Position
+	// End is the position just after the last character.
+	End meta.Position
+}
+
+// Item represents a token.
+type Item struct {
+	Token Token
+	Text  string
+	// Pos is the position of the first character.
+	Pos meta.Position
+	// End is the position just after the last character.
+	End meta.Position
+	// LeadingTrivia is the whitespace and comments between the previous token and this one.
+	LeadingTrivia []*Trivia
+	// Err is the reason why the token is TILLEGAL in the tolerant mode.
+	Err error
+}
+
+// Tokenizer splits an input into Items.
+type Tokenizer struct {
+	src      []byte
+	scanner  *scanner.Scanner
+	end      scanner.Position
+	tolerant bool
+}
+
+// Option is an option for NewTokenizer.
+type Option func(*Tokenizer)
+
+// WithFilename is an option to set filename to the positions.
+func WithFilename(filename string) Option {
+	return func(t *Tokenizer) {
+		t.end.Filename = filename
+	}
+}
+
+// WithTolerant is an option to never fail. Next returns a TILLEGAL Item with the Err instead of an error.
+func WithTolerant(tolerant bool) Option {
+	return func(t *Tokenizer) {
+		t.tolerant = tolerant
+	}
+}
+
+// NewTokenizer creates a new Tokenizer.
+// A failure to read input is treated as the end of the input.
+func NewTokenizer(input io.Reader, opts ...Option) *Tokenizer {
+	src, _ := io.ReadAll(input)
+	t := &Tokenizer{
+		src: src,
+		end: *scanner.NewPosition(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.scanner = scanner.NewScanner(src, scanner.WithFilename(t.end.Filename))
+	t.scanner.Mode = scanner.ScanIdent | scanner.ScanLit | scanner.ScanKeyword | scanner.ScanComment
+	return t
+}
+
+// Tokenize returns all Items of input, the last of which is TEOF.
+func Tokenize(input io.Reader, opts ...Option) ([]*Item, error) {
+	t := NewTokenizer(input, opts...)
+	var items []*Item
+	for {
+		item, err := t.Next()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if item.Token == TEOF {
+			return items, nil
+		}
+	}
+}
+
+// Next returns the next Item. It keeps returning a TEOF Item at the end of the input.
+//
+// The texts of all trivia and tokens concatenated are the input itself.
+func (t *Tokenizer) Next() (*Item, error) {
+	var trivia []*Trivia
+	for {
+		tok, text, pos, err := t.scanner.Scan()
+		if t.end.Offset < pos.Offset {
+			trivia = append(trivia, t.newTrivia(TriviaWhitespace, pos))
+		}
+		end := t.scanner.Mark()
+
+		switch {
+		case tok == scanner.TCOMMENT:
+			// A line comment does not include its newline, which is the next whitespace.
+			trivia = append(trivia, t.newTrivia(TriviaComment, advance(pos, text)))
+			continue
+		case err != nil && t.isLineCommentAtEOF(pos):
+			// The scanner requires a newline after a line comment, but it is usual for a file not to end with one.
+			trivia = append(trivia, t.newTrivia(TriviaComment, end))
+			continue
+		case tok == scanner.TILLEGAL:
+			if end.Offset <= pos.Offset {
+				// Skips the character so that the next call makes progress.
+				_, size := utf8.DecodeRune(t.src[pos.Offset:])
+				end = pos
+				end.Offset += size
+				end.Column++
+				t.scanner.Reset(end)
+			}
+			text = string(t.src[pos.Offset:end.Offset])
+			if err == nil {
+				err = fmt.Errorf("found %q but expected a token", text)
+			}
+			if !t.tolerant {
+				return nil, fmt.Errorf("%s: %w", pos, err)
+			}
+		}
+
+		t.end = end
+		return &Item{
+			Token:         tok,
+			Text:          text,
+			Pos:           meta.NewPosition(pos),
+			End:           meta.NewPosition(end),
+			LeadingTrivia: trivia,
+			Err:           err,
+		}, nil
+	}
+}
+
+// newTrivia creates a Trivia from the end of the last one to end.
+func (t *Tokenizer) newTrivia(kind TriviaKind, end scanner.Position) *Trivia {
+	trivia := &Trivia{
+		Kind: kind,
+		Text: string(t.src[t.end.Offset:end.Offset]),
+		Pos:  meta.NewPosition(t.end),
+		End:  meta.NewPosition(end),
+	}
+	t.end = end
+	return trivia
+}
+
+func (t *Tokenizer) isLineCommentAtEOF(pos scanner.Position) bool {
+	rest := t.src[pos.Offset:]
+	return bytes.HasPrefix(rest, []byte("//")) && !bytes.ContainsRune(rest, '\n')
+}
+
+// advance returns the position after text which starts at pos.
+func advance(pos scanner.Position, text string) scanner.Position {
+	pos.Offset += len(text)
+	if i := strings.LastIndexByte(text, '\n'); 0 <= i {
+		pos.Line += strings.Count(text, "\n")
+		pos.Column = 1
+		text = text[i+1:]
+	}
+	pos.Column += utf8.RuneCountInString(text)
+	return pos
+}
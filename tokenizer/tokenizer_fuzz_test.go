@@ -0,0 +1,57 @@
+package tokenizer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+func FuzzTokenize(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("..", "_testdata", "*.proto"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(content))
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		items, err := tokenizer.Tokenize(strings.NewReader(input), tokenizer.WithTolerant(true))
+		if err != nil {
+			t.Fatalf("got err %v in the tolerant mode", err)
+		}
+
+		// The texts of all trivia and tokens concatenated are the input itself,
+		// and each of them starts where the previous one ends.
+		var b strings.Builder
+		end := meta.Position{Offset: 0, Line: 1, Column: 1}
+		for _, item := range items {
+			for _, trivia := range item.LeadingTrivia {
+				if trivia.Pos != end {
+					t.Fatalf("got trivia %q at %v, but want at %v", trivia.Text, trivia.Pos, end)
+				}
+				b.WriteString(trivia.Text)
+				end = trivia.End
+			}
+			if item.Pos != end {
+				t.Fatalf("got %q at %v, but want at %v", item.Text, item.Pos, end)
+			}
+			b.WriteString(item.Text)
+			end = item.End
+			if end.Offset != b.Len() {
+				t.Fatalf("got %q ending at %d, but want at %d", item.Text, end.Offset, b.Len())
+			}
+		}
+		if b.String() != input {
+			t.Errorf("got %q, but want %q", b.String(), input)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package tokenizer_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thought-machine/go-protoparser/internal/util_test"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/tokenizer"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		tolerant  bool
+		wantItems []*tokenizer.Item
+		wantErr   bool
+	}{
+		{
+			name:  "tokenizing an empty input",
+			input: "",
+			wantItems: []*tokenizer.Item{
+				{
+					Token: tokenizer.TEOF,
+					Pos:   meta.Position{Offset: 0, Line: 1, Column: 1},
+					End:   meta.Position{Offset: 0, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "tokenizing tokens and trivia",
+			input: "syntax = \"proto3\"; // c\n/* b */ message",
+			wantItems: []*tokenizer.Item{
+				{
+					Token: tokenizer.TSYNTAX,
+					Text:  "syntax",
+					Pos:   meta.Position{Offset: 0, Line: 1, Column: 1},
+					End:   meta.Position{Offset: 6, Line: 1, Column: 7},
+				},
+				{
+					Token: tokenizer.TEQUALS,
+					Text:  "=",
+					Pos:   meta.Position{Offset: 7, Line: 1, Column: 8},
+					End:   meta.Position{Offset: 8, Line: 1, Column: 9},
+					LeadingTrivia: []*tokenizer.Trivia{
+						{
+							Kind: tokenizer.TriviaWhitespace,
+							Text: " ",
+							Pos:  meta.Position{Offset: 6, Line: 1, Column: 7},
+							End:  meta.Position{Offset: 7, Line: 1, Column: 8},
+						},
+					},
+				},
+				{
+					Token: tokenizer.TSTRLIT,
+					Text:  `"proto3"`,
+					Pos:   meta.Position{Offset: 9, Line: 1, Column: 10},
+					End:   meta.Position{Offset: 17, Line: 1, Column: 18},
+					LeadingTrivia: []*tokenizer.Trivia{
+						{
+							Kind: tokenizer.TriviaWhitespace,
+							Text: " ",
+							Pos:  meta.Position{Offset: 8, Line: 1, Column: 9},
+							End:  meta.Position{Offset: 9, Line: 1, Column: 10},
+						},
+					},
+				},
+				{
+					Token: tokenizer.TSEMICOLON,
+					Text:  ";",
+					Pos:   meta.Position{Offset: 17, Line: 1, Column: 18},
+					End:   meta.Position{Offset: 18, Line: 1, Column: 19},
+				},
+				{
+					Token: tokenizer.TMESSAGE,
+					Text:  "message",
+					Pos:   meta.Position{Offset: 32, Line: 2, Column: 9},
+					End:   meta.Position{Offset: 39, Line: 2, Column: 16},
+					LeadingTrivia: []*tokenizer.Trivia{
+						{
+							Kind: tokenizer.TriviaWhitespace,
+							Text: " ",
+							Pos:  meta.Position{Offset: 18, Line: 1, Column: 19},
+							End:  meta.Position{Offset: 19, Line: 1, Column: 20},
+						},
+						{
+							Kind: tokenizer.TriviaComment,
+							Text: "// c",
+							Pos:  meta.Position{Offset: 19, Line: 1, Column: 20},
+							End:  meta.Position{Offset: 23, Line: 1, Column: 24},
+						},
+						{
+							Kind: tokenizer.TriviaWhitespace,
+							Text: "\n",
+							Pos:  meta.Position{Offset: 23, Line: 1, Column: 24},
+							End:  meta.Position{Offset: 24, Line: 2, Column: 1},
+						},
+						{
+							Kind: tokenizer.TriviaComment,
+							Text: "/* b */",
+							Pos:  meta.Position{Offset: 24, Line: 2, Column: 1},
+							End:  meta.Position{Offset: 31, Line: 2, Column: 8},
+						},
+						{
+							Kind: tokenizer.TriviaWhitespace,
+							Text: " ",
+							Pos:  meta.Position{Offset: 31, Line: 2, Column: 8},
+							End:  meta.Position{Offset: 32, Line: 2, Column: 9},
+						},
+					},
+				},
+				{
+					Token: tokenizer.TEOF,
+					Pos:   meta.Position{Offset: 39, Line: 2, Column: 16},
+					End:   meta.Position{Offset: 39, Line: 2, Column: 16},
+				},
+			},
+		},
+		{
+			name:  "tokenizing a line comment at the end without a newline",
+			input: "a // c",
+			wantItems: []*tokenizer.Item{
+				{
+					Token: tokenizer.TIDENT,
+					Text:  "a",
+					Pos:   meta.Position{Offset: 0, Line: 1, Column: 1},
+					End:   meta.Position{Offset: 1, Line: 1, Column: 2},
+				},
+				{
+					Token: tokenizer.TEOF,
+					Pos:   meta.Position{Offset: 6, Line: 1, Column: 7},
+					End:   meta.Position{Offset: 6, Line: 1, Column: 7},
+					LeadingTrivia: []*tokenizer.Trivia{
+						{
+							Kind: tokenizer.TriviaWhitespace,
+							Text: " ",
+							Pos:  meta.Position{Offset: 1, Line: 1, Column: 2},
+							End:  meta.Position{Offset: 2, Line: 1, Column: 3},
+						},
+						{
+							Kind: tokenizer.TriviaComment,
+							Text: "// c",
+							Pos:  meta.Position{Offset: 2, Line: 1, Column: 3},
+							End:  meta.Position{Offset: 6, Line: 1, Column: 7},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "failing on an illegal character",
+			input:   "a @",
+			wantErr: true,
+		},
+		{
+			name:     "tokenizing illegal characters in the tolerant mode",
+			input:    "@0x",
+			tolerant: true,
+			wantItems: []*tokenizer.Item{
+				{
+					Token: tokenizer.TILLEGAL,
+					Text:  "@",
+					Pos:   meta.Position{Offset: 0, Line: 1, Column: 1},
+					End:   meta.Position{Offset: 1, Line: 1, Column: 2},
+				},
+				{
+					Token: tokenizer.TILLEGAL,
+					Text:  "0x",
+					Pos:   meta.Position{Offset: 1, Line: 1, Column: 2},
+					End:   meta.Position{Offset: 3, Line: 1, Column: 4},
+				},
+				{
+					Token: tokenizer.TEOF,
+					Pos:   meta.Position{Offset: 3, Line: 1, Column: 4},
+					End:   meta.Position{Offset: 3, Line: 1, Column: 4},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := tokenizer.Tokenize(strings.NewReader(test.input), tokenizer.WithTolerant(test.tolerant))
+			switch {
+			case test.wantErr:
+				if err == nil {
+					t.Errorf("got err nil, but want err")
+				}
+				return
+			case !test.wantErr && err != nil:
+				t.Errorf("got err %v, but want nil", err)
+				return
+			}
+
+			for _, item := range got {
+				if item.Token == tokenizer.TILLEGAL && item.Err == nil {
+					t.Errorf("got no Err of %v", item)
+				}
+				item.Err = nil
+			}
+			if !reflect.DeepEqual(got, test.wantItems) {
+				t.Errorf("got %v, but want %v", util_test.PrettyFormat(got), util_test.PrettyFormat(test.wantItems))
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+// Package typescript generates TypeScript declaration files from linked files, following the proto3 JSON mapping.
+//
+// Each package is a module, like "foo.bar.d.ts". Nested types are in the namespace of the enclosing message,
+// enums are unions of the value names and oneofs are unions of the objects which set at most one of the fields.
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/thought-machine/go-protoparser/doc"
+	"github.com/thought-machine/go-protoparser/parser"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// File is a generated declaration file.
+type File struct {
+	// Package is the package name.
+	Package string
+	// Path is the file name, like "foo.bar.d.ts".
+	Path    string
+	Content []byte
+}
+
+// Generator generates the declaration files.
+type Generator struct {
+	protoNames bool
+}
+
+// Option is an option for NewGenerator.
+type Option func(*Generator)
+
+// WithProtoNames is an option to use the field names as written rather than the lowerCamelCase JSON names.
+func WithProtoNames(protoNames bool) Option {
+	return func(g *Generator) {
+		g.protoNames = protoNames
+	}
+}
+
+// NewGenerator creates a new Generator.
+func NewGenerator(options ...Option) *Generator {
+	g := &Generator{}
+	for _, opt := range options {
+		opt(g)
+	}
+	return g
+}
+
+// Generate generates a declaration file for each package in the schema, in the order of schema.Packages.
+// It fails if a field refers to a type which is neither linked nor a well-known type.
+func (g *Generator) Generate(s *schema.Schema) ([]*File, error) {
+	var files []*File
+	for _, pkg := range s.Packages() {
+		var buf bytes.Buffer
+		if err := g.GeneratePackage(&buf, s, pkg); err != nil {
+			return nil, err
+		}
+		files = append(files, &File{
+			Package: pkg,
+			Path:    Path(pkg),
+			Content: buf.Bytes(),
+		})
+	}
+	return files, nil
+}
+
+// Path returns the file name of the declarations of the package.
+func Path(pkg string) string {
+	return module(pkg) + ".d.ts"
+}
+
+func module(pkg string) string {
+	if pkg == "" {
+		return "default"
+	}
+	return pkg
+}
+
+// GeneratePackage writes the declarations of the package to w.
+func (g *Generator) GeneratePackage(w io.Writer, s *schema.Schema, pkg string) error {
+	p := &printer{
+		Generator: g,
+		pkg:       pkg,
+		imports:   make(map[string]bool),
+	}
+	var paths []string
+	for _, f := range s.Files {
+		if f.Package != pkg {
+			continue
+		}
+		paths = append(paths, f.Path)
+		for _, m := range f.Messages {
+			if err := p.message(m); err != nil {
+				return err
+			}
+		}
+		for _, e := range f.Enums {
+			p.enum(e)
+		}
+	}
+
+	var header bytes.Buffer
+	fmt.Fprintf(&header, "// Code generated from %s. DO NOT EDIT.\n", strings.Join(paths, ", "))
+	var imports []string
+	for imported := range p.imports {
+		imports = append(imports, imported)
+	}
+	sort.Strings(imports)
+	for _, imported := range imports {
+		fmt.Fprintf(&header, "import type * as %s from %q;\n", alias(imported), "./"+module(imported))
+	}
+
+	if 0 < p.buf.Len() {
+		header.WriteByte('\n')
+	}
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+type printer struct {
+	*Generator
+	pkg     string
+	imports map[string]bool
+	buf     bytes.Buffer
+	indent  int
+}
+
+func (p *printer) line(format string, args ...interface{}) {
+	if format != "" {
+		p.buf.WriteString(strings.Repeat("  ", p.indent))
+		fmt.Fprintf(&p.buf, format, args...)
+	}
+	p.buf.WriteByte('\n')
+}
+
+// blank writes a blank line to separate declarations, unless it is the first one in the file or the block.
+func (p *printer) blank() {
+	if p.buf.Len() != 0 && !bytes.HasSuffix(p.buf.Bytes(), []byte("{\n")) {
+		p.line("")
+	}
+}
+
+// comment writes the doc comment of the node as JSDoc.
+func (p *printer) comment(node parser.Visitee, extra ...string) {
+	d := doc.Of(node)
+	var lines []string
+	for i, paragraph := range d.Paragraphs {
+		if 0 < i {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(paragraph, "\n")...)
+	}
+	if tag := d.Tag(doc.TagDeprecated); tag != nil {
+		lines = append(lines, strings.TrimSpace("@deprecated "+tag.Value))
+	}
+	lines = append(lines, extra...)
+	if len(lines) == 0 {
+		return
+	}
+
+	if len(lines) == 1 {
+		p.line("/** %s */", escapeComment(lines[0]))
+		return
+	}
+	p.line("/**")
+	for _, l := range lines {
+		p.line("%s", strings.TrimRight(" * "+escapeComment(l), " "))
+	}
+	p.line(" */")
+}
+
+func escapeComment(text string) string {
+	return strings.ReplaceAll(text, "*/", `*\/`)
+}
+
+func (p *printer) message(m *schema.Message) error {
+	p.blank()
+	p.comment(m.Node)
+
+	var oneofs []string
+	for _, oneof := range m.Oneofs {
+		oneofs = append(oneofs, m.Name+"."+oneofTypeName(m, oneof))
+	}
+	if len(oneofs) == 0 {
+		p.line("export interface %s {", m.Name)
+	} else {
+		p.line("export type %s = {", m.Name)
+	}
+	p.indent++
+	for _, f := range m.Fields {
+		if f.Oneof != nil {
+			continue
+		}
+		t, err := p.fieldType(f)
+		if err != nil {
+			return err
+		}
+		var extra []string
+		if deprecated(f) && !doc.Of(f.Node).Has(doc.TagDeprecated) {
+			extra = append(extra, "@deprecated")
+		}
+		p.comment(f.Node, extra...)
+		p.line("%s?: %s;", p.propertyName(f), t)
+	}
+	p.indent--
+	if len(oneofs) == 0 {
+		p.line("}")
+	} else {
+		p.line("} & %s;", strings.Join(oneofs, " & "))
+	}
+
+	if len(m.Messages) == 0 && len(m.Enums) == 0 && len(m.Oneofs) == 0 {
+		return nil
+	}
+	p.blank()
+	p.line("export namespace %s {", m.Name)
+	p.indent++
+	for _, oneof := range m.Oneofs {
+		if err := p.oneof(m, oneof); err != nil {
+			return err
+		}
+	}
+	for _, nested := range m.Messages {
+		if err := p.message(nested); err != nil {
+			return err
+		}
+	}
+	for _, e := range m.Enums {
+		p.enum(e)
+	}
+	p.indent--
+	p.line("}")
+	return nil
+}
+
+// deprecated reports whether the field has the deprecated option.
+func deprecated(f *schema.Field) bool {
+	for _, option := range f.Options {
+		if option.OptionName == "deprecated" && option.Constant == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// oneof writes the union of the objects each of which sets one field of the oneof, and of the object setting none.
+func (p *printer) oneof(m *schema.Message, oneof *schema.Oneof) error {
+	var names, types []string
+	for _, f := range oneof.Fields {
+		t, err := p.fieldType(f)
+		if err != nil {
+			return err
+		}
+		names = append(names, p.propertyName(f))
+		types = append(types, t)
+	}
+
+	p.blank()
+	p.comment(oneof.Node, "At most one of the fields is set.")
+	p.line("export type %s =", oneofTypeName(m, oneof))
+	p.indent++
+	for i := 0; i <= len(names); i++ {
+		var props []string
+		for j, name := range names {
+			if i == j {
+				props = append(props, name+": "+types[j])
+			} else {
+				props = append(props, name+"?: never")
+			}
+		}
+		end := ""
+		if i == len(names) {
+			end = ";"
+		}
+		p.line("| { %s }%s", strings.Join(props, "; "), end)
+	}
+	p.indent--
+	return nil
+}
+
+// oneofTypeName returns the UpperCamelCase name of the oneof, suffixed by "Oneof" if a nested type has the name.
+func oneofTypeName(m *schema.Message, oneof *schema.Oneof) string {
+	name := schema.JSONName("_" + oneof.Name)
+	for _, nested := range m.Messages {
+		if nested.Name == name {
+			return name + "Oneof"
+		}
+	}
+	for _, e := range m.Enums {
+		if e.Name == name {
+			return name + "Oneof"
+		}
+	}
+	return name
+}
+
+func (p *printer) enum(e *schema.Enum) {
+	p.blank()
+	p.comment(e.Node)
+	var names []string
+	for _, v := range e.Values {
+		names = append(names, fmt.Sprintf("%q", v.Name))
+	}
+	if len(names) == 0 {
+		names = []string{"never"}
+	}
+	p.line("export type %s = %s;", e.Name, strings.Join(names, " | "))
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func (p *printer) propertyName(f *schema.Field) string {
+	name := f.JSONName
+	if p.protoNames {
+		name = f.Name
+	}
+	if identifier.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+func (p *printer) fieldType(f *schema.Field) (string, error) {
+	t, err := p.typeName(f.Type)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case f.IsMap():
+		return "{ [key: string]: " + t + " }", nil
+	case f.Repeated:
+		if strings.ContainsAny(t, " |") {
+			t = "(" + t + ")"
+		}
+		return t + "[]", nil
+	}
+	return t, nil
+}
+
+// typeName returns the TypeScript type of a single value of the type.
+func (p *printer) typeName(t *schema.Ref) (string, error) {
+	if name, ok := scalarType(t.Name); ok {
+		return name, nil
+	}
+	if name, ok := wellKnownType(t.FullName); ok {
+		return name, nil
+	}
+
+	var pkg string
+	switch {
+	case t.Message != nil:
+		pkg = t.Message.File.Package
+	case t.Enum != nil:
+		pkg = t.Enum.File.Package
+	default:
+		return "", fmt.Errorf("%s: %w %q", t.Pos, schema.ErrUndefined, t.Name)
+	}
+	if pkg == p.pkg {
+		return localName(t.FullName, pkg), nil
+	}
+	p.imports[pkg] = true
+	return alias(pkg) + "." + localName(t.FullName, pkg), nil
+}
+
+func localName(fullName string, pkg string) string {
+	if pkg == "" {
+		return fullName
+	}
+	return strings.TrimPrefix(fullName, pkg+".")
+}
+
+// alias returns the name of the imported module of the package.
+func alias(pkg string) string {
+	return strings.ReplaceAll(module(pkg), ".", "_")
+}
+
+// scalarType returns the type of the scalar value type. The 64-bit integers and the bytes are strings.
+func scalarType(name string) (string, bool) {
+	switch name {
+	case "int32", "uint32", "sint32", "fixed32", "sfixed32", "double", "float":
+		return "number", true
+	case "int64", "uint64", "sint64", "fixed64", "sfixed64", "string", "bytes":
+		return "string", true
+	case "bool":
+		return "boolean", true
+	}
+	return "", false
+}
+
+// wellKnownType returns the type of the well-known type which has a special JSON representation.
+func wellKnownType(fullName string) (string, bool) {
+	switch fullName {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return "string", true
+	case "google.protobuf.Struct":
+		return "{ [key: string]: unknown }", true
+	case "google.protobuf.Value":
+		return "unknown", true
+	case "google.protobuf.ListValue":
+		return "unknown[]", true
+	case "google.protobuf.NullValue":
+		return "null", true
+	case "google.protobuf.Empty":
+		return "Record<string, never>", true
+	case "google.protobuf.Any":
+		return `{ "@type": string; [key: string]: unknown }`, true
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue", "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return "number | null", true
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return "string | null", true
+	case "google.protobuf.BoolValue":
+		return "boolean | null", true
+	}
+	return "", false
+}
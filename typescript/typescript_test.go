@@ -0,0 +1,224 @@
+package typescript_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/schema"
+	"github.com/thought-machine/go-protoparser/typescript"
+)
+
+var testFS = fstest.MapFS{
+	"item.proto": {Data: []byte(`syntax = "proto3";
+package itempb;
+import "google/protobuf/timestamp.proto";
+import "shared.proto";
+
+// Item is an item.
+//
+// It is sold in */shops/*.
+message Item {
+  // Image is an image.
+  message Image {
+    int64 display_order = 1;
+    bytes binary = 2;
+  }
+  // The name.
+  // @deprecated Use title.
+  string item_name = 1;
+  repeated Image images = 2;
+  map<int32, Status> statuses = 3;
+  google.protobuf.Timestamp created_at = 4;
+  oneof condition {
+    double score = 5;
+    Item parent = 6;
+  }
+  uint32 count = 7 [deprecated = true];
+  repeated shared.Tag tags = 8;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 1;
+}
+`)},
+	"shared.proto": {Data: []byte(`syntax = "proto3";
+package shared;
+
+message Tag {
+  string name = 1 [json_name = "tag-name"];
+}
+`)},
+}
+
+func load(t *testing.T) *schema.Schema {
+	s, _ := schema.Load(context.Background(), testFS, []string{"item.proto"}, protoparser.WithImportPaths("."))
+	if s == nil {
+		t.Fatalf("got nil, but want the schema")
+	}
+	return s
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []typescript.Option
+		want    []*typescript.File
+	}{
+		{
+			name: "generating with the JSON names",
+			want: []*typescript.File{
+				{
+					Package: "itempb",
+					Path:    "itempb.d.ts",
+					Content: []byte(`// Code generated from item.proto. DO NOT EDIT.
+import type * as shared from "./shared";
+
+/**
+ * Item is an item.
+ *
+ * It is sold in *\/shops/*.
+ */
+export type Item = {
+  /**
+   * The name.
+   * @deprecated Use title.
+   */
+  itemName?: string;
+  images?: Item.Image[];
+  statuses?: { [key: string]: Status };
+  createdAt?: string;
+  /** @deprecated */
+  count?: number;
+  tags?: shared.Tag[];
+} & Item.Condition;
+
+export namespace Item {
+  /** At most one of the fields is set. */
+  export type Condition =
+    | { score: number; parent?: never }
+    | { score?: never; parent: Item }
+    | { score?: never; parent?: never };
+
+  /** Image is an image. */
+  export interface Image {
+    displayOrder?: string;
+    binary?: string;
+  }
+}
+
+export type Status = "STATUS_UNSPECIFIED" | "STATUS_OK";
+`),
+				},
+				{
+					Package: "shared",
+					Path:    "shared.d.ts",
+					Content: []byte(`// Code generated from shared.proto. DO NOT EDIT.
+
+export interface Tag {
+  "tag-name"?: string;
+}
+`),
+				},
+			},
+		},
+		{
+			name: "generating with the proto names",
+			options: []typescript.Option{
+				typescript.WithProtoNames(true),
+			},
+			want: []*typescript.File{
+				{
+					Package: "itempb",
+					Path:    "itempb.d.ts",
+					Content: []byte(`// Code generated from item.proto. DO NOT EDIT.
+import type * as shared from "./shared";
+
+/**
+ * Item is an item.
+ *
+ * It is sold in *\/shops/*.
+ */
+export type Item = {
+  /**
+   * The name.
+   * @deprecated Use title.
+   */
+  item_name?: string;
+  images?: Item.Image[];
+  statuses?: { [key: string]: Status };
+  created_at?: string;
+  /** @deprecated */
+  count?: number;
+  tags?: shared.Tag[];
+} & Item.Condition;
+
+export namespace Item {
+  /** At most one of the fields is set. */
+  export type Condition =
+    | { score: number; parent?: never }
+    | { score?: never; parent: Item }
+    | { score?: never; parent?: never };
+
+  /** Image is an image. */
+  export interface Image {
+    display_order?: string;
+    binary?: string;
+  }
+}
+
+export type Status = "STATUS_UNSPECIFIED" | "STATUS_OK";
+`),
+				},
+				{
+					Package: "shared",
+					Path:    "shared.d.ts",
+					Content: []byte(`// Code generated from shared.proto. DO NOT EDIT.
+
+export interface Tag {
+  name?: string;
+}
+`),
+				},
+			},
+		},
+	}
+
+	s := load(t)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := typescript.NewGenerator(test.options...).Generate(s)
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				for i := range got {
+					t.Logf("%s:\n%s", got[i].Path, got[i].Content)
+				}
+				t.Errorf("got %v, but want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_GeneratePackage_undefined(t *testing.T) {
+	fsys := fstest.MapFS{
+		"broken.proto": {Data: []byte(`syntax = "proto3";
+message Broken {
+  Missing missing = 1;
+}
+`)},
+	}
+	s, _ := schema.Load(context.Background(), fsys, []string{"broken.proto"})
+	var buf bytes.Buffer
+	err := typescript.NewGenerator().GeneratePackage(&buf, s, "")
+	if !errors.Is(err, schema.ErrUndefined) {
+		t.Errorf("got %v, but want %v", err, schema.ErrUndefined)
+	}
+}
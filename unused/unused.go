@@ -0,0 +1,153 @@
+// Package unused finds the imports and the definitions of a linked schema which are not used.
+//
+// A top-level message or enum is unreferenced if no field, RPC or extend outside it refers to it. It may still
+// be used by the code, like the root of a file format, so its nested types are considered used.
+// A nested message or enum is dead if it is not reachable from a top-level definition by the references,
+// since it is private to its parent by convention.
+package unused
+
+import (
+	"fmt"
+
+	"github.com/thought-machine/go-protoparser/depgraph"
+	"github.com/thought-machine/go-protoparser/parser/meta"
+	"github.com/thought-machine/go-protoparser/schema"
+)
+
+// Kind is the kind of a finding.
+type Kind string
+
+// The kinds.
+const (
+	// Import is an import whose file and public imports define nothing used by the importing file.
+	Import Kind = "import"
+	// Unreferenced is a top-level message or enum which nothing outside it refers to.
+	Unreferenced Kind = "unreferenced"
+	// Dead is a nested message or enum which is not reachable from a top-level definition.
+	Dead Kind = "dead"
+)
+
+// Finding is an unused import or definition.
+type Finding struct {
+	Kind Kind `json:"kind"`
+	// Name is the path of the import, or the full name of the definition.
+	Name    string        `json:"name"`
+	Message string        `json:"message"`
+	Pos     meta.Position `json:"pos"`
+}
+
+// String returns the finding like "a.proto:3:1: import "b.proto" is unused".
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Pos, f.Message)
+}
+
+// Find returns the unused imports and definitions of the files which are not imported by others,
+// in the order of the files, and the imports before the definitions of each file.
+func Find(s *schema.Schema) []*Finding {
+	imports := make(map[*schema.File][]*depgraph.UnusedImport)
+	for _, u := range depgraph.UnusedImports(s) {
+		imports[u.File] = append(imports[u.File], u)
+	}
+
+	g := depgraph.Types(s)
+	referenced, live := analyze(s, g)
+
+	var findings []*Finding
+	for _, f := range s.Files {
+		if f.Imported {
+			continue
+		}
+		for _, u := range imports[f] {
+			findings = append(findings, &Finding{
+				Kind:    Import,
+				Name:    u.Import.Path,
+				Message: fmt.Sprintf("import %q is unused", u.Import.Path),
+				Pos:     u.Import.Node.Meta.Pos,
+			})
+		}
+		for _, n := range g.Nodes {
+			if n.File != f.Path || n.Kind == depgraph.ServiceNode {
+				continue
+			}
+			pos, nested := definition(s, n)
+			switch {
+			case !nested && !referenced[n.ID]:
+				findings = append(findings, &Finding{
+					Kind:    Unreferenced,
+					Name:    n.ID,
+					Message: fmt.Sprintf("%s %q is never referenced", n.Kind, n.ID),
+					Pos:     pos,
+				})
+			case nested && !live[n.ID]:
+				findings = append(findings, &Finding{
+					Kind:    Dead,
+					Name:    n.ID,
+					Message: fmt.Sprintf("nested %s %q is not reachable from a top-level definition", n.Kind, n.ID),
+					Pos:     pos,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// analyze returns the definitions referenced from outside themselves, and the definitions reachable from
+// the top-level definitions, the services and the top-level extends.
+func analyze(s *schema.Schema, g *depgraph.Graph) (referenced, live map[string]bool) {
+	referenced = make(map[string]bool)
+	live = make(map[string]bool)
+	out := make(map[string][]string)
+	for _, e := range g.Edges {
+		out[e.From] = append(out[e.From], e.To)
+		if !schema.IsWithin(e.From, e.To) {
+			referenced[e.To] = true
+		}
+	}
+
+	var stack []string
+	for _, n := range g.Nodes {
+		if _, nested := definition(s, n); !nested {
+			stack = append(stack, n.ID)
+		}
+	}
+	// The top-level extends are not nodes of the graph.
+	for _, f := range s.Files {
+		for _, e := range f.Extends {
+			refs := []*schema.Ref{e.Type}
+			for _, field := range e.Fields {
+				refs = append(refs, field.Type)
+			}
+			for _, ref := range refs {
+				if ref.Message != nil || ref.Enum != nil {
+					referenced[ref.FullName] = true
+					stack = append(stack, ref.FullName)
+				}
+			}
+		}
+	}
+
+	for 0 < len(stack) {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if live[id] {
+			continue
+		}
+		live[id] = true
+		stack = append(stack, out[id]...)
+	}
+	return referenced, live
+}
+
+// definition returns the position of the definition of the node, and whether it is nested in a message.
+func definition(s *schema.Schema, n *depgraph.Node) (meta.Position, bool) {
+	if m := s.Message(n.ID); m != nil {
+		return m.Node.Meta.Pos, m.Parent != nil
+	}
+	if e := s.Enum(n.ID); e != nil {
+		return e.Node.Meta.Pos, e.Parent != nil
+	}
+	if service := s.Service(n.ID); service != nil {
+		return service.Node.Meta.Pos, false
+	}
+	return meta.Position{}, false
+}
@@ -0,0 +1,77 @@
+package unused_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	protoparser "github.com/thought-machine/go-protoparser"
+	"github.com/thought-machine/go-protoparser/schema"
+	"github.com/thought-machine/go-protoparser/unused"
+)
+
+func TestFind(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte(`syntax = "proto3";
+package a;
+import "b.proto";
+import "c.proto";
+message A {
+  Inner inner = 1;
+  message Inner {}
+  message Dead {
+    Dead2 dead = 1;
+  }
+  message Dead2 {}
+  message Self {
+    Self self = 1;
+  }
+}
+message Unused {
+  Unused self = 1;
+}
+enum E {
+  E_UNSPECIFIED = 0;
+}
+message Ext {}
+extend b.B {
+  Ext ext = 100;
+}
+service S {
+  rpc Get(A) returns (b.B);
+}
+`)},
+		"b.proto": {Data: []byte(`syntax = "proto3";
+package b;
+message B {
+  message Nested {}
+}
+`)},
+		"c.proto": {Data: []byte(`syntax = "proto3";
+package c;
+message C {}
+`)},
+	}
+	s, err := schema.Load(context.Background(), fsys, []string{"a.proto"}, protoparser.WithImportPaths("."))
+	if err != nil {
+		t.Fatalf("got err %v", err)
+	}
+
+	var got []string
+	for _, f := range unused.Find(s) {
+		got = append(got, f.String())
+	}
+	// The imported files are not reported, and a reference from a definition to itself is not a use.
+	want := []string{
+		`a.proto:4:1: import "c.proto" is unused`,
+		`a.proto:8:3: nested message "a.A.Dead" is not reachable from a top-level definition`,
+		`a.proto:11:3: nested message "a.A.Dead2" is not reachable from a top-level definition`,
+		`a.proto:12:3: nested message "a.A.Self" is not reachable from a top-level definition`,
+		`a.proto:16:1: message "a.Unused" is never referenced`,
+		`a.proto:19:1: enum "a.E" is never referenced`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, but want %v", got, want)
+	}
+}